@@ -26,6 +26,7 @@ class UnitHelper(object):
       "STORAGE_THRESHOLD": 0,
       "STATSD_ENDPOINT": "127.0.0.1:8125",
       "STORAGE": "/data",
+      "AUTH_DISABLED": "true",
     }
 
   def get_arch(self):
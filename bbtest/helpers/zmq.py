@@ -15,7 +15,7 @@ class ZMQHelper(threading.Thread):
     self.__mutex = threading.Lock()
     self.backlog = []
     self.context = context
-    self.vault_unit_message = re.compile(r'^VaultUnit\/([^\s]{1,100}) LedgerUnit\/([^\s]{1,100}) ([^\s]{1,100}) ([^\s]{1,100}) ([^\s]{1,100}) ([^\s]{1,100}) (-?\d{1,100}\.\d{1,100}|-?\d{1,100}) ([A-Z]{3})$')
+    self.vault_unit_message = re.compile(r'^VaultUnit\/([^\s]{1,100}) LedgerUnit\/([^\s]{1,100}) ([^\s]{1,100}) ([^\s]{1,100}) ([^\s]{1,100}) ([^\s]{1,100}) (-?\d{1,100}\.\d{1,100}|-?\d{1,100}) ([A-Z]{3})(?: 00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2})?$')
 
   def start(self):
     ctx = zmq.Context.instance()
@@ -51,6 +51,13 @@ def transaction_should_exist(context, tenant):
   assert os.path.isfile(path) is True, "{} does not exists but should".format(path)
 
 
+@then('transaction of tenant {tenant} should be referenced by tenant {counterparty}')
+def transaction_should_be_referenced(context, tenant, counterparty):
+  assert context.last_transaction_id, 'missing last_transaction_id from context'
+  path = '/data/t_{}/inbound/{}/{}'.format(counterparty, tenant, context.last_transaction_id)
+  assert os.path.isfile(path) is True, "{} does not exists but should".format(path)
+
+
 @when('following transaction is created from tenant {tenant}')
 def create_custom_transfer(context, tenant):
   context.http_request_body = context.text
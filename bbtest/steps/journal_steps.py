@@ -25,6 +25,9 @@ def check_transaction_state(context, tenant, transaction):
 
     for transfer in lines[1:]:
       item = transfer.split(' ')
+      if len(item) == 1:
+        # metadata of transaction
+        continue
 
       transfers.append({
         'id': item[0],
@@ -15,7 +15,10 @@
 package actor
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
+
 	system "github.com/jancajthaml-openbank/actor-system"
 )
 
@@ -49,6 +52,29 @@ func parseMessage(msg string) (interface{}, error) {
 	case RespTransactionDuplicate:
 		return new(TransactionDuplicate), nil
 
+	case RespListSagas:
+		if i == end {
+			return nil, fmt.Errorf("invalid message %s", msg)
+		}
+		result := new(SagasListed)
+		if err := json.Unmarshal([]byte(msg[i+1:]), &result.Sagas); err != nil {
+			return nil, fmt.Errorf("invalid message %s", msg)
+		}
+		now := time.Now()
+		for idx := range result.Sagas {
+			result.Sagas[idx].Age = now.Sub(result.Sagas[idx].StartedAt).Seconds()
+		}
+		return result, nil
+
+	case RespSagaAborted:
+		return new(SagaAborted), nil
+
+	case RespSagaMissing:
+		return new(SagaMissing), nil
+
+	case RespSagaNotAbortable:
+		return new(SagaNotAbortable), nil
+
 	default:
 		return nil, fmt.Errorf("unknown message %s", msg)
 	}
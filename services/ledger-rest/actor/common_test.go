@@ -0,0 +1,46 @@
+package actor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMessage(t *testing.T) {
+
+	t.Log("sagas listed")
+	{
+		message, err := parseMessage(`SR [{"actor":"transaction/1","transaction":"xyz","phase":"promise","startedAt":"2020-01-01T00:00:00Z","phaseStartedAt":"2020-01-01T00:00:01Z","outstanding":[{"tenant":"one","name":"A"}],"responses":[{"tenant":"two","name":"B","reply":"rejected","reason":"INSUFFICIENT_FUNDS"}]}]`)
+		require.Nil(t, err)
+		listed, ok := message.(*SagasListed)
+		require.True(t, ok)
+		require.Equal(t, 1, len(listed.Sagas))
+		assert.Equal(t, "xyz", listed.Sagas[0].Transaction)
+		assert.Equal(t, "promise", listed.Sagas[0].Phase)
+		assert.Equal(t, "A", listed.Sagas[0].Outstanding[0].Name)
+		assert.Equal(t, "INSUFFICIENT_FUNDS", listed.Sagas[0].Responses[0].Reason)
+		assert.True(t, listed.Sagas[0].Age > 0)
+	}
+
+	t.Log("sagas listed without payload")
+	{
+		_, err := parseMessage("SR")
+		assert.NotNil(t, err)
+	}
+
+	t.Log("saga abort replies")
+	{
+		message, err := parseMessage("S0 xyz")
+		require.Nil(t, err)
+		assert.IsType(t, new(SagaAborted), message)
+
+		message, err = parseMessage("S1 xyz")
+		require.Nil(t, err)
+		assert.IsType(t, new(SagaMissing), message)
+
+		message, err = parseMessage("S2 xyz")
+		require.Nil(t, err)
+		assert.IsType(t, new(SagaNotAbortable), message)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build go1.18
+// +build go1.18
+
+package actor
+
+import (
+	"testing"
+)
+
+func FuzzParseMessage(f *testing.F) {
+	f.Add("T0 xyz")
+	f.Add(`SR [{"actor":"transaction/1","transaction":"xyz","phase":"promise"}]`)
+	f.Add("SR")
+	f.Add("S0 xyz")
+	f.Add("EE")
+	f.Add("")
+	f.Add(" ")
+
+	f.Fuzz(func(t *testing.T, msg string) {
+		parseMessage(msg)
+	})
+}
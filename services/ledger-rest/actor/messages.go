@@ -16,6 +16,7 @@ package actor
 
 import (
 	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/tracing"
 	"strings"
 	"time"
 )
@@ -35,12 +36,26 @@ const (
 	RespTransactionDuplicate = "T4"
 	// RespTransactionMissing ledger message response code for "Transaction Missing"
 	RespTransactionMissing = "T5"
+	// ReqListSagas ledger message request code for "List Sagas"
+	ReqListSagas = "SL"
+	// RespListSagas ledger message response code for "Sagas Listed"
+	RespListSagas = "SR"
+	// ReqAbortSaga ledger message request code for "Abort Saga"
+	ReqAbortSaga = "SA"
+	// RespSagaAborted ledger message response code for "Saga Aborted"
+	RespSagaAborted = "S0"
+	// RespSagaMissing ledger message response code for "Saga Missing"
+	RespSagaMissing = "S1"
+	// RespSagaNotAbortable ledger message response code for "Saga Not Abortable"
+	RespSagaNotAbortable = "S2"
 	// FatalError ledger message response code for "Error"
 	FatalError = "EE"
 )
 
-// CreateTransactionMessage is message for creation of new transaction
-func CreateTransactionMessage(transaction model.Transaction) string {
+// CreateTransactionMessage is message for creation of new transaction,
+// metadata of transaction precede transfers, metadata of transfer are appended
+// to it and valid trace context is appended as traceparent
+func CreateTransactionMessage(transaction model.Transaction, trace tracing.SpanContext) string {
 	var buffer strings.Builder
 
 	numOfTransfers := len(transaction.Transfers)
@@ -61,10 +76,33 @@ func CreateTransactionMessage(transaction model.Transaction) string {
 		buffer.WriteString(transfer.Currency)
 		buffer.WriteString(";")
 		buffer.WriteString(transfer.ValueDate.Format(time.RFC3339))
+		if transfer.Metadata != nil && !transfer.Metadata.IsEmpty() {
+			buffer.WriteString(";")
+			buffer.WriteString(transfer.Metadata.Serialize())
+		}
 		if idx != numOfTransfers-1 {
 			buffer.WriteString(" ")
 		}
 	}
 
+	if trace.IsValid() {
+		buffer.WriteString(" ")
+		buffer.WriteString(trace.String())
+	}
+
+	if transaction.Metadata != nil && !transaction.Metadata.IsEmpty() {
+		return ReqCreateTransaction + " " + transaction.IDTransaction + " " + transaction.Metadata.Serialize() + " " + buffer.String()
+	}
+
 	return ReqCreateTransaction + " " + transaction.IDTransaction + " " + buffer.String()
 }
+
+// ListSagasMessage is message for listing of in-flight sagas
+func ListSagasMessage() string {
+	return ReqListSagas
+}
+
+// AbortSagaMessage is message for abort of saga of given transaction
+func AbortSagaMessage(transaction string) string {
+	return ReqAbortSaga + " " + transaction
+}
@@ -0,0 +1,46 @@
+package actor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/tracing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTransactionMessage(t *testing.T) {
+	transfer := model.Transfer{
+		IDTransfer: "1",
+		Credit:     model.Account{Tenant: "one", Name: "A"},
+		Debit:      model.Account{Tenant: "one", Name: "B"},
+		ValueDate:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Amount:     "1.0",
+		Currency:   "EUR",
+	}
+
+	t.Log("without metadata")
+	{
+		message := CreateTransactionMessage(model.Transaction{
+			IDTransaction: "xyz",
+			Transfers:     []model.Transfer{transfer},
+		}, tracing.SpanContext{})
+		assert.Equal(t, "NT xyz 1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z", message)
+	}
+
+	t.Log("with metadata")
+	{
+		withMetadata := transfer
+		withMetadata.Metadata = &model.Metadata{EndToEndID: "E2E 1;a"}
+		message := CreateTransactionMessage(model.Transaction{
+			IDTransaction: "xyz",
+			Metadata: &model.Metadata{
+				Description: "payroll",
+				Tags:        map[string]string{"batch": "7"},
+			},
+			Transfers: []model.Transfer{withMetadata, transfer},
+		}, tracing.SpanContext{})
+		assert.Equal(t, "NT xyz d=payroll&t.batch=7 1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z;e=E2E%201%3Ba 1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z", message)
+	}
+}
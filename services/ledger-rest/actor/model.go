@@ -14,6 +14,10 @@
 
 package actor
 
+import (
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+)
+
 // ReplyTimeout message
 type ReplyTimeout struct{}
 
@@ -34,3 +38,17 @@ type TransactionDuplicate struct{}
 
 // TransactioMissing message
 type TransactioMissing struct{}
+
+// SagasListed message
+type SagasListed struct {
+	Sagas []model.Saga
+}
+
+// SagaAborted message
+type SagaAborted struct{}
+
+// SagaMissing message
+type SagaMissing struct{}
+
+// SagaNotAbortable message
+type SagaNotAbortable struct{}
@@ -0,0 +1,81 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actor
+
+import (
+	"time"
+
+	system "github.com/jancajthaml-openbank/actor-system"
+	"github.com/rs/xid"
+)
+
+func askUnit(sys *System, tenant string, message string, timeout time.Duration) (result interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Msgf("Ask of %s recovered in %+v", tenant, r)
+			result = nil
+		}
+	}()
+
+	ch := make(chan interface{})
+	defer close(ch)
+
+	envelope := system.NewActor("saga/"+xid.New().String(), nil)
+	defer sys.UnregisterActor(envelope.Name)
+
+	sys.RegisterActor(envelope, func(state interface{}, context system.Context) {
+		ch <- context.Data
+	})
+
+	sys.SendMessage(
+		message,
+		system.Coordinates{
+			Region: "LedgerUnit/" + tenant,
+			Name:   envelope.Name,
+		},
+		system.Coordinates{
+			Region: "LedgerRest",
+			Name:   envelope.Name,
+		},
+	)
+
+	select {
+
+	case result = <-ch:
+		return
+
+	case <-time.After(timeout):
+		result = new(ReplyTimeout)
+		return
+	}
+}
+
+// ListSagas lists in-flight sagas of tenant
+func ListSagas(sys *System, tenant string) interface{} {
+	result := askUnit(sys, tenant, ListSagasMessage(), 5*time.Second)
+	if _, ok := result.(*ReplyTimeout); ok {
+		log.Warn().Msgf("List sagas of %s timeout", tenant)
+	}
+	return result
+}
+
+// AbortSaga forces saga of given transaction into rollback
+func AbortSaga(sys *System, tenant string, transaction string) interface{} {
+	result := askUnit(sys, tenant, AbortSagaMessage(transaction), 5*time.Second)
+	if _, ok := result.(*ReplyTimeout); ok {
+		log.Warn().Msgf("Abort saga %s/%s timeout", tenant, transaction)
+	}
+	return result
+}
@@ -17,12 +17,13 @@ package actor
 import (
 	system "github.com/jancajthaml-openbank/actor-system"
 	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/tracing"
 	"github.com/rs/xid"
 	"time"
 )
 
 // CreateTransaction creates new transaction
-func CreateTransaction(sys *System, tenant string, transaction model.Transaction) (result interface{}) {
+func CreateTransaction(sys *System, tenant string, trace tracing.SpanContext, transaction model.Transaction) (result interface{}) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Error().Msgf("CreateTransaction recovered in %+v", r)
@@ -41,7 +42,7 @@ func CreateTransaction(sys *System, tenant string, transaction model.Transaction
 	})
 
 	sys.SendMessage(
-		CreateTransactionMessage(transaction),
+		CreateTransactionMessage(transaction, trace),
 		system.Coordinates{
 			Region: "LedgerUnit/" + tenant,
 			Name:   envelope.Name,
@@ -58,7 +59,7 @@ func CreateTransaction(sys *System, tenant string, transaction model.Transaction
 		return
 
 	case <-time.After(25 * time.Second):
-		log.Warn().Msgf("Create transaction %s/%s timeout", tenant, transaction.IDTransaction)
+		log.Warn().Msgf("Create transaction %s/%s timeout [trace %s]", tenant, transaction.IDTransaction, trace.TraceIDString())
 		result = new(ReplyTimeout)
 		return
 	}
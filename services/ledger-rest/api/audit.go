@@ -0,0 +1,91 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/jancajthaml-openbank/ledger-rest/audit"
+	"github.com/jancajthaml-openbank/ledger-rest/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+const auditEntryKey = "audit"
+
+// Audit records action performed by request into audit trail once request is
+// served, handlers refine entry via AnnotateAudit
+func Audit(sink *audit.Sink, action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			entry := &audit.Entry{
+				Action:    action,
+				Tenant:    c.Param("tenant"),
+				Client:    clientOf(c.Request()),
+				RequestID: c.Request().Header.Get(echo.HeaderXRequestID),
+				Trace:     TraceOf(c).TraceIDString(),
+			}
+			c.Set(auditEntryKey, entry)
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			if principal, ok := c.Get(principalKey).(*auth.Principal); ok {
+				entry.Principal = principal.Name
+			}
+			entry.Status = c.Response().Status
+			if entry.Outcome == "" {
+				entry.Outcome = outcomeOfStatus(entry.Status)
+			}
+			sink.Record(*entry)
+			return nil
+		}
+	}
+}
+
+// clientOf returns address of peer of request, forwarding headers are set by
+// client and are not trusted
+func clientOf(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
+// AnnotateAudit amends audit entry of request if request is audited
+func AnnotateAudit(c echo.Context, annotate func(entry *audit.Entry)) {
+	if entry, ok := c.Get(auditEntryKey).(*audit.Entry); ok {
+		annotate(entry)
+	}
+}
+
+func outcomeOfStatus(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "unauthorized"
+	case status == http.StatusForbidden:
+		return "forbidden"
+	case status >= 500:
+		return "failed"
+	case status >= 400:
+		return "rejected"
+	default:
+		return "ok"
+	}
+}
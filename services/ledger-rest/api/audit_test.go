@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-rest/audit"
+	"github.com/jancajthaml-openbank/ledger-rest/auth"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "audit")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	sink := audit.NewSink(tmpdir, "test", 0, 0)
+	require.NotNil(t, sink)
+	defer sink.Cancel()
+
+	router := echo.New()
+
+	authenticated := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(principalKey, &auth.Principal{Name: "alice"})
+			return next(c)
+		}
+	}
+
+	router.POST("/tenant/:tenant", func(c echo.Context) error {
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Transaction = "xyz"
+			entry.Outcome = "committed"
+		})
+		return c.NoContent(http.StatusOK)
+	}, Audit(sink, "tenant.create"), authenticated)
+
+	router.DELETE("/tenant/:tenant", func(c echo.Context) error {
+		return c.NoContent(http.StatusForbidden)
+	}, Audit(sink, "tenant.delete"))
+
+	readEntries := func() []audit.Entry {
+		chunk, err := ioutil.ReadFile(filepath.Join(tmpdir, "test.jsonl"))
+		require.Nil(t, err)
+		entries := make([]audit.Entry, 0)
+		for _, line := range strings.Split(strings.TrimSpace(string(chunk)), "\n") {
+			var entry audit.Entry
+			require.Nil(t, json.Unmarshal([]byte(line), &entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	t.Log("records annotated entry with principal")
+	{
+		req := httptest.NewRequest(http.MethodPost, "/tenant/one", nil)
+		req.Header.Set(echo.HeaderXRequestID, "req-1")
+		req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+		req.Header.Set(echo.HeaderXRealIP, "203.0.113.8")
+		req.RemoteAddr = "198.51.100.1:4321"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		entries := readEntries()
+		require.Equal(t, 1, len(entries))
+		assert.Equal(t, "tenant.create", entries[0].Action)
+		assert.Equal(t, "committed", entries[0].Outcome)
+		assert.Equal(t, "one", entries[0].Tenant)
+		assert.Equal(t, "alice", entries[0].Principal)
+		assert.Equal(t, "req-1", entries[0].RequestID)
+		assert.Equal(t, "198.51.100.1", entries[0].Client)
+		assert.Equal(t, "xyz", entries[0].Transaction)
+		assert.Equal(t, http.StatusOK, entries[0].Status)
+	}
+
+	t.Log("derives outcome from status")
+	{
+		req := httptest.NewRequest(http.MethodDelete, "/tenant/one", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		entries := readEntries()
+		require.Equal(t, 2, len(entries))
+		assert.Equal(t, "tenant.delete", entries[1].Action)
+		assert.Equal(t, "forbidden", entries[1].Outcome)
+		assert.Equal(t, "", entries[1].Principal)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/jancajthaml-openbank/ledger-rest/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+const principalKey = "principal"
+
+// Authorization allows only principals permitted to perform operation on
+// tenant given by path parameter
+func Authorization(guard *auth.Guard, operation auth.Operation) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, err := guard.Authorize(c.Request(), c.Param("tenant"), operation)
+			switch err {
+
+			case nil:
+				if principal != nil {
+					c.Set(principalKey, principal)
+				}
+				return next(c)
+
+			case auth.ErrForbidden:
+				c.Response().WriteHeader(http.StatusForbidden)
+				return nil
+
+			default:
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				c.Response().WriteHeader(http.StatusUnauthorized)
+				return nil
+
+			}
+		}
+	}
+}
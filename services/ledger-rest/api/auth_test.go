@@ -0,0 +1,56 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-rest/auth"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorization(t *testing.T) {
+	handler := func(c echo.Context) error {
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	t.Log("open when authorization is disabled")
+	{
+		router := echo.New()
+		router.GET("/transaction/:tenant", handler, Authorization(nil, auth.OperationRead))
+
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_authorization")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(tmpdir, "policy.json"), []byte(`{"principals":{}}`), 0600))
+
+	guard, err := auth.NewGuard(filepath.Join(tmpdir, "policy.json"), "", false)
+	require.Nil(t, err)
+
+	t.Log("unauthenticated when credentials are missing")
+	{
+		router := echo.New()
+		router.GET("/transaction/:tenant", handler, Authorization(guard, auth.OperationRead))
+
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// CertificateReloader holds server key pair and reloads it when files change
+// on disk or when SIGHUP is received
+type CertificateReloader struct {
+	certPath    string
+	keyPath     string
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	hangup      chan os.Signal
+}
+
+// NewCertificateReloader returns certificate reloader fascade with key pair
+// already loaded
+func NewCertificateReloader(certPath string, keyPath string) *CertificateReloader {
+	reloader := &CertificateReloader{
+		certPath: filepath.Clean(certPath),
+		keyPath:  filepath.Clean(keyPath),
+		hangup:   make(chan os.Signal, 1),
+	}
+	if err := reloader.Reload(); err != nil {
+		log.Error().Msgf("Invalid cert %s and key %s", certPath, keyPath)
+		return nil
+	}
+	return reloader
+}
+
+func modificationTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// Reload loads key pair from disk, on failure previous key pair is kept
+func (reloader *CertificateReloader) Reload() error {
+	if reloader == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	certModTime, err := modificationTime(reloader.certPath)
+	if err != nil {
+		return err
+	}
+	keyModTime, err := modificationTime(reloader.keyPath)
+	if err != nil {
+		return err
+	}
+	certificate, err := tls.LoadX509KeyPair(reloader.certPath, reloader.keyPath)
+	if err != nil {
+		return err
+	}
+	reloader.mutex.Lock()
+	reloader.certificate = &certificate
+	reloader.certModTime = certModTime
+	reloader.keyModTime = keyModTime
+	reloader.mutex.Unlock()
+	return nil
+}
+
+// IsStale returns true if certificate or key changed on disk since last load
+func (reloader *CertificateReloader) IsStale() bool {
+	if reloader == nil {
+		return false
+	}
+	certModTime, err := modificationTime(reloader.certPath)
+	if err != nil {
+		return false
+	}
+	keyModTime, err := modificationTime(reloader.keyPath)
+	if err != nil {
+		return false
+	}
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+	return !certModTime.Equal(reloader.certModTime) || !keyModTime.Equal(reloader.keyModTime)
+}
+
+// GetCertificate returns currently loaded key pair, satisfies
+// tls.Config.GetCertificate
+func (reloader *CertificateReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if reloader == nil {
+		return nil, fmt.Errorf("nil pointer")
+	}
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+	return reloader.certificate, nil
+}
+
+// Setup subscribes to SIGHUP
+func (reloader *CertificateReloader) Setup() error {
+	if reloader == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	signal.Notify(reloader.hangup, syscall.SIGHUP)
+	return nil
+}
+
+// Done always returns done
+func (reloader *CertificateReloader) Done() <-chan interface{} {
+	done := make(chan interface{})
+	close(done)
+	return done
+}
+
+// Cancel unsubscribes from SIGHUP
+func (reloader *CertificateReloader) Cancel() {
+	if reloader == nil {
+		return
+	}
+	signal.Stop(reloader.hangup)
+}
+
+// Work reloads key pair if SIGHUP was received or files changed
+func (reloader *CertificateReloader) Work() {
+	if reloader == nil {
+		return
+	}
+	select {
+	case <-reloader.hangup:
+		log.Info().Msg("Received SIGHUP, reloading certificate")
+	default:
+		if !reloader.IsStale() {
+			return
+		}
+		log.Info().Msg("Certificate changed on disk, reloading")
+	}
+	if err := reloader.Reload(); err != nil {
+		log.Warn().Msgf("Failed to reload certificate %s and key %s, keeping previous one, %+v", reloader.certPath, reloader.keyPath, err)
+		return
+	}
+	log.Info().Msg("Certificate reloaded")
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeyPair(t *testing.T, dir string, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "server.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "server.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+func commonNameOf(t *testing.T, reloader *CertificateReloader) string {
+	certificate, err := reloader.GetCertificate(nil)
+	require.Nil(t, err)
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.Nil(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReloader(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_certificate")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	certPath := filepath.Join(tmpdir, "server.crt")
+	keyPath := filepath.Join(tmpdir, "server.key")
+
+	t.Log("missing key pair")
+	{
+		assert.Nil(t, NewCertificateReloader(certPath, keyPath))
+	}
+
+	writeKeyPair(t, tmpdir, "first")
+	reloader := NewCertificateReloader(certPath, keyPath)
+	require.NotNil(t, reloader)
+
+	t.Log("serves loaded key pair")
+	{
+		assert.False(t, reloader.IsStale())
+		assert.Equal(t, "first", commonNameOf(t, reloader))
+	}
+
+	t.Log("reloads rotated key pair")
+	{
+		writeKeyPair(t, tmpdir, "second")
+		future := time.Now().Add(time.Minute)
+		require.Nil(t, os.Chtimes(certPath, future, future))
+		require.Nil(t, os.Chtimes(keyPath, future, future))
+
+		assert.True(t, reloader.IsStale())
+		reloader.Work()
+		assert.False(t, reloader.IsStale())
+		assert.Equal(t, "second", commonNameOf(t, reloader))
+	}
+
+	t.Log("keeps previous key pair when rotated one is invalid")
+	{
+		require.Nil(t, ioutil.WriteFile(keyPath, []byte("garbage"), 0600))
+		future := time.Now().Add(2 * time.Minute)
+		require.Nil(t, os.Chtimes(keyPath, future, future))
+
+		reloader.Work()
+		assert.Equal(t, "second", commonNameOf(t, reloader))
+	}
+}
+
+func TestTLSVersion(t *testing.T) {
+	t.Log("supported versions")
+	{
+		_, err := TLSVersion("1.2")
+		assert.Nil(t, err)
+		_, err = TLSVersion("1.3")
+		assert.Nil(t, err)
+	}
+
+	t.Log("unsupported versions")
+	{
+		_, err := TLSVersion("1.0")
+		assert.NotNil(t, err)
+		_, err = TLSVersion("")
+		assert.NotNil(t, err)
+	}
+}
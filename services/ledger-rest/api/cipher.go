@@ -16,6 +16,7 @@ package api
 
 import (
 	"crypto/tls"
+	"fmt"
 )
 
 // CipherSuites list of trusted tls cipher suites
@@ -35,3 +36,15 @@ var CipherSuites = []uint16{
 	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
 	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
 }
+
+// TLSVersion returns tls protocol version for its textual representation
+func TLSVersion(value string) (uint16, error) {
+	switch value {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls version %s", value)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+)
+
+const eventsPollInterval = 250 * time.Millisecond
+const eventsHeartbeatInterval = 15 * time.Second
+
+// StreamTransactionEvents streams state changes of transactions of given
+// tenant as server sent events
+func StreamTransactionEvents(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		var last uint64
+		var err error
+
+		if lastEventID := c.Request().Header.Get("Last-Event-ID"); lastEventID != "" {
+			last, err = strconv.ParseUint(lastEventID, 10, 64)
+			if err != nil {
+				c.Response().WriteHeader(http.StatusBadRequest)
+				return nil
+			}
+		} else {
+			last, err = persistence.LoadLastEventSequence(storage, tenant)
+			if err != nil {
+				return err
+			}
+		}
+
+		// stream outlives connection write timeout of server
+		http.NewResponseController(c.Response().Writer).SetWriteDeadline(time.Time{})
+
+		c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+		c.Response().Header().Set("Cache-Control", "no-cache")
+		c.Response().Header().Set("Connection", "keep-alive")
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Flush()
+
+		poll := time.NewTicker(eventsPollInterval)
+		defer poll.Stop()
+		heartbeat := time.NewTicker(eventsHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			for {
+				event, err := persistence.LoadEvent(storage, tenant, last+1)
+				if err == persistence.ErrMalformedEvent {
+					log.Warn().Msgf("Skipping malformed event %d of tenant %s", last+1, tenant)
+					last++
+					continue
+				}
+				if err != nil {
+					log.Warn().Msgf("Unable to load event %d of tenant %s %+v", last+1, tenant, err)
+					return nil
+				}
+				if event == nil {
+					break
+				}
+				c.Response().Write(event.MarshalSSE())
+				last = event.Sequence
+			}
+			c.Response().Flush()
+
+			select {
+			case <-c.Request().Context().Done():
+				return nil
+			case <-heartbeat.C:
+				c.Response().Write([]byte(":\n\n"))
+			case <-poll.C:
+			}
+		}
+	}
+}
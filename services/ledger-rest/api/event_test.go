@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamTransactionEvents(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.WriteFile("t_x/event/00000000000000000001", []byte("a new 2020-01-01T00:00:00Z"))
+	storage.WriteFile("t_x/event/00000000000000000002", []byte("a committed 2020-01-01T00:00:01Z"))
+
+	router := echo.New()
+	router.GET("/transaction/:tenant/events", StreamTransactionEvents(storage))
+
+	t.Log("resumes after Last-Event-ID")
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 2*eventsPollInterval)
+		defer cancel()
+
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x/events", nil).WithContext(ctx)
+		req.Header.Set("Last-Event-ID", "1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "id: 2\nevent: committed\ndata: {\"id\":\"a\",\"status\":\"committed\",\"timestamp\":\"2020-01-01T00:00:01Z\"}\n\n", rec.Body.String())
+	}
+
+	t.Log("streams from beginning")
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 2*eventsPollInterval)
+		defer cancel()
+
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x/events", nil).WithContext(ctx)
+		req.Header.Set("Last-Event-ID", "0")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "id: 1\nevent: created\n")
+		assert.Contains(t, rec.Body.String(), "id: 2\nevent: committed\n")
+	}
+
+	t.Log("streams only new events without Last-Event-ID")
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 2*eventsPollInterval)
+		defer cancel()
+
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x/events", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "", rec.Body.String())
+	}
+
+	t.Log("invalid Last-Event-ID")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x/events", nil)
+		req.Header.Set("Last-Event-ID", "x")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/export"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+)
+
+// ExportTransactions streams transfers of transactions of given tenant in
+// requested format, chunk is limited by limit query parameter and cursor of
+// next chunk is returned in X-Export-Cursor header
+func ExportTransactions(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		format := c.QueryParam("format")
+		if format == "" {
+			format = export.FormatCSV
+		}
+
+		var filter export.Filter
+		for _, bound := range []struct {
+			target *time.Time
+			value  string
+		}{
+			{&filter.From, c.QueryParam("from")},
+			{&filter.To, c.QueryParam("to")},
+			{&filter.ValueFrom, c.QueryParam("valueFrom")},
+			{&filter.ValueTo, c.QueryParam("valueTo")},
+		} {
+			at, err := export.ParseBound(bound.value)
+			if err != nil {
+				c.Response().WriteHeader(http.StatusBadRequest)
+				return nil
+			}
+			*bound.target = at
+		}
+
+		limit := 0
+		if value := c.QueryParam("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+				c.Response().WriteHeader(http.StatusBadRequest)
+				return nil
+			}
+		}
+
+		writer, err := export.NewWriter(format, c.Response())
+		if err != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+
+		transactions, err := persistence.LoadTransactionsIDs(storage, tenant)
+		if err != nil {
+			return err
+		}
+		chunk, next := export.Chunk(transactions, c.QueryParam("after"), limit)
+
+		// export of large tenant outlives connection write timeout of server
+		http.NewResponseController(c.Response().Writer).SetWriteDeadline(time.Time{})
+
+		c.Response().Header().Set(echo.HeaderContentType, export.ContentType(format))
+		c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=\""+tenant+"."+format+"\"")
+		if next != "" {
+			c.Response().Header().Set("X-Export-Cursor", next)
+		}
+		c.Response().WriteHeader(http.StatusOK)
+
+		if err = export.Export(storage, tenant, chunk, filter, writer); err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			log.Warn().Msgf("Export of tenant %s interrupted %+v", tenant, err)
+			return nil
+		}
+		c.Response().Flush()
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportTransactions(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.WriteFile("t_x/transaction/a", []byte("committed\n1 x A x B 2020-01-01T00:00:00Z 1.5 EUR\n2 x A x B 2020-02-01T00:00:00Z 2 EUR\n"))
+	storage.WriteFile("t_x/transaction/b", []byte("rollbacked\n3 x A x B 2020-03-01T00:00:00Z 3 EUR\n"))
+	storage.WriteFile("t_x/transaction/c", []byte("committed\n4 x A x B 2020-04-01T00:00:00Z 4 EUR\n"))
+	storage.WriteFile("t_x/creation/a", []byte("2019-06-01T00:00:00Z"))
+
+	router := echo.New()
+	router.GET("/transaction/:tenant/export", ExportTransactions(storage))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x/export"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Log("exports all transfers as csv")
+	{
+		rec := get("")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "", rec.Header().Get("X-Export-Cursor"))
+
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		require.Nil(t, err)
+		require.Equal(t, 5, len(records))
+		assert.Equal(t, "transaction_id", records[0][0])
+		assert.Equal(t, []string{"a", "committed"}, records[1][:2])
+		assert.Equal(t, "1.5", records[1][9])
+	}
+
+	t.Log("resumes chunked export after cursor")
+	{
+		rec := get("?format=jsonl&limit=2")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/x-ndjson", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "b", rec.Header().Get("X-Export-Cursor"))
+		assert.Equal(t, 3, strings.Count(rec.Body.String(), "\n"))
+
+		rec = get("?format=jsonl&limit=2&after=b")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "", rec.Header().Get("X-Export-Cursor"))
+		assert.Contains(t, rec.Body.String(), "\"transaction_id\":\"c\"")
+		assert.Equal(t, 1, strings.Count(rec.Body.String(), "\n"))
+	}
+
+	t.Log("filters by value date")
+	{
+		rec := get("?format=jsonl&valueFrom=2020-02-01&valueTo=2020-04-01")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 2, strings.Count(rec.Body.String(), "\n"))
+		assert.Contains(t, rec.Body.String(), "\"transfer_id\":\"2\"")
+		assert.Contains(t, rec.Body.String(), "\"transfer_id\":\"3\"")
+	}
+
+	t.Log("filters by time of creation")
+	{
+		rec := get("?format=jsonl&to=2020-01-01")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 2, strings.Count(rec.Body.String(), "\n"))
+		assert.Contains(t, rec.Body.String(), "\"recorded_at\":\"2019-06-01T00:00:00Z\"")
+		assert.NotContains(t, rec.Body.String(), "\"transaction_id\":\"b\"")
+
+		rec = get("?format=jsonl&from=2020-01-01")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "\"transaction_id\":\"a\"")
+	}
+
+	t.Log("exports parquet")
+	{
+		rec := get("?format=parquet")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/vnd.apache.parquet", rec.Header().Get(echo.HeaderContentType))
+		assert.True(t, strings.HasPrefix(rec.Body.String(), "PAR1"))
+		assert.True(t, strings.HasSuffix(rec.Body.String(), "PAR1"))
+	}
+
+	t.Log("refuses invalid query")
+	{
+		assert.Equal(t, http.StatusBadRequest, get("?format=xml").Code)
+		assert.Equal(t, http.StatusBadRequest, get("?from=yesterday").Code)
+		assert.Equal(t, http.StatusBadRequest, get("?limit=-1").Code)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Instrumentation records route, status and latency of every request,
+// requests not matching any route are recorded as unmatched
+func Instrumentation(collector metrics.Metrics) echo.MiddlewareFunc {
+	var once sync.Once
+	routes := make(map[string]bool)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			once.Do(func() {
+				for _, route := range c.Echo().Routes() {
+					routes[route.Path] = true
+				}
+			})
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			route := c.Path()
+			if !routes[route] {
+				route = "unmatched"
+			}
+			collector.RequestServed(c.Request().Method, route, c.Response().Status, time.Now().Sub(start))
+			return nil
+		}
+	}
+}
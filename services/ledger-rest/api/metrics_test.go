@@ -0,0 +1,46 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMetrics struct {
+	served []string
+}
+
+func (metrics *mockMetrics) RequestServed(method string, route string, status int, duration time.Duration) {
+	metrics.served = append(metrics.served, fmt.Sprintf("%s %s %d", method, route, status))
+}
+
+func TestInstrumentation(t *testing.T) {
+	collector := new(mockMetrics)
+
+	router := echo.New()
+	router.Use(Instrumentation(collector))
+	router.GET("/transaction/:tenant/:id", func(c echo.Context) error {
+		if c.Param("id") == "broken" {
+			return fmt.Errorf("broken")
+		}
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+
+	for _, path := range []string{"/transaction/a/x", "/transaction/a/broken", "/unknown"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+	}
+
+	assert.Equal(t, []string{
+		"GET /transaction/:tenant/:id 200",
+		"GET /transaction/:tenant/:id 500",
+		"GET unmatched 404",
+	}, collector.served)
+}
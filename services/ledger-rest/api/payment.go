@@ -0,0 +1,125 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/actor"
+	"github.com/jancajthaml-openbank/ledger-rest/audit"
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/payment"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/xid"
+)
+
+// ImportPayments submits pain.001 credit transfer initiation of given tenant
+// as one transaction per payment information block and replies with pain.002
+// status report
+func ImportPayments(system *actor.System, mapping *payment.IBANMapping) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		b, err := ioutil.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, payment.MaxPain001Size))
+		defer c.Request().Body.Close()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.Response().WriteHeader(http.StatusRequestEntityTooLarge)
+				return nil
+			}
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return err
+		}
+
+		document, err := payment.ParsePain001(b)
+		if err != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Transaction = document.Initiation.MessageID
+		})
+
+		submit := func(transaction model.Transaction) (string, string) {
+			AnnotateAudit(c, func(entry *audit.Entry) {
+				for _, transfer := range transaction.Transfers {
+					entry.Amounts = append(entry.Amounts, audit.Amount{
+						Transfer: transaction.IDTransaction + "/" + transfer.IDTransfer,
+						Value:    transfer.Amount,
+						Currency: transfer.Currency,
+					})
+				}
+			})
+			return paymentStatus(actor.CreateTransaction(system, tenant, PropagatedTraceOf(c), transaction))
+		}
+
+		// every payment block awaits its saga, import of large initiation
+		// outlives connection write timeout of server
+		http.NewResponseController(c.Response().Writer).SetWriteDeadline(time.Time{})
+
+		report := payment.Import(xid.New().String(), document, tenant, mapping, submit, time.Now())
+
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Outcome = paymentOutcome(report.Report.Group.Status)
+		})
+
+		chunk, err := report.Serialize()
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXMLCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write(chunk)
+		c.Response().Flush()
+		return nil
+	}
+}
+
+func paymentStatus(reply interface{}) (string, string) {
+	switch reply.(type) {
+	case *actor.TransactionCreated:
+		return payment.StatusSettled, ""
+	case *actor.TransactionRejected, *actor.TransactionRefused:
+		return payment.StatusRejected, payment.ReasonNotSpecified
+	case *actor.TransactionDuplicate:
+		return payment.StatusRejected, payment.ReasonDuplicate
+	default:
+		return payment.StatusPending, ""
+	}
+}
+
+func paymentOutcome(status string) string {
+	switch status {
+	case payment.StatusSettled:
+		return "committed"
+	case payment.StatusRejected:
+		return "rejected"
+	case payment.StatusPartial:
+		return "partial"
+	default:
+		return "pending"
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-rest/payment"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPayments(t *testing.T) {
+	mapping, err := payment.NewIBANMapping(map[string]string{
+		"DE89370400440532013000": "x/A",
+	})
+	require.Nil(t, err)
+
+	router := echo.New()
+	router.POST("/payment/:tenant", ImportPayments(nil, mapping))
+
+	post := func(path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Log("malformed initiation")
+	{
+		assert.Equal(t, http.StatusBadRequest, post("/payment/x", "not xml").Code)
+		assert.Equal(t, http.StatusBadRequest, post("/payment/x", "<Document><CstmrCdtTrfInitn/></Document>").Code)
+	}
+
+	t.Log("refuses oversized initiation")
+	{
+		body := "<Document>" + strings.Repeat(" ", payment.MaxPain001Size) + "</Document>"
+		assert.Equal(t, http.StatusRequestEntityTooLarge, post("/payment/x", body).Code)
+	}
+
+	t.Log("reports rejection of unknown debtor account")
+	{
+		rec := post("/payment/y", `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"><CstmrCdtTrfInitn><GrpHdr><MsgId>M</MsgId></GrpHdr><PmtInf><PmtInfId>P</PmtInfId><DbtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></DbtrAcct><CdtTrfTxInf><PmtId><EndToEndId>E</EndToEndId></PmtId><Amt><InstdAmt Ccy="EUR">1</InstdAmt></Amt><CdtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></CdtrAcct></CdtTrfTxInf></PmtInf></CstmrCdtTrfInitn></Document>`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, echo.MIMEApplicationXMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+
+		var report payment.Pain002
+		require.Nil(t, xml.Unmarshal(rec.Body.Bytes(), &report))
+		assert.Equal(t, "M", report.Report.Group.MessageID)
+		assert.Equal(t, "pain.001.001.09", report.Report.Group.MessageName)
+		assert.Equal(t, payment.StatusRejected, report.Report.Group.Status)
+		require.Equal(t, 1, len(report.Report.Payments))
+		assert.Equal(t, payment.ReasonInvalidDebtorAccount, report.Report.Payments[0].Reason.Code)
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/audit"
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+	"github.com/jancajthaml-openbank/ledger-rest/reconciliation"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/xid"
+)
+
+// reconciliationMutex serializes updates of persisted reconciliations
+var reconciliationMutex sync.Mutex
+
+func reconciledAccount(c echo.Context) (model.Account, bool) {
+	account := model.Account{
+		Tenant: c.Param("tenant"),
+		Name:   c.Param("account"),
+	}
+	if account.Tenant == "" || account.Name == "" || account.Name == "." || account.Name == ".." {
+		return account, false
+	}
+	return account, true
+}
+
+func writeReconciliation(c echo.Context, entity *model.Reconciliation) error {
+	chunk, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	c.Response().WriteHeader(http.StatusOK)
+	c.Response().Write(chunk)
+	c.Response().Flush()
+	return nil
+}
+
+// CreateReconciliation reconciles account against external statement in body
+// and persists outcome, tolerance defaults to given one and may be overridden
+// by amountTolerance and dateTolerance query parameters, dateTolerance is
+// given in whole days
+func CreateReconciliation(storage localfs.Storage, defaultTolerance *reconciliation.Tolerance) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		account, ok := reconciledAccount(c)
+		if !ok {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		b, err := ioutil.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, reconciliation.MaxStatementSize))
+		defer c.Request().Body.Close()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.Response().WriteHeader(http.StatusRequestEntityTooLarge)
+				return nil
+			}
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return err
+		}
+
+		var first, last time.Time
+		for _, bound := range []struct {
+			target *time.Time
+			value  string
+		}{
+			{&first, c.QueryParam("from")},
+			{&last, c.QueryParam("to")},
+		} {
+			if bound.value == "" {
+				continue
+			}
+			if *bound.target, err = time.Parse("2006-01-02", bound.value); err != nil {
+				c.Response().WriteHeader(http.StatusBadRequest)
+				return nil
+			}
+		}
+
+		tolerance := *defaultTolerance
+		if value := c.QueryParam("amountTolerance"); value != "" {
+			override, err := reconciliation.NewTolerance(value, tolerance.Days)
+			if err != nil {
+				c.Response().WriteHeader(http.StatusBadRequest)
+				return nil
+			}
+			tolerance.Amount = override.Amount
+		}
+		if value := c.QueryParam("dateTolerance"); value != "" {
+			days, err := strconv.Atoi(value)
+			if err != nil || days < 0 {
+				c.Response().WriteHeader(http.StatusBadRequest)
+				return nil
+			}
+			tolerance.Days = days
+		}
+
+		format := c.QueryParam("format")
+		if format == "" {
+			format = reconciliation.DetectFormat(b)
+		}
+		external, err := reconciliation.ParseStatement(format, b)
+		if err != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+
+		internal, err := persistence.LoadAccountEntries(storage, account)
+		if err != nil {
+			return err
+		}
+
+		entity, err := reconciliation.Reconcile(xid.New().String(), account, internal, external, first, last, tolerance, time.Now())
+		if err != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Transaction = entity.ID
+		})
+
+		if err = persistence.CreateReconciliation(storage, entity); err != nil {
+			return err
+		}
+
+		return writeReconciliation(c, entity)
+	}
+}
+
+// ListReconciliations lists reconciliations of given account
+func ListReconciliations(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		account, ok := reconciledAccount(c)
+		if !ok {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		reconciliations, err := persistence.LoadReconciliationsIDs(storage, account)
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+
+		for idx, id := range reconciliations {
+			if idx == len(reconciliations)-1 {
+				c.Response().Write([]byte(id))
+			} else {
+				c.Response().Write([]byte(id + "\n"))
+			}
+			c.Response().Flush()
+		}
+
+		return nil
+	}
+}
+
+func loadReconciliation(c echo.Context, storage localfs.Storage) (*model.Reconciliation, error) {
+	account, ok := reconciledAccount(c)
+	if !ok {
+		return nil, nil
+	}
+	id := c.Param("id")
+	if _, err := xid.FromString(id); err != nil {
+		return nil, nil
+	}
+	return persistence.LoadReconciliation(storage, account, id)
+}
+
+// GetReconciliation returns reconciliation of given account
+func GetReconciliation(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		entity, err := loadReconciliation(c, storage)
+		if err != nil {
+			return err
+		}
+		if entity == nil {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		return writeReconciliation(c, entity)
+	}
+}
+
+func reconciliationErrorStatus(err error) int {
+	switch err {
+	case reconciliation.ErrUnknownBreak:
+		return http.StatusNotFound
+	case reconciliation.ErrAlreadyResolved, reconciliation.ErrNotResolved:
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ResolveReconciliationBreak records resolution of break of reconciliation
+func ResolveReconciliationBreak(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		b, err := ioutil.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, reconciliation.MaxResolutionSize))
+		defer c.Request().Body.Close()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.Response().WriteHeader(http.StatusRequestEntityTooLarge)
+				return nil
+			}
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return err
+		}
+
+		var req = new(model.ReconciliationResolution)
+		if json.Unmarshal(b, req) != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+
+		reconciliationMutex.Lock()
+		defer reconciliationMutex.Unlock()
+
+		entity, err := loadReconciliation(c, storage)
+		if err != nil {
+			return err
+		}
+		if entity == nil {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Transaction = entity.ID + "/" + c.Param("break")
+		})
+
+		if err = reconciliation.Resolve(entity, c.Param("break"), *req, time.Now()); err != nil {
+			c.Response().WriteHeader(reconciliationErrorStatus(err))
+			return nil
+		}
+		if err = persistence.UpdateReconciliation(storage, entity); err != nil {
+			return err
+		}
+
+		return writeReconciliation(c, entity)
+	}
+}
+
+// ReopenReconciliationBreak discards resolution of break of reconciliation
+func ReopenReconciliationBreak(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		reconciliationMutex.Lock()
+		defer reconciliationMutex.Unlock()
+
+		entity, err := loadReconciliation(c, storage)
+		if err != nil {
+			return err
+		}
+		if entity == nil {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Transaction = entity.ID + "/" + c.Param("break")
+		})
+
+		if err = reconciliation.Reopen(entity, c.Param("break")); err != nil {
+			c.Response().WriteHeader(reconciliationErrorStatus(err))
+			return nil
+		}
+		if err = persistence.UpdateReconciliation(storage, entity); err != nil {
+			return err
+		}
+
+		return writeReconciliation(c, entity)
+	}
+}
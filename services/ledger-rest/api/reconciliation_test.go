@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/reconciliation"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciliation(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_reconciliation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.WriteFile("t_x/transaction/a", []byte("committed\n1 x A x B 2020-01-01T00:00:00Z 10 EUR\n"))
+	storage.WriteFile("t_x/transaction/b", []byte("committed\n2 x B x A 2020-01-02T00:00:00Z 4 EUR\n"))
+	storage.WriteFile("t_x/transaction/c", []byte("rollbacked\n3 x A x B 2020-01-02T00:00:00Z 99 EUR\n"))
+
+	tolerance, err := reconciliation.NewTolerance("0", 0)
+	require.Nil(t, err)
+
+	router := echo.New()
+	router.POST("/reconciliation/:tenant/:account", CreateReconciliation(storage, tolerance))
+	router.GET("/reconciliation/:tenant/:account", ListReconciliations(storage))
+	router.GET("/reconciliation/:tenant/:account/:id", GetReconciliation(storage))
+	router.POST("/reconciliation/:tenant/:account/:id/:break", ResolveReconciliationBreak(storage))
+	router.DELETE("/reconciliation/:tenant/:account/:id/:break", ReopenReconciliationBreak(storage))
+
+	request := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) map[string]interface{} {
+		result := make(map[string]interface{})
+		require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		return result
+	}
+
+	var id string
+
+	t.Log("reconciles account against statement")
+	{
+		rec := request(http.MethodPost, "/reconciliation/x/A", "reference,value_date,amount,currency\n1,2020-01-01,10,EUR\nX,2020-01-02,-5,EUR\n")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+
+		var report model.Reconciliation
+		require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		id = report.ID
+		assert.Equal(t, "open", decode(rec)["status"])
+		assert.Equal(t, 1, len(report.Matched))
+		require.Equal(t, 1, len(report.UnmatchedInternal))
+		assert.Equal(t, "2", report.UnmatchedInternal[0].Reference)
+		require.Equal(t, 1, len(report.UnmatchedExternal))
+		assert.Equal(t, "X", report.UnmatchedExternal[0].Reference)
+	}
+
+	t.Log("lists and returns persisted reconciliation")
+	{
+		rec := request(http.MethodGet, "/reconciliation/x/A", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, id, rec.Body.String())
+
+		rec = request(http.MethodGet, "/reconciliation/x/A/"+id, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, id, decode(rec)["id"])
+
+		assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/reconciliation/x/B/"+id, "").Code)
+		assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/reconciliation/x/A/..", "").Code)
+	}
+
+	t.Log("resolves and reopens breaks")
+	{
+		path := "/reconciliation/x/A/" + id
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, path+"/i2", `{"action":"accepted"}`).Code)
+		assert.Equal(t, http.StatusNotFound, request(http.MethodPost, path+"/i9", `{"action":"accepted","note":"x"}`).Code)
+
+		rec := request(http.MethodPost, path+"/i2", `{"action":"matched","counterpart":"e2","note":"partial refund"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "reconciled", decode(rec)["status"])
+
+		assert.Equal(t, http.StatusConflict, request(http.MethodPost, path+"/e2", `{"action":"accepted","note":"x"}`).Code)
+		assert.Equal(t, "reconciled", decode(request(http.MethodGet, path, ""))["status"])
+
+		rec = request(http.MethodDelete, path+"/e2", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "open", decode(rec)["status"])
+		assert.Equal(t, http.StatusConflict, request(http.MethodDelete, path+"/e2", "").Code)
+	}
+
+	t.Log("overrides date tolerance in whole days")
+	{
+		rec := request(http.MethodPost, "/reconciliation/x/A?dateTolerance=2", "reference,value_date,amount,currency\n1,2020-01-01,10,EUR\n")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var report model.Reconciliation
+		require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.Equal(t, 2, report.DateTolerance)
+	}
+
+	t.Log("invalid statement")
+	{
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/reconciliation/x/A", "amount\n1\n").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/reconciliation/x/A?format=mt940", "").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/reconciliation/x/A?amountTolerance=-1", "value_date,amount,currency\n2020-01-01,1,EUR\n").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/reconciliation/x/A?dateTolerance=48h", "value_date,amount,currency\n2020-01-01,1,EUR\n").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/reconciliation/x/A?dateTolerance=-1", "value_date,amount,currency\n2020-01-01,1,EUR\n").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/reconciliation/x/A?from=2020-01-02&to=2020-01-01", "value_date,amount,currency\n2020-01-01,1,EUR\n").Code)
+	}
+
+	t.Log("oversized body")
+	{
+		statement := "value_date,amount,currency\n" + strings.Repeat("2020-01-01,1,EUR\n", reconciliation.MaxStatementSize/17+1)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, request(http.MethodPost, "/reconciliation/x/A", statement).Code)
+
+		resolution := `{"action":"accepted","note":"` + strings.Repeat("x", reconciliation.MaxResolutionSize) + `"}`
+		assert.Equal(t, http.StatusRequestEntityTooLarge, request(http.MethodPost, "/reconciliation/x/A/"+id+"/i2", resolution).Code)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jancajthaml-openbank/ledger-rest/actor"
+	"github.com/jancajthaml-openbank/ledger-rest/audit"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListSagas returns transactions being negotiated by ledger unit of given
+// tenant with accounts they wait for
+func ListSagas(system *actor.System) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		switch result := actor.ListSagas(system, tenant).(type) {
+
+		case *actor.SagasListed:
+			chunk, err := json.Marshal(result.Sagas)
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+			c.Response().WriteHeader(http.StatusOK)
+			c.Response().Write(chunk)
+			c.Response().Flush()
+			return nil
+
+		case *actor.ReplyTimeout:
+			c.Response().WriteHeader(http.StatusGatewayTimeout)
+			return nil
+
+		default:
+			c.Response().WriteHeader(http.StatusInternalServerError)
+			return nil
+
+		}
+	}
+}
+
+// AbortSaga forces saga of given transaction into rollback, saga already
+// committing is not abortable
+func AbortSaga(system *actor.System) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		id := c.Param("id")
+		if id == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Transaction = id
+		})
+
+		switch actor.AbortSaga(system, tenant, id).(type) {
+
+		case *actor.SagaAborted:
+			c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+			c.Response().WriteHeader(http.StatusOK)
+			c.Response().Write([]byte(id))
+			c.Response().Flush()
+			return nil
+
+		case *actor.SagaMissing:
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+
+		case *actor.SagaNotAbortable:
+			c.Response().WriteHeader(http.StatusConflict)
+			return nil
+
+		case *actor.ReplyTimeout:
+			c.Response().WriteHeader(http.StatusGatewayTimeout)
+			return nil
+
+		default:
+			c.Response().WriteHeader(http.StatusInternalServerError)
+			return nil
+
+		}
+	}
+}
@@ -17,13 +17,24 @@ package api
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/jancajthaml-openbank/ledger-rest/actor"
+	"github.com/jancajthaml-openbank/ledger-rest/audit"
+	"github.com/jancajthaml-openbank/ledger-rest/auth"
+	"github.com/jancajthaml-openbank/ledger-rest/metrics"
+	"github.com/jancajthaml-openbank/ledger-rest/offboarding"
+	"github.com/jancajthaml-openbank/ledger-rest/payment"
+	"github.com/jancajthaml-openbank/ledger-rest/reconciliation"
 	"github.com/jancajthaml-openbank/ledger-rest/system"
+	"github.com/jancajthaml-openbank/ledger-rest/tracing"
 
 	localfs "github.com/jancajthaml-openbank/local-fs"
 	"github.com/labstack/echo/v4"
@@ -37,6 +48,39 @@ const connectionWriteTimeout = 5 * time.Second
 type Server struct {
 	underlying *http.Server
 	listener   *net.TCPListener
+	cancel     context.CancelFunc
+}
+
+// ServerConfig represents settings of rest server
+type ServerConfig struct {
+	// BindAddress represents address server listens on
+	BindAddress string
+	// Port represents port server listens on
+	Port int
+	// TLSMinVersion represents minimal accepted tls version
+	TLSMinVersion string
+	// TLSMaxVersion represents maximal accepted tls version
+	TLSMaxVersion string
+	// ClientCA represents path to CA of client certificates, empty disables
+	// client certificates
+	ClientCA string
+	// AuthPolicy represents path to authorization policy
+	AuthPolicy string
+	// AuthKeys represents path to api keys
+	AuthKeys string
+	// AuthDisabled represents explicit opt out of authorization
+	AuthDisabled bool
+	// IBANMapping represents path to IBAN mapping, empty disables payment
+	// import
+	IBANMapping string
+	// ReconciliationAmountTolerance represents default maximal difference of
+	// amounts of paired entries
+	ReconciliationAmountTolerance string
+	// ReconciliationDateTolerance represents default maximal difference of
+	// value dates of paired entries in whole days
+	ReconciliationDateTolerance int
+	// RootStorage represents root of shared storage
+	RootStorage string
 }
 
 type tcpKeepAliveListener struct {
@@ -44,41 +88,156 @@ type tcpKeepAliveListener struct {
 }
 
 // NewServer returns new secure server instance
-func NewServer(port int, certPath string, keyPath string, rootStorage string, actorSystem *actor.System, systemControl system.Control, diskMonitor system.CapacityCheck, memoryMonitor system.CapacityCheck) *Server {
-	storage, err := localfs.NewPlaintextStorage(rootStorage)
+func NewServer(cfg ServerConfig, certificates *CertificateReloader, actorSystem *actor.System, systemControl system.Control, offboarder *offboarding.Offboarder, metricsCollector *metrics.Prometheus, tracer *tracing.Exporter, auditSink *audit.Sink, diskMonitor system.CapacityCheck, memoryMonitor system.CapacityCheck) *Server {
+	storage, err := localfs.NewPlaintextStorage(cfg.RootStorage)
 	if err != nil {
 		log.Error().Msgf("Failed to ensure storage %+v", err)
 		return nil
 	}
 
+	if metricsCollector == nil {
+		log.Error().Msg("Missing metrics")
+		return nil
+	}
+
+	if tracer == nil {
+		log.Error().Msg("Missing tracing")
+		return nil
+	}
+
+	if auditSink == nil {
+		log.Error().Msg("Missing audit")
+		return nil
+	}
+
 	router := echo.New()
+	router.Use(Tracing(tracer))
+	router.Use(Instrumentation(metricsCollector))
+	router.Use(TenantValidation)
+
+	if systemControl == nil {
+		log.Error().Msg("Missing system control")
+		return nil
+	}
+
+	if offboarder == nil {
+		log.Error().Msg("Missing offboarding")
+		return nil
+	}
+
+	if certificates == nil {
+		log.Error().Msg("Missing server certificate")
+		return nil
+	}
+
+	minVersion, err := TLSVersion(cfg.TLSMinVersion)
+	if err != nil {
+		log.Error().Msgf("Invalid minimal tls version %+v", err)
+		return nil
+	}
+	maxVersion, err := TLSVersion(cfg.TLSMaxVersion)
+	if err != nil {
+		log.Error().Msgf("Invalid maximal tls version %+v", err)
+		return nil
+	}
+	if minVersion > maxVersion {
+		log.Error().Msgf("Minimal tls version %s is greater than maximal %s", cfg.TLSMinVersion, cfg.TLSMaxVersion)
+		return nil
+	}
 
-	certificate, err := tls.LoadX509KeyPair(certPath, keyPath)
+	var clientCAs *x509.CertPool
+	if cfg.ClientCA != "" {
+		chunk, err := ioutil.ReadFile(filepath.Clean(cfg.ClientCA))
+		if err != nil {
+			log.Error().Msgf("Invalid client CA %s", cfg.ClientCA)
+			return nil
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(chunk) {
+			log.Error().Msgf("Invalid client CA %s", cfg.ClientCA)
+			return nil
+		}
+	}
+
+	guard, err := auth.NewGuard(cfg.AuthPolicy, cfg.AuthKeys, cfg.AuthDisabled)
 	if err != nil {
-		log.Error().Msgf("Invalid cert %s and key %s", certPath, keyPath)
+		log.Error().Msgf("Invalid authorization setup %+v", err)
 		return nil
 	}
 
+	var ibanMapping *payment.IBANMapping
+	if cfg.IBANMapping != "" {
+		ibanMapping, err = payment.LoadIBANMapping(cfg.IBANMapping)
+		if err != nil {
+			log.Error().Msgf("Invalid IBAN mapping %+v", err)
+			return nil
+		}
+	}
+
+	tolerance, err := reconciliation.NewTolerance(cfg.ReconciliationAmountTolerance, cfg.ReconciliationDateTolerance)
+	if err != nil {
+		log.Error().Msgf("Invalid reconciliation tolerance %+v", err)
+		return nil
+	}
+
+	read := Authorization(guard, auth.OperationRead)
+	transact := Authorization(guard, auth.OperationTransact)
+	administer := Authorization(guard, auth.OperationAdminister)
+
 	router.GET("/health", HealtCheck(memoryMonitor, diskMonitor))
 	router.HEAD("/health", HealtCheckPing(memoryMonitor, diskMonitor))
+	router.GET("/metrics", echo.WrapHandler(metricsCollector), read)
 
-	router.GET("/tenant", ListTenants(systemControl))
-	router.POST("/tenant/:tenant", CreateTenant(systemControl))
-	router.DELETE("/tenant/:tenant", DeleteTenant(systemControl))
+	router.GET("/tenant", ListTenants(systemControl, cfg.RootStorage, storage), administer)
+	router.GET("/tenant/:tenant", GetTenant(systemControl, cfg.RootStorage, storage), administer)
+	router.POST("/tenant/:tenant", CreateTenant(systemControl, offboarder), Audit(auditSink, "tenant.create"), administer)
+	router.DELETE("/tenant/:tenant", DeleteTenant(offboarder), Audit(auditSink, "tenant.delete"), administer)
+	router.GET("/tenant/:tenant/config", GetTenantConfig(storage), read)
+	router.PUT("/tenant/:tenant/config", UpdateTenantConfig(storage), Audit(auditSink, "tenant.config.update"), administer)
 
-	router.GET("/transaction/:tenant/:id", GetTransaction(storage))
-	router.POST("/transaction/:tenant", CreateTransaction(storage, actorSystem))
-	router.GET("/transaction/:tenant", GetTransactions(storage))
+	router.GET("/transaction/:tenant/events", StreamTransactionEvents(storage), read)
+	router.GET("/transaction/:tenant/export", ExportTransactions(storage), read)
+	router.GET("/transaction/:tenant/:id", GetTransaction(storage), read)
+	router.GET("/transaction/:tenant/:origin/:id", GetInboundTransaction(storage), read)
+	router.POST("/transaction/:tenant", CreateTransaction(storage, actorSystem), Audit(auditSink, "transaction.create"), transact, Intake(offboarder))
+	router.GET("/transaction/:tenant", GetTransactions(storage), read)
+
+	router.GET("/statement/:tenant/:account", GetStatement(storage), read)
+
+	if ibanMapping != nil {
+		router.POST("/payment/:tenant", ImportPayments(actorSystem, ibanMapping), Audit(auditSink, "payment.import"), transact, Intake(offboarder))
+	}
+
+	router.POST("/reconciliation/:tenant/:account", CreateReconciliation(storage, tolerance), Audit(auditSink, "reconciliation.create"), administer)
+	router.GET("/reconciliation/:tenant/:account", ListReconciliations(storage), read)
+	router.GET("/reconciliation/:tenant/:account/:id", GetReconciliation(storage), read)
+	router.POST("/reconciliation/:tenant/:account/:id/:break", ResolveReconciliationBreak(storage), Audit(auditSink, "reconciliation.resolve"), administer)
+	router.DELETE("/reconciliation/:tenant/:account/:id/:break", ReopenReconciliationBreak(storage), Audit(auditSink, "reconciliation.reopen"), administer)
+
+	router.GET("/saga/:tenant", ListSagas(actorSystem), administer)
+	router.DELETE("/saga/:tenant/:id", AbortSaga(actorSystem), Audit(auditSink, "saga.abort"), administer)
+
+	router.GET("/webhook/:tenant", ListWebhooks(storage), read)
+	router.POST("/webhook/:tenant", CreateWebhook(storage), Audit(auditSink, "webhook.create"), administer)
+	router.GET("/webhook/:tenant/:id", GetWebhook(storage), read)
+	router.DELETE("/webhook/:tenant/:id", DeleteWebhook(storage), Audit(auditSink, "webhook.delete"), administer)
+	router.GET("/webhook/:tenant/:id/deadletter", ListWebhookDeadLetters(storage), read)
+
+	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Server{
+		cancel: cancel,
 		underlying: &http.Server{
-			Addr:         fmt.Sprintf("127.0.0.1:%d", port),
+			Addr: net.JoinHostPort(cfg.BindAddress, strconv.Itoa(cfg.Port)),
+			BaseContext: func(_ net.Listener) context.Context {
+				return ctx
+			},
 			ReadTimeout:  connectionReadTimeout,
 			WriteTimeout: connectionWriteTimeout,
 			Handler:      router,
 			TLSConfig: &tls.Config{
-				MinVersion:               tls.VersionTLS12,
-				MaxVersion:               tls.VersionTLS12,
+				MinVersion:               minVersion,
+				MaxVersion:               maxVersion,
 				PreferServerCipherSuites: true,
 				InsecureSkipVerify:       false,
 				CurvePreferences: []tls.CurveID{
@@ -86,10 +245,10 @@ func NewServer(port int, certPath string, keyPath string, rootStorage string, ac
 					tls.CurveP384,
 					tls.CurveP256,
 				},
-				CipherSuites: CipherSuites,
-				Certificates: []tls.Certificate{
-					certificate,
-				},
+				CipherSuites:   CipherSuites,
+				ClientAuth:     clientAuth(clientCAs),
+				ClientCAs:      clientCAs,
+				GetCertificate: certificates.GetCertificate,
 			},
 			TLSNextProto: make(map[string]func(*http.Server, *tls.Conn, http.Handler), 0),
 		},
@@ -97,6 +256,13 @@ func NewServer(port int, certPath string, keyPath string, rootStorage string, ac
 	}
 }
 
+func clientAuth(clientCAs *x509.CertPool) tls.ClientAuthType {
+	if clientCAs == nil {
+		return tls.NoClientCert
+	}
+	return tls.VerifyClientCertIfGiven
+}
+
 // Setup initializes TCP listener
 func (server *Server) Setup() error {
 	if server == nil {
@@ -122,6 +288,7 @@ func (server *Server) Cancel() {
 	if server == nil {
 		return
 	}
+	server.cancel()
 	ctx, cancel := context.WithTimeout(context.Background(), connectionWriteTimeout)
 	defer cancel()
 	server.underlying.Shutdown(ctx)
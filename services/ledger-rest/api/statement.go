@@ -0,0 +1,73 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+	"github.com/jancajthaml-openbank/ledger-rest/statement"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/xid"
+)
+
+// GetStatement returns camt.053 statement of account for period given by from
+// and to dates, both inclusive
+func GetStatement(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		account := model.Account{
+			Tenant: c.Param("tenant"),
+			Name:   c.Param("account"),
+		}
+		if account.Tenant == "" || account.Name == "" || account.Name == "." || account.Name == ".." {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		first, err := time.Parse("2006-01-02", c.QueryParam("from"))
+		if err != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+		last, err := time.Parse("2006-01-02", c.QueryParam("to"))
+		if err != nil || last.Before(first) {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+
+		entries, err := persistence.LoadAccountEntries(storage, account)
+		if err != nil {
+			return err
+		}
+		document, err := statement.NewCamt053(xid.New().String(), account, entries, first, last, time.Now())
+		if err != nil {
+			return err
+		}
+		chunk, err := document.Serialize()
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXMLCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write(chunk)
+		c.Response().Flush()
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-rest/statement"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatement(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_statement")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.WriteFile("t_x/transaction/a", []byte("committed\n1 x A x B 2020-01-01T00:00:00Z 10 EUR\n"))
+	storage.WriteFile("t_x/transaction/b", []byte("rollbacked\n2 x A x B 2020-01-02T00:00:00Z 99 EUR\n"))
+	storage.WriteFile("t_x/transaction/c", []byte("committed\n3 x B x A 2020-01-03T00:00:00Z 4 EUR\n"))
+	storage.WriteFile("t_y/transaction/d", []byte("committed\n4 x A y C 2020-01-04T00:00:00Z 1.5 EUR\n"))
+	storage.WriteFile("t_x/inbound/y/d", []byte("committed"))
+
+	router := echo.New()
+	router.GET("/statement/:tenant/:account", GetStatement(storage))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Log("states committed transfers of account including inbound")
+	{
+		rec := get("/statement/x/A?from=2020-01-02&to=2020-01-31")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, echo.MIMEApplicationXMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+
+		var document statement.Camt053
+		require.Nil(t, xml.Unmarshal(rec.Body.Bytes(), &document))
+		require.Equal(t, 1, len(document.Statement.Statements))
+
+		stmt := document.Statement.Statements[0]
+		assert.Equal(t, "x/A", stmt.Account.ID.ID)
+		assert.Equal(t, "10", stmt.Balances[0].Amount.Value)
+		assert.Equal(t, "7.5", stmt.Balances[1].Amount.Value)
+		require.Equal(t, 2, len(stmt.Entries))
+		assert.Equal(t, "3", stmt.Entries[0].Reference)
+		assert.Equal(t, "DBIT", stmt.Entries[0].Indicator)
+		assert.Equal(t, "4", stmt.Entries[1].Reference)
+		assert.Equal(t, "CRDT", stmt.Entries[1].Indicator)
+	}
+
+	t.Log("account without entries")
+	{
+		rec := get("/statement/x/Z?from=2020-01-01&to=2020-01-31")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var document statement.Camt053
+		require.Nil(t, xml.Unmarshal(rec.Body.Bytes(), &document))
+		require.Equal(t, 1, len(document.Statement.Statements))
+
+		stmt := document.Statement.Statements[0]
+		assert.Equal(t, "x/Z", stmt.Account.ID.ID)
+		assert.Equal(t, "0", stmt.Balances[0].Amount.Value)
+		assert.Equal(t, "0", stmt.Balances[1].Amount.Value)
+		assert.Equal(t, 0, len(stmt.Entries))
+	}
+
+	t.Log("states only transactions in account index once tenant is indexed")
+	{
+		storage.WriteFile("t_x/account_index", []byte{})
+		storage.WriteFile("t_x/account/A/x/c", []byte{})
+		storage.WriteFile("t_x/account/A/y/d", []byte{})
+		storage.WriteFile("t_x/account/A/z/e", []byte{})
+
+		rec := get("/statement/x/A?from=2020-01-01&to=2020-01-31")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var document statement.Camt053
+		require.Nil(t, xml.Unmarshal(rec.Body.Bytes(), &document))
+		require.Equal(t, 1, len(document.Statement.Statements))
+
+		stmt := document.Statement.Statements[0]
+		assert.Equal(t, "0", stmt.Balances[0].Amount.Value)
+		assert.Equal(t, "2.5", stmt.Balances[1].Amount.Value)
+		assert.Equal(t, "DBIT", stmt.Balances[1].Indicator)
+		require.Equal(t, 2, len(stmt.Entries))
+		assert.Equal(t, "3", stmt.Entries[0].Reference)
+		assert.Equal(t, "4", stmt.Entries[1].Reference)
+	}
+
+	t.Log("invalid account")
+	{
+		assert.Equal(t, http.StatusNotFound, get("/statement/x/..?from=2020-01-01&to=2020-01-31").Code)
+	}
+
+	t.Log("invalid period")
+	{
+		assert.Equal(t, http.StatusBadRequest, get("/statement/x/A?from=2020-01-31&to=2020-01-01").Code)
+		assert.Equal(t, http.StatusBadRequest, get("/statement/x/A?from=2020-01-01").Code)
+	}
+}
@@ -15,16 +15,46 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/offboarding"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
 	"github.com/jancajthaml-openbank/ledger-rest/system"
 
+	localfs "github.com/jancajthaml-openbank/local-fs"
 	"github.com/labstack/echo/v4"
 )
 
-// CreateTenant enables ledger-unit@{tenant}
-func CreateTenant(control system.Control) func(c echo.Context) error {
+func loadTenant(name string, status system.UnitStatus, rootStorage string, storage localfs.Storage) (*model.Tenant, error) {
+	transactions, err := persistence.LoadTransactionsCount(storage, name)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := persistence.LoadTenantStorageUsage(rootStorage, name)
+	if err != nil {
+		return nil, err
+	}
+	lastScan, err := persistence.LoadLastFinalizerScan(storage, name)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Tenant{
+		Name:              name,
+		Status:            status.Status,
+		StatusChangedAt:   status.StatusChangedAt,
+		Transactions:      transactions,
+		StorageUsage:      usage,
+		LastFinalizerScan: lastScan,
+	}, nil
+}
+
+// CreateTenant enables ledger-unit@{tenant} and cancels scheduled purge of
+// its data
+func CreateTenant(control system.Control, offboarder *offboarding.Offboarder) func(c echo.Context) error {
 	return func(c echo.Context) error {
 		tenant := strings.TrimSpace(c.Param("tenant"))
 		if tenant == "" {
@@ -35,36 +65,133 @@ func CreateTenant(control system.Control) func(c echo.Context) error {
 		if err != nil {
 			return err
 		}
+		if err = offboarder.CancelPurge(tenant); err != nil {
+			return err
+		}
+		offboarder.Unfence(tenant)
 		c.Response().WriteHeader(http.StatusOK)
 		return nil
 	}
 }
 
-// DeleteTenant disables ledger-unit@{tenant}
-func DeleteTenant(control system.Control) func(c echo.Context) error {
+// DeleteTenant offboards ledger-unit@{tenant}, refuses when tenant has pending
+// transactions unless drain query parameter is set
+func DeleteTenant(offboarder *offboarding.Offboarder) func(c echo.Context) error {
 	return func(c echo.Context) error {
 		tenant := strings.TrimSpace(c.Param("tenant"))
 		if tenant == "" {
 			c.Response().WriteHeader(http.StatusNotFound)
 			return nil
 		}
-		err := control.DisableUnit("unit@" + tenant + ".service")
+		drain := c.QueryParam("drain") == "true"
+		// draining and archiving outlive connection write timeout of server
+		http.NewResponseController(c.Response().Writer).SetWriteDeadline(time.Time{})
+		report, err := offboarder.Offboard(c.Request().Context(), tenant, drain)
+		if report == nil {
+			return err
+		}
+		chunk, marshalErr := json.Marshal(report)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		switch err {
+		case nil:
+			c.Response().WriteHeader(http.StatusOK)
+		case offboarding.ErrPendingTransactions:
+			c.Response().WriteHeader(http.StatusConflict)
+		default:
+			log.Warn().Msgf("Offboarding of tenant %s failed %+v", tenant, err)
+			c.Response().WriteHeader(http.StatusInternalServerError)
+		}
+		c.Response().Write(chunk)
+		c.Response().Flush()
+		return nil
+	}
+}
+
+// Intake admits transaction intake of tenant unless tenant is being
+// offboarded
+func Intake(offboarder *offboarding.Offboarder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tenant := strings.TrimSpace(c.Param("tenant"))
+			if !offboarder.Admit(tenant) {
+				c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+				c.Response().WriteHeader(http.StatusConflict)
+				c.Response().Write([]byte("tenant is being offboarded"))
+				return nil
+			}
+			defer offboarder.Release(tenant)
+			return next(c)
+		}
+	}
+}
+
+// GetTenant returns status of ledger-unit@{tenant}
+func GetTenant(control system.Control, rootStorage string, storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := strings.TrimSpace(c.Param("tenant"))
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		properties, err := control.GetUnitsProperties("unit@")
+		if err != nil {
+			return err
+		}
+		status, ok := properties["ledger-unit@"+tenant+".service"]
+		if !ok {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		result, err := loadTenant(tenant, status, rootStorage, storage)
 		if err != nil {
 			return err
 		}
+		chunk, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write(chunk)
+		c.Response().Flush()
 		return nil
 	}
 }
 
-// ListTenants lists ledger-unit@
-func ListTenants(control system.Control) func(c echo.Context) error {
+// ListTenants lists ledger-unit@, with detail query parameter set returns
+// status of each of them
+func ListTenants(control system.Control, rootStorage string, storage localfs.Storage) func(c echo.Context) error {
 	return func(c echo.Context) error {
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		units, err := control.ListUnits("unit@")
 		if err != nil {
 			return err
 		}
+		if c.QueryParam("detail") == "true" {
+			properties, err := control.GetUnitsProperties("unit@")
+			if err != nil {
+				return err
+			}
+			result := make([]*model.Tenant, 0, len(units))
+			for _, unit := range units {
+				tenant, err := loadTenant(unit, properties["ledger-unit@"+unit+".service"], rootStorage, storage)
+				if err != nil {
+					return err
+				}
+				result = append(result, tenant)
+			}
+			chunk, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			c.Response().WriteHeader(http.StatusOK)
+			c.Response().Write(chunk)
+			c.Response().Flush()
+			return nil
+		}
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
 		c.Response().WriteHeader(http.StatusOK)
 		for idx, unit := range units {
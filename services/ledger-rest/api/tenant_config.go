@@ -0,0 +1,96 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+)
+
+// GetTenantConfig returns runtime configuration of given tenant
+func GetTenantConfig(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		config, err := persistence.LoadTenantConfig(storage, tenant)
+		if err != nil {
+			return err
+		}
+
+		chunk, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write(chunk)
+		c.Response().Flush()
+		return nil
+	}
+}
+
+// UpdateTenantConfig replaces runtime configuration of given tenant, ledger
+// unit of tenant picks it up without restart
+func UpdateTenantConfig(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		b, err := ioutil.ReadAll(c.Request().Body)
+		defer c.Request().Body.Close()
+		if err != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return err
+		}
+
+		var req = new(model.TenantConfig)
+		if err = json.Unmarshal(b, req); err != nil {
+			c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+			c.Response().WriteHeader(http.StatusBadRequest)
+			c.Response().Write([]byte(err.Error()))
+			return nil
+		}
+
+		if err = persistence.UpdateTenantConfig(storage, tenant, req); err != nil {
+			return err
+		}
+
+		chunk, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write(chunk)
+		c.Response().Flush()
+		return nil
+	}
+}
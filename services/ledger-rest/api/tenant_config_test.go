@@ -0,0 +1,86 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantConfig(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_tenant_config")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	require.Nil(t, err)
+
+	router := echo.New()
+	router.GET("/tenant/:tenant/config", GetTenantConfig(storage))
+	router.PUT("/tenant/:tenant/config", UpdateTenantConfig(storage))
+
+	t.Log("empty when not configured")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/tenant/a/config", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "{}", rec.Body.String())
+	}
+
+	t.Log("invalid configuration")
+	{
+		for body, reason := range map[string]string{
+			`{"currencies":["eur"]}`:       `invalid currency "eur"`,
+			`{"finalizerInterval":"10ms"}`: "invalid finalizerInterval, expected duration of at least 1s",
+			`{"maxAmount":"-1"}`:           "invalid maxAmount",
+			`{"maxAmount":"0"}`:            "invalid maxAmount",
+			`{"maxAmount":"1e10"}`:         "invalid maxAmount",
+			`{"maxAmount":"Inf"}`:          "invalid maxAmount",
+			`{"maxAmount":"NaN"}`:          "invalid maxAmount",
+			`{"maxAmount":"1_000"}`:        "invalid maxAmount",
+			`{"logLevel":"TRACE"}`:         "invalid logLevel, expected one of DEBUG, INFO, ERROR",
+			`{"maxTransfers":-1}`:          "invalid maxTransfers",
+			`{"unknown":true}`:             `json: unknown field "unknown"`,
+		} {
+			req := httptest.NewRequest(http.MethodPut, "/tenant/a/config", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+			assert.Equal(t, reason, rec.Body.String(), body)
+		}
+	}
+
+	t.Log("update and read back")
+	{
+		body := `{"currencies":["EUR","CZK"],"finalizerInterval":"1m","maxTransfers":10,"maxAmount":"1000.5","logLevel":"debug"}`
+		expected := `{"currencies":["EUR","CZK"],"finalizerInterval":"1m","maxTransfers":10,"maxAmount":"1000.5","logLevel":"DEBUG"}`
+
+		req := httptest.NewRequest(http.MethodPut, "/tenant/a/config", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, expected, rec.Body.String())
+
+		req = httptest.NewRequest(http.MethodGet, "/tenant/a/config", nil)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, expected, rec.Body.String())
+
+		data, err := storage.ReadFileFully("t_a/config")
+		require.Nil(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+}
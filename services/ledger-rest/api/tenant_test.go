@@ -1,15 +1,24 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/offboarding"
 	"github.com/jancajthaml-openbank/ledger-rest/system"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
 )
 
 type mockSystemControl struct {
@@ -34,8 +43,21 @@ func (sys mockSystemControl) ListUnits(prefix string) ([]string, error) {
 	return result, nil
 }
 
-func (sys mockSystemControl) GetUnitsProperties(name string) (map[string]system.UnitStatus, error) {
-	return make(map[string]system.UnitStatus), nil
+func (sys mockSystemControl) GetUnitsProperties(prefix string) (map[string]system.UnitStatus, error) {
+	if sys.circuitBreakListUnits == true {
+		return nil, fmt.Errorf("get units properties circuit break")
+	}
+	var result = make(map[string]system.UnitStatus)
+	for _, unit := range sys.units {
+		if !strings.HasPrefix(unit, prefix) {
+			continue
+		}
+		result["ledger-"+unit] = system.UnitStatus{
+			Status:          "running",
+			StatusChangedAt: 1,
+		}
+	}
+	return result, nil
 }
 
 func (sys mockSystemControl) DisableUnit(name string) error {
@@ -58,7 +80,7 @@ func TestCreateTenant(t *testing.T) {
 		mockControl := new(mockSystemControl)
 
 		router := echo.New()
-		router.POST("/tenant/:tenant", CreateTenant(mockControl))
+		router.POST("/tenant/:tenant", CreateTenant(mockControl, nil))
 
 		req := httptest.NewRequest(http.MethodPost, "/tenant/x", nil)
 		rec := httptest.NewRecorder()
@@ -73,7 +95,7 @@ func TestCreateTenant(t *testing.T) {
 		mockControl := new(mockSystemControl)
 
 		router := echo.New()
-		router.POST("/tenant/:tenant", CreateTenant(mockControl))
+		router.POST("/tenant/:tenant", CreateTenant(mockControl, nil))
 
 		req := httptest.NewRequest(http.MethodPost, "/tenant/ ", nil)
 		rec := httptest.NewRecorder()
@@ -88,7 +110,7 @@ func TestCreateTenant(t *testing.T) {
 		mockControl.circuitBreakEnableUnit = true
 
 		router := echo.New()
-		router.POST("/tenant/:tenant", CreateTenant(mockControl))
+		router.POST("/tenant/:tenant", CreateTenant(mockControl, nil))
 
 		req := httptest.NewRequest(http.MethodPost, "/tenant/x", nil)
 		rec := httptest.NewRecorder()
@@ -99,27 +121,36 @@ func TestCreateTenant(t *testing.T) {
 }
 
 func TestDeleteTenant(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_delete_tenant")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	require.Nil(t, err)
+
 	t.Log("happy path")
 	{
 		mockControl := new(mockSystemControl)
+		offboarder := offboarding.NewOffboarder(tmpdir, tmpdir+"/archive", time.Second, 0, mockControl)
 
 		router := echo.New()
-		router.DELETE("/tenant/:tenant", DeleteTenant(mockControl))
+		router.DELETE("/tenant/:tenant", DeleteTenant(offboarder))
 
 		req := httptest.NewRequest(http.MethodDelete, "/tenant/x", nil)
 		rec := httptest.NewRecorder()
 		router.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
-		assert.Equal(t, "", rec.Body.String())
+		assert.Equal(t, `{"tenant":"x","steps":[{"name":"fence","status":"ok","detail":"intake stopped"},{"name":"drain","status":"ok","detail":"no pending transactions"},{"name":"disable","status":"ok"},{"name":"archive","status":"skipped","detail":"no data"},{"name":"purge","status":"skipped","detail":"no data"}]}`, rec.Body.String())
 	}
 
 	t.Log("missing tenant")
 	{
 		mockControl := new(mockSystemControl)
+		offboarder := offboarding.NewOffboarder(tmpdir, tmpdir+"/archive", time.Second, 0, mockControl)
 
 		router := echo.New()
-		router.DELETE("/tenant/:tenant", DeleteTenant(mockControl))
+		router.DELETE("/tenant/:tenant", DeleteTenant(offboarder))
 
 		req := httptest.NewRequest(http.MethodDelete, "/tenant/ ", nil)
 		rec := httptest.NewRecorder()
@@ -128,13 +159,32 @@ func TestDeleteTenant(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, rec.Code)
 	}
 
+	t.Log("pending transactions")
+	{
+		require.Nil(t, storage.WriteFile("t_y/transaction/a", []byte("accepted")))
+
+		mockControl := new(mockSystemControl)
+		offboarder := offboarding.NewOffboarder(tmpdir, tmpdir+"/archive", time.Second, 0, mockControl)
+
+		router := echo.New()
+		router.DELETE("/tenant/:tenant", DeleteTenant(offboarder))
+
+		req := httptest.NewRequest(http.MethodDelete, "/tenant/y", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, `{"tenant":"y","steps":[{"name":"fence","status":"ok","detail":"intake stopped"},{"name":"drain","status":"failed","detail":"1 pending transactions"}]}`, rec.Body.String())
+	}
+
 	t.Log("disable unit fails")
 	{
 		mockControl := new(mockSystemControl)
 		mockControl.circuitBreakDisableUnit = true
+		offboarder := offboarding.NewOffboarder(tmpdir, tmpdir+"/archive", time.Second, 0, mockControl)
 
 		router := echo.New()
-		router.DELETE("/tenant/:tenant", DeleteTenant(mockControl))
+		router.DELETE("/tenant/:tenant", DeleteTenant(offboarder))
 
 		req := httptest.NewRequest(http.MethodDelete, "/tenant/x", nil)
 		rec := httptest.NewRecorder()
@@ -144,6 +194,101 @@ func TestDeleteTenant(t *testing.T) {
 	}
 }
 
+func TestIntake(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_intake")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	mockControl := new(mockSystemControl)
+	offboarder := offboarding.NewOffboarder(tmpdir, tmpdir+"/archive", 200*time.Millisecond, 0, mockControl)
+
+	release := make(chan struct{})
+	admitted := make(chan struct{})
+
+	router := echo.New()
+	router.POST("/transaction/:tenant", func(c echo.Context) error {
+		admitted <- struct{}{}
+		<-release
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	}, Intake(offboarder))
+	router.DELETE("/tenant/:tenant", DeleteTenant(offboarder))
+	router.POST("/tenant/:tenant", CreateTenant(mockControl, offboarder))
+
+	call := func(method string, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Log("intake in flight is pending transaction")
+	{
+		done := make(chan int)
+		go func() {
+			done <- call(http.MethodPost, "/transaction/x").Code
+		}()
+		<-admitted
+
+		rec := call(http.MethodDelete, "/tenant/x")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, `{"tenant":"x","steps":[{"name":"fence","status":"ok","detail":"intake stopped"},{"name":"drain","status":"failed","detail":"1 pending transactions"}]}`, rec.Body.String())
+
+		release <- struct{}{}
+		assert.Equal(t, http.StatusOK, <-done)
+	}
+
+	t.Log("intake resumes when offboarding is refused")
+	{
+		go func() {
+			<-admitted
+			release <- struct{}{}
+		}()
+		assert.Equal(t, http.StatusOK, call(http.MethodPost, "/transaction/x").Code)
+	}
+
+	t.Log("offboarding waits for intake in flight and rejects new one")
+	{
+		done := make(chan int)
+		go func() {
+			done <- call(http.MethodPost, "/transaction/x").Code
+		}()
+		<-admitted
+
+		offboarded := make(chan int)
+		go func() {
+			offboarded <- call(http.MethodDelete, "/tenant/x?drain=true").Code
+		}()
+
+		deadline := time.Now().Add(time.Second)
+		for offboarder.Admit("x") && time.Now().Before(deadline) {
+			offboarder.Release("x")
+			time.Sleep(time.Millisecond)
+		}
+
+		rec := call(http.MethodPost, "/transaction/x")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "tenant is being offboarded", rec.Body.String())
+
+		release <- struct{}{}
+		assert.Equal(t, http.StatusOK, <-done)
+		assert.Equal(t, http.StatusOK, <-offboarded)
+
+		assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/transaction/x").Code)
+	}
+
+	t.Log("onboarding resumes intake")
+	{
+		assert.Equal(t, http.StatusOK, call(http.MethodPost, "/tenant/x").Code)
+
+		go func() {
+			<-admitted
+			release <- struct{}{}
+		}()
+		assert.Equal(t, http.StatusOK, call(http.MethodPost, "/transaction/x").Code)
+	}
+}
+
 func TestGetTenants(t *testing.T) {
 	t.Log("happy path")
 	{
@@ -152,7 +297,7 @@ func TestGetTenants(t *testing.T) {
 		mockControl.units = append(mockControl.units, "unit@b.service")
 
 		router := echo.New()
-		router.GET("/tenant", ListTenants(mockControl))
+		router.GET("/tenant", ListTenants(mockControl, "", nil))
 
 		req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
 		rec := httptest.NewRecorder()
@@ -168,7 +313,7 @@ func TestGetTenants(t *testing.T) {
 		mockControl.circuitBreakListUnits = true
 
 		router := echo.New()
-		router.GET("/tenant", ListTenants(mockControl))
+		router.GET("/tenant", ListTenants(mockControl, "", nil))
 
 		req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
 		rec := httptest.NewRecorder()
@@ -177,3 +322,85 @@ func TestGetTenants(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	}
 }
+
+func TestGetTenant(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_tenant")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	require.Nil(t, err)
+	require.Nil(t, storage.WriteFile("t_a/transaction/x", []byte("committed")))
+	require.Nil(t, storage.WriteFile("t_a/transaction/y", []byte("new")))
+	require.Nil(t, storage.WriteFile("t_a/finalizer", []byte("2020-01-01T00:00:00Z")))
+
+	mockControl := new(mockSystemControl)
+	mockControl.units = append(mockControl.units, "unit@a.service")
+	mockControl.units = append(mockControl.units, "unit@b.service")
+
+	t.Log("happy path")
+	{
+		router := echo.New()
+		router.GET("/tenant/:tenant", GetTenant(mockControl, tmpdir, storage))
+
+		req := httptest.NewRequest(http.MethodGet, "/tenant/a", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var tenant model.Tenant
+		require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &tenant))
+		assert.Equal(t, "a", tenant.Name)
+		assert.Equal(t, "running", tenant.Status)
+		assert.Equal(t, uint64(1), tenant.StatusChangedAt)
+		assert.Equal(t, 2, tenant.Transactions)
+		assert.Equal(t, uint64(len("committed")+len("new")+len("2020-01-01T00:00:00Z")), tenant.StorageUsage)
+		require.NotNil(t, tenant.LastFinalizerScan)
+		assert.True(t, tenant.LastFinalizerScan.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+	}
+
+	t.Log("tenant without data")
+	{
+		router := echo.New()
+		router.GET("/tenant/:tenant", GetTenant(mockControl, tmpdir, storage))
+
+		req := httptest.NewRequest(http.MethodGet, "/tenant/b", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"name":"b","status":"running","statusChangedAt":1,"transactions":0,"storageUsage":0,"lastFinalizerScan":null}`, rec.Body.String())
+	}
+
+	t.Log("unknown tenant")
+	{
+		router := echo.New()
+		router.GET("/tenant/:tenant", GetTenant(mockControl, tmpdir, storage))
+
+		req := httptest.NewRequest(http.MethodGet, "/tenant/c", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+
+	t.Log("detailed listing")
+	{
+		router := echo.New()
+		router.GET("/tenant", ListTenants(mockControl, tmpdir, storage))
+
+		req := httptest.NewRequest(http.MethodGet, "/tenant?detail=true", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var tenants []model.Tenant
+		require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &tenants))
+		require.Equal(t, 2, len(tenants))
+		assert.Equal(t, "a", tenants[0].Name)
+		assert.Equal(t, 2, tenants[0].Transactions)
+		assert.Equal(t, "b", tenants[1].Name)
+	}
+}
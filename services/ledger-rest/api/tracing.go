@@ -0,0 +1,82 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/tracing"
+
+	"github.com/labstack/echo/v4"
+)
+
+const traceContextKey = "trace"
+
+const propagatedTraceContextKey = "propagatedTrace"
+
+// Tracing continues trace of W3C traceparent header of request or starts new
+// one, span of request is exported once request is served, only trace
+// continued from traceparent header is propagated to ledger-unit
+func Tracing(exporter *tracing.Exporter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			parent, _ := tracing.ParseTraceparent(c.Request().Header.Get("traceparent"))
+			span := parent.Child()
+			c.Set(traceContextKey, span)
+			if parent.IsValid() {
+				c.Set(propagatedTraceContextKey, span)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			status := c.Response().Status
+
+			exporter.Export(tracing.Span{
+				Name:    c.Request().Method + " " + c.Path(),
+				Kind:    tracing.KindServer,
+				Context: span,
+				Parent:  parent,
+				Start:   start,
+				End:     time.Now(),
+				Attributes: map[string]string{
+					"http.method":      c.Request().Method,
+					"http.route":       c.Path(),
+					"http.target":      c.Request().URL.Path,
+					"http.status_code": strconv.Itoa(status),
+				},
+				Failed: status >= 500,
+			})
+			return nil
+		}
+	}
+}
+
+// TraceOf returns span context of request
+func TraceOf(c echo.Context) tracing.SpanContext {
+	span, _ := c.Get(traceContextKey).(tracing.SpanContext)
+	return span
+}
+
+// PropagatedTraceOf returns span context of request to be propagated to
+// ledger-unit, it is not valid when client sent no traceparent so that trace
+// started by ledger-rest stays local and vault orders keep their format
+func PropagatedTraceOf(c echo.Context) tracing.SpanContext {
+	span, _ := c.Get(propagatedTraceContextKey).(tracing.SpanContext)
+	return span
+}
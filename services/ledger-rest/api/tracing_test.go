@@ -0,0 +1,109 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/actor"
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/tracing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracing(t *testing.T) {
+	router := echo.New()
+	router.Use(Tracing(tracing.NewExporter("", "test")))
+
+	var captured tracing.SpanContext
+	var propagated tracing.SpanContext
+	router.GET("/trace", func(c echo.Context) error {
+		captured = TraceOf(c)
+		propagated = PropagatedTraceOf(c)
+		return c.NoContent(http.StatusOK)
+	})
+
+	t.Log("continues trace of traceparent header")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/trace", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", captured.TraceIDString())
+		assert.NotEqual(t, "00f067aa0ba902b7", captured.SpanIDString())
+		assert.Equal(t, captured, propagated)
+	}
+
+	t.Log("starts new trace without traceparent header")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/trace", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, captured.IsValid())
+		assert.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", captured.TraceIDString())
+		assert.False(t, propagated.IsValid())
+	}
+}
+
+// TestCreateTransactionMessageTrace keeps testdata/create_transaction_messages
+// in sync with messages sent to ledger-unit, ledger-unit replays them to verify
+// format of vault orders, span of ledger-rest is replaced by span of client as
+// it differs with every request
+func TestCreateTransactionMessageTrace(t *testing.T) {
+	router := echo.New()
+	router.Use(Tracing(tracing.NewExporter("", "test")))
+
+	transaction := model.Transaction{
+		IDTransaction: "xyz",
+		Transfers: []model.Transfer{
+			{
+				IDTransfer: "1",
+				Credit:     model.Account{Tenant: "one", Name: "A"},
+				Debit:      model.Account{Tenant: "two", Name: "B"},
+				ValueDate:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				Amount:     "1.5",
+				Currency:   "EUR",
+			},
+		},
+	}
+
+	var message string
+	router.POST("/transaction/:tenant", func(c echo.Context) error {
+		trace := PropagatedTraceOf(c)
+		message = actor.CreateTransactionMessage(transaction, trace)
+		if trace.IsValid() {
+			message = strings.Replace(message, trace.SpanIDString(), "00f067aa0ba902b7", 1)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	send := func(traceparent string) string {
+		req := httptest.NewRequest(http.MethodPost, "/transaction/one", nil)
+		if traceparent != "" {
+			req.Header.Set("traceparent", traceparent)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return message
+	}
+
+	actual := []string{
+		send(""),
+		send("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"),
+	}
+
+	data, err := ioutil.ReadFile("testdata/create_transaction_messages")
+	require.Nil(t, err)
+	assert.Equal(t, string(data), strings.Join(actual, "\n")+"\n")
+}
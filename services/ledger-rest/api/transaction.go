@@ -16,13 +16,16 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/jancajthaml-openbank/ledger-rest/actor"
+	"github.com/jancajthaml-openbank/ledger-rest/audit"
 	"github.com/jancajthaml-openbank/ledger-rest/model"
 	"github.com/jancajthaml-openbank/ledger-rest/persistence"
 	localfs "github.com/jancajthaml-openbank/local-fs"
 	"github.com/labstack/echo/v4"
 	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
 // GetTransaction returns transaction state
@@ -63,6 +66,50 @@ func GetTransaction(storage localfs.Storage) func(c echo.Context) error {
 	}
 }
 
+// GetInboundTransaction returns state of transaction of other tenant that
+// involves given tenant
+func GetInboundTransaction(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		origin := c.Param("origin")
+		if origin == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		id := c.Param("id")
+		if id == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		transaction, err := persistence.LoadInboundTransaction(storage, tenant, origin, id)
+		if err != nil {
+			return err
+		}
+
+		if transaction == nil {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		chunk, err := json.Marshal(transaction)
+		if err != nil {
+			return err
+		}
+
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write(chunk)
+		c.Response().Flush()
+		return nil
+	}
+}
+
 // CreateTransaction creates new transaction for given tenant
 func CreateTransaction(storage localfs.Storage, system *actor.System) func(c echo.Context) error {
 	return func(c echo.Context) error {
@@ -87,7 +134,25 @@ func CreateTransaction(storage localfs.Storage, system *actor.System) func(c ech
 			return nil
 		}
 
-		switch actor.CreateTransaction(system, tenant, *req).(type) {
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Transaction = req.IDTransaction
+			entry.Amounts = make([]audit.Amount, 0, len(req.Transfers))
+			for _, transfer := range req.Transfers {
+				entry.Amounts = append(entry.Amounts, audit.Amount{
+					Transfer: transfer.IDTransfer,
+					Value:    transfer.Amount,
+					Currency: transfer.Currency,
+				})
+			}
+		})
+
+		reply := actor.CreateTransaction(system, tenant, PropagatedTraceOf(c), *req)
+
+		AnnotateAudit(c, func(entry *audit.Entry) {
+			entry.Outcome = transactionOutcome(reply)
+		})
+
+		switch reply.(type) {
 
 		case *actor.TransactionCreated:
 			c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
@@ -122,7 +187,49 @@ func CreateTransaction(storage localfs.Storage, system *actor.System) func(c ech
 	}
 }
 
-// GetTransactions return existing transactions of given tenant
+// metadataFilter returns filter of transactions given by tag, endToEndId and
+// description query parameters, tag is either key or key=value
+func metadataFilter(c echo.Context) (model.MetadataFilter, error) {
+	filter := model.MetadataFilter{
+		Tags:        make(map[string]string),
+		EndToEndID:  c.QueryParam("endToEndId"),
+		Description: c.QueryParam("description"),
+	}
+	for _, tag := range c.QueryParams()["tag"] {
+		parts := strings.SplitN(tag, "=", 2)
+		if parts[0] == "" {
+			return filter, fmt.Errorf("invalid tag filter %q", tag)
+		}
+		if len(parts) == 2 {
+			filter.Tags[parts[0]] = parts[1]
+		} else {
+			filter.Tags[parts[0]] = ""
+		}
+	}
+	return filter, nil
+}
+
+func transactionOutcome(reply interface{}) string {
+	switch reply.(type) {
+	case *actor.TransactionCreated:
+		return "committed"
+	case *actor.TransactionRejected:
+		return "rollbacked"
+	case *actor.TransactionRefused:
+		return "refused"
+	case *actor.TransactionDuplicate:
+		return "duplicate"
+	case *actor.TransactionRace:
+		return "race"
+	case *actor.ReplyTimeout:
+		return "timeout"
+	default:
+		return "failed"
+	}
+}
+
+// GetTransactions return existing transactions of given tenant, tag,
+// endToEndId and description query parameters filter them by metadata
 func GetTransactions(storage localfs.Storage) func(c echo.Context) error {
 	return func(c echo.Context) error {
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
@@ -133,11 +240,48 @@ func GetTransactions(storage localfs.Storage) func(c echo.Context) error {
 			return nil
 		}
 
+		filter, err := metadataFilter(c)
+		if err != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+
 		transactions, err := persistence.LoadTransactionsIDs(storage, tenant)
 		if err != nil {
 			return err
 		}
 
+		inbound, err := persistence.LoadInboundTransactionsIDs(storage, tenant)
+		if err != nil {
+			return err
+		}
+
+		if !filter.IsEmpty() {
+			accepted := make([]string, 0)
+			for _, id := range transactions {
+				transaction, err := persistence.LoadTransaction(storage, tenant, id)
+				if err != nil {
+					return err
+				}
+				if filter.Accepts(transaction) {
+					accepted = append(accepted, id)
+				}
+			}
+			for _, reference := range inbound {
+				parts := strings.SplitN(reference, "/", 2)
+				transaction, err := persistence.LoadInboundTransaction(storage, tenant, parts[0], parts[1])
+				if err != nil {
+					return err
+				}
+				if filter.Accepts(transaction) {
+					accepted = append(accepted, reference)
+				}
+			}
+			transactions, inbound = accepted, nil
+		}
+
+		transactions = append(transactions, inbound...)
+
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
 		c.Response().WriteHeader(http.StatusOK)
 
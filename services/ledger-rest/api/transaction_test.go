@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTransactions(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_transactions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.WriteFile("t_x/transaction/a", []byte("committed\nd=March%20payroll&t.batch=7\n1 x A x B 2020-01-01T00:00:00Z 10 EUR\n"))
+	storage.WriteFile("t_x/transaction/b", []byte("committed\n2 x A x B 2020-01-02T00:00:00Z 4 EUR e=E2E-2&t.batch=8\n"))
+	storage.WriteFile("t_x/transaction/c", []byte("committed\n3 x A x B 2020-01-02T00:00:00Z 99 EUR\n"))
+	storage.WriteFile("t_y/transaction/d", []byte("committed\nt.batch=7\n4 x A y C 2020-01-04T00:00:00Z 1.5 EUR\n"))
+	storage.WriteFile("t_x/inbound/y/d", []byte("committed"))
+
+	router := echo.New()
+	router.GET("/transaction/:tenant", GetTransactions(storage))
+
+	list := func(query string) []string {
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		if rec.Body.Len() == 0 {
+			return []string{}
+		}
+		result := strings.Split(rec.Body.String(), "\n")
+		sort.Strings(result)
+		return result
+	}
+
+	t.Log("without filter")
+	{
+		assert.Equal(t, []string{"a", "b", "c", "y/d"}, list(""))
+	}
+
+	t.Log("by tag")
+	{
+		assert.Equal(t, []string{"a", "y/d"}, list("?tag=batch=7"))
+		assert.Equal(t, []string{"a", "b", "y/d"}, list("?tag=batch"))
+		assert.Equal(t, []string{}, list("?tag=batch=9"))
+	}
+
+	t.Log("by end to end id and description")
+	{
+		assert.Equal(t, []string{"b"}, list("?endToEndId=E2E-2"))
+		assert.Equal(t, []string{"a"}, list("?description=payroll"))
+		assert.Equal(t, []string{}, list("?description=payroll&tag=batch=8"))
+	}
+
+	t.Log("invalid filter")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/transaction/x?tag==7", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestGetInboundTransaction(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_inbound_transaction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.WriteFile("t_y/transaction/d", []byte("committed\n1 x A y B 2020-01-01T00:00:00Z 1 EUR\n2 y C z D 2020-01-01T00:00:00Z 2 EUR\n3 z E x F 2020-01-01T00:00:00Z 3 EUR\n"))
+	storage.WriteFile("t_x/inbound/y/d", []byte("committed"))
+	storage.WriteFile("t_z/inbound/y/d", []byte("committed"))
+
+	router := echo.New()
+	router.GET("/transaction/:tenant/:origin/:id", GetInboundTransaction(storage))
+
+	get := func(path string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		result := make(map[string]interface{})
+		require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		return result
+	}
+
+	transferIDs := func(transaction map[string]interface{}) []string {
+		result := make([]string, 0)
+		for _, transfer := range transaction["transfers"].([]interface{}) {
+			result = append(result, transfer.(map[string]interface{})["id"].(string))
+		}
+		return result
+	}
+
+	t.Log("counterparty sees only transfers involving it")
+	{
+		assert.Equal(t, []string{"1", "3"}, transferIDs(get("/transaction/x/y/d")))
+		assert.Equal(t, []string{"2", "3"}, transferIDs(get("/transaction/z/y/d")))
+	}
+}
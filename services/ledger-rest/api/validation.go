@@ -0,0 +1,44 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jancajthaml-openbank/ledger-rest/support/naming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TenantValidation rejects requests whose tenant or origin path parameter is
+// not valid tenant name
+func TenantValidation(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		for _, param := range []string{"tenant", "origin"} {
+			value := strings.TrimSpace(c.Param(param))
+			if value == "" {
+				continue
+			}
+			if err := naming.ValidateTenant(value); err != nil {
+				c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+				c.Response().WriteHeader(http.StatusBadRequest)
+				c.Response().Write([]byte(err.Error()))
+				return nil
+			}
+		}
+		return next(c)
+	}
+}
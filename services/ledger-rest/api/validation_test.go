@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantValidation(t *testing.T) {
+	router := echo.New()
+	router.Use(TenantValidation)
+	router.GET("/transaction/:tenant/:origin/:id", func(c echo.Context) error {
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+
+	t.Log("valid tenant and origin")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/transaction/a/b/x", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	t.Log("invalid tenant")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/transaction/a@b/b/x", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, `tenant name contains invalid character '@', allowed are letters, digits, "_" and "-"`, rec.Body.String())
+	}
+
+	t.Log("invalid origin")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/transaction/a/-b/x", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "tenant name must start with letter or digit", rec.Body.String())
+	}
+}
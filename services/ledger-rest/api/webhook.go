@@ -0,0 +1,193 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/xid"
+)
+
+// CreateWebhook subscribes new webhook to transaction events of given tenant
+func CreateWebhook(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		b, err := ioutil.ReadAll(c.Request().Body)
+		defer c.Request().Body.Close()
+		if err != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return err
+		}
+
+		var req = new(model.Webhook)
+		if json.Unmarshal(b, req) != nil {
+			c.Response().WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+		req.ID = xid.New().String()
+
+		if err = persistence.CreateWebhook(storage, tenant, req); err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte(req.ID))
+		c.Response().Flush()
+		return nil
+	}
+}
+
+// GetWebhook returns webhook of given tenant
+func GetWebhook(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		id := c.Param("id")
+		if id == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		webhook, err := persistence.LoadWebhook(storage, tenant, id)
+		if err != nil {
+			return err
+		}
+		if webhook == nil {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		chunk, err := json.Marshal(webhook)
+		if err != nil {
+			return err
+		}
+
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write(chunk)
+		c.Response().Flush()
+		return nil
+	}
+}
+
+// DeleteWebhook unsubscribes webhook of given tenant
+func DeleteWebhook(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		id := c.Param("id")
+		if id == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		ok, err := persistence.DeleteWebhook(storage, tenant, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	}
+}
+
+// ListWebhooks lists webhooks of given tenant
+func ListWebhooks(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		webhooks, err := persistence.LoadWebhooksIDs(storage, tenant)
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+
+		for idx, webhook := range webhooks {
+			if idx == len(webhooks)-1 {
+				c.Response().Write([]byte(webhook))
+			} else {
+				c.Response().Write([]byte(webhook + "\n"))
+			}
+			c.Response().Flush()
+		}
+
+		return nil
+	}
+}
+
+// ListWebhookDeadLetters lists sequences of events webhook gave up delivering
+func ListWebhookDeadLetters(storage localfs.Storage) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		tenant := c.Param("tenant")
+		if tenant == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		id := c.Param("id")
+		if id == "" {
+			c.Response().WriteHeader(http.StatusNotFound)
+			return nil
+		}
+
+		deadLetters, err := persistence.LoadWebhookDeadLettersIDs(storage, tenant, id)
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+
+		for idx, deadLetter := range deadLetters {
+			if idx == len(deadLetters)-1 {
+				c.Response().Write([]byte(deadLetter))
+			} else {
+				c.Response().Write([]byte(deadLetter + "\n"))
+			}
+			c.Response().Flush()
+		}
+
+		return nil
+	}
+}
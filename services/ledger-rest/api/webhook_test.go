@@ -0,0 +1,103 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_webhooks")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	require.Nil(t, err)
+
+	router := echo.New()
+	router.POST("/webhook/:tenant", CreateWebhook(storage))
+	router.GET("/webhook/:tenant", ListWebhooks(storage))
+	router.GET("/webhook/:tenant/:id", GetWebhook(storage))
+	router.DELETE("/webhook/:tenant/:id", DeleteWebhook(storage))
+	router.GET("/webhook/:tenant/:id/deadletter", ListWebhookDeadLetters(storage))
+
+	call := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Log("rejects invalid webhook")
+	{
+		rec := call(http.MethodPost, "/webhook/a", `{"url":"ftp://example.com","secret":"s"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = call(http.MethodGet, "/webhook/a", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "", rec.Body.String())
+	}
+
+	var id string
+
+	t.Log("creates webhook")
+	{
+		rec := call(http.MethodPost, "/webhook/a", `{"url":"https://example.com/hook","secret":"s","events":["committed"]}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		id = rec.Body.String()
+		require.NotEqual(t, "", id)
+
+		rec = call(http.MethodGet, "/webhook/a", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, id, rec.Body.String())
+	}
+
+	t.Log("gets webhook without secret")
+	{
+		rec := call(http.MethodGet, "/webhook/a/"+id, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"id":"`+id+`","url":"https://example.com/hook","events":["committed"]}`, rec.Body.String())
+
+		rec = call(http.MethodGet, "/webhook/a/unknown", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = call(http.MethodGet, "/webhook/b/"+id, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+
+	t.Log("lists dead letters")
+	{
+		rec := call(http.MethodGet, "/webhook/a/"+id+"/deadletter", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "", rec.Body.String())
+
+		require.Nil(t, persistence.CreateWebhookDeadLetter(storage, "a", id, 1, nil, "failed"))
+		require.Nil(t, persistence.CreateWebhookDeadLetter(storage, "a", id, 2, nil, "failed"))
+
+		rec = call(http.MethodGet, "/webhook/a/"+id+"/deadletter", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "00000000000000000001\n00000000000000000002", rec.Body.String())
+	}
+
+	t.Log("deletes webhook")
+	{
+		rec := call(http.MethodDelete, "/webhook/a/"+id, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = call(http.MethodDelete, "/webhook/a/"+id, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = call(http.MethodGet, "/webhook/a/"+id, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+{
+  "certificates": {
+    "cn:ops.local": "ops"
+  },
+  "principals": {
+    "ops": {
+      "*": ["administer", "read"]
+    },
+    "alice": {
+      "A": ["read", "transact"]
+    }
+  }
+}
+`
+
+func signToken(t *testing.T, key ed25519.PrivateKey, kid string, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","kid":"` + kid + `"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	signature := ed25519.Sign(key, []byte(header+"."+payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func setupGuard(t *testing.T) (*Guard, ed25519.PrivateKey, func()) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_auth")
+	require.Nil(t, err)
+
+	require.Nil(t, ioutil.WriteFile(filepath.Join(tmpdir, "policy.json"), []byte(testPolicy), 0600))
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.Nil(t, err)
+	require.Nil(t, os.MkdirAll(filepath.Join(tmpdir, "keys"), 0700))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(tmpdir, "keys", "k1.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	guard, err := NewGuard(filepath.Join(tmpdir, "policy.json"), filepath.Join(tmpdir, "keys"), false)
+	require.Nil(t, err)
+
+	return guard, privateKey, func() {
+		os.RemoveAll(tmpdir)
+	}
+}
+
+func TestNewGuard(t *testing.T) {
+	t.Log("refuses to run without policy")
+	{
+		guard, err := NewGuard("", "", false)
+		assert.NotNil(t, err)
+		assert.Nil(t, guard)
+	}
+
+	t.Log("allows everything when explicitly disabled")
+	{
+		guard, err := NewGuard("", "", true)
+		require.Nil(t, err)
+		assert.Nil(t, guard)
+
+		principal, err := guard.Authorize(httptest.NewRequest(http.MethodGet, "/", nil), "a", OperationAdminister)
+		assert.Nil(t, err)
+		assert.Nil(t, principal)
+	}
+
+	t.Log("enforces provided policy even when disabled")
+	{
+		tmpdir, err := ioutil.TempDir(os.TempDir(), "test_auth")
+		require.Nil(t, err)
+		defer os.RemoveAll(tmpdir)
+		require.Nil(t, ioutil.WriteFile(filepath.Join(tmpdir, "policy.json"), []byte(testPolicy), 0600))
+
+		guard, err := NewGuard(filepath.Join(tmpdir, "policy.json"), "", true)
+		require.Nil(t, err)
+		require.NotNil(t, guard)
+
+		_, err = guard.Authorize(httptest.NewRequest(http.MethodGet, "/", nil), "a", OperationRead)
+		assert.Equal(t, ErrUnauthenticated, err)
+	}
+
+	t.Log("starts with packaged default policy denying everyone")
+	{
+		guard, err := NewGuard("../../../packaging/policy.json", "", false)
+		require.Nil(t, err)
+		require.NotNil(t, guard)
+		assert.Equal(t, 0, len(guard.policy.Principals))
+
+		_, err = guard.Authorize(httptest.NewRequest(http.MethodGet, "/", nil), "a", OperationRead)
+		assert.Equal(t, ErrUnauthenticated, err)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	guard, _, cleanup := setupGuard(t)
+	defer cleanup()
+
+	policy := guard.policy
+
+	t.Log("wildcard grant")
+	{
+		assert.True(t, policy.IsAllowed("ops", "A", OperationAdminister))
+		assert.True(t, policy.IsAllowed("ops", "", OperationAdminister))
+		assert.False(t, policy.IsAllowed("ops", "A", OperationTransact))
+	}
+
+	t.Log("tenant grant")
+	{
+		assert.True(t, policy.IsAllowed("alice", "A", OperationTransact))
+		assert.False(t, policy.IsAllowed("alice", "B", OperationRead))
+		assert.False(t, policy.IsAllowed("alice", "", OperationRead))
+	}
+
+	t.Log("unknown principal")
+	{
+		assert.False(t, policy.IsAllowed("bob", "A", OperationRead))
+	}
+}
+
+func TestGuard(t *testing.T) {
+	guard, key, cleanup := setupGuard(t)
+	defer cleanup()
+
+	expiry := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+
+	t.Log("nil guard allows everything")
+	{
+		var disabled *Guard
+		principal, err := disabled.Authorize(httptest.NewRequest(http.MethodGet, "/", nil), "A", OperationAdminister)
+		assert.Nil(t, err)
+		assert.Nil(t, principal)
+	}
+
+	t.Log("missing credentials")
+	{
+		_, err := guard.Authorize(httptest.NewRequest(http.MethodGet, "/", nil), "A", OperationRead)
+		assert.Equal(t, ErrUnauthenticated, err)
+	}
+
+	t.Log("valid token")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, key, "k1", `{"sub":"alice","exp":`+expiry+`}`))
+		principal, err := guard.Authorize(req, "A", OperationTransact)
+		assert.Nil(t, err)
+		require.NotNil(t, principal)
+		assert.Equal(t, "alice", principal.Name)
+
+		_, err = guard.Authorize(req, "B", OperationTransact)
+		assert.Equal(t, ErrForbidden, err)
+	}
+
+	t.Log("expired token")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, key, "k1", `{"sub":"alice","exp":1}`))
+		_, err := guard.Authorize(req, "A", OperationRead)
+		assert.Equal(t, ErrUnauthenticated, err)
+	}
+
+	t.Log("token signed by unknown key")
+	{
+		_, other, err := ed25519.GenerateKey(rand.Reader)
+		require.Nil(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, other, "k1", `{"sub":"alice","exp":`+expiry+`}`))
+		_, err = guard.Authorize(req, "A", OperationRead)
+		assert.Equal(t, ErrUnauthenticated, err)
+	}
+
+	t.Log("token of unknown subject")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, key, "k1", `{"sub":"bob","exp":`+expiry+`}`))
+		_, err := guard.Authorize(req, "A", OperationRead)
+		assert.Equal(t, ErrUnauthenticated, err)
+	}
+
+	t.Log("mapped client certificate")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{
+				Subject: pkix.Name{CommonName: "ops.local"},
+			}}},
+		}
+		principal, err := guard.Authorize(req, "A", OperationAdminister)
+		assert.Nil(t, err)
+		require.NotNil(t, principal)
+		assert.Equal(t, "ops", principal.Name)
+		assert.Equal(t, "mtls", principal.Method)
+	}
+
+	t.Log("unmapped client certificate")
+	{
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{
+				Subject: pkix.Name{CommonName: "stranger"},
+			}}},
+		}
+		_, err := guard.Authorize(req, "A", OperationRead)
+		assert.Equal(t, ErrUnauthenticated, err)
+	}
+}
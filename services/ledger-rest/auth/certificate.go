@@ -0,0 +1,55 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// CertificateAuthenticator maps verified client certificates to principals
+type CertificateAuthenticator struct {
+	policy *Policy
+}
+
+// NewCertificateAuthenticator returns mTLS authenticator
+func NewCertificateAuthenticator(policy *Policy) *CertificateAuthenticator {
+	return &CertificateAuthenticator{
+		policy: policy,
+	}
+}
+
+// Authenticate returns principal of verified client certificate or nil if
+// request carries no client certificate
+func (authenticator *CertificateAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if authenticator == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	certificate := r.TLS.VerifiedChains[0][0]
+	fingerprint := sha256.Sum256(certificate.Raw)
+
+	for _, key := range []string{"sha256:" + hex.EncodeToString(fingerprint[:]), "cn:" + certificate.Subject.CommonName} {
+		if principal, ok := authenticator.policy.Certificates[key]; ok {
+			return &Principal{
+				Name:   principal,
+				Method: "mtls",
+			}, nil
+		}
+	}
+
+	log.Debug().Msgf("Client certificate %s is not mapped to any principal", certificate.Subject.CommonName)
+	return nil, ErrUnauthenticated
+}
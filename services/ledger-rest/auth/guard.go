@@ -0,0 +1,91 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Authenticator resolves principal of request, returns nil principal when
+// request does not carry credentials it understands
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Guard authenticates requests and authorizes principals against policy
+type Guard struct {
+	authenticators []Authenticator
+	policy         *Policy
+}
+
+// NewGuard returns guard with mTLS and bearer token authentication, policy is
+// required unless authorization is explicitly disabled in which case nil
+// guard allowing everything is returned
+func NewGuard(policyPath string, keysPath string, disabled bool) (*Guard, error) {
+	if policyPath == "" {
+		if !disabled {
+			return nil, fmt.Errorf("authorization policy not provided")
+		}
+		log.Warn().Msg("Authorization disabled, API is open to anyone")
+		return nil, nil
+	}
+	policy, err := LoadPolicy(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(KeySet)
+	if keysPath != "" {
+		keys, err = LoadKeySet(keysPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	log.Info().Msgf("Loaded authorization policy with %d principals and %d token keys", len(policy.Principals), len(keys))
+	return &Guard{
+		authenticators: []Authenticator{
+			NewCertificateAuthenticator(policy),
+			NewTokenAuthenticator(keys, policy),
+		},
+		policy: policy,
+	}, nil
+}
+
+// Authorize returns principal of request if it may perform operation on
+// tenant
+func (guard *Guard) Authorize(r *http.Request, tenant string, operation Operation) (*Principal, error) {
+	if guard == nil {
+		return nil, nil
+	}
+	var principal *Principal
+	for _, authenticator := range guard.authenticators {
+		candidate, err := authenticator.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+		if candidate != nil {
+			principal = candidate
+			break
+		}
+	}
+	if principal == nil {
+		return nil, ErrUnauthenticated
+	}
+	if !guard.policy.IsAllowed(principal.Name, tenant, operation) {
+		log.Debug().Msgf("Principal %s is not allowed to %s tenant %s", principal.Name, operation, tenant)
+		return principal, ErrForbidden
+	}
+	return principal, nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import "fmt"
+
+// Operation represents kind of access principal requests
+type Operation string
+
+const (
+	// OperationRead represents read access to tenant's data
+	OperationRead = Operation("read")
+	// OperationTransact represents creation of transactions in tenant
+	OperationTransact = Operation("transact")
+	// OperationAdminister represents management of tenant
+	OperationAdminister = Operation("administer")
+)
+
+// WildcardTenant represents grant valid for all tenants
+const WildcardTenant = "*"
+
+// Principal represents authenticated caller
+type Principal struct {
+	Name   string
+	Method string
+}
+
+// ErrUnauthenticated is returned when caller did not present valid credentials
+var ErrUnauthenticated = fmt.Errorf("unauthenticated")
+
+// ErrForbidden is returned when principal is not allowed to perform operation
+var ErrForbidden = fmt.Errorf("forbidden")
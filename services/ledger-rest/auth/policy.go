@@ -0,0 +1,95 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// Policy represents mapping of principals to operations they are allowed to
+// perform on tenants
+type Policy struct {
+	// Certificates maps client certificate "sha256:{fingerprint}" or
+	// "cn:{common name}" to principal
+	Certificates map[string]string `json:"certificates"`
+	// Principals maps principal to operations granted per tenant, tenant "*"
+	// grants operations on all tenants
+	Principals map[string]map[string][]Operation `json:"principals"`
+}
+
+// LoadPolicy loads policy from json file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	result := new(Policy)
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("invalid policy %s because %+v", path, err)
+	}
+	for principal, grants := range result.Principals {
+		for tenant, operations := range grants {
+			for _, operation := range operations {
+				switch operation {
+				case OperationRead, OperationTransact, OperationAdminister:
+				default:
+					return nil, fmt.Errorf("invalid operation %s of principal %s on tenant %s", operation, principal, tenant)
+				}
+			}
+		}
+	}
+	for certificate, principal := range result.Certificates {
+		if _, ok := result.Principals[principal]; !ok {
+			return nil, fmt.Errorf("certificate %s maps to unknown principal %s", certificate, principal)
+		}
+	}
+	return result, nil
+}
+
+// IsKnown tells whenever principal is present in policy
+func (policy *Policy) IsKnown(principal string) bool {
+	if policy == nil {
+		return false
+	}
+	_, ok := policy.Principals[principal]
+	return ok
+}
+
+// IsAllowed tells whenever principal may perform operation on tenant, empty
+// tenant requires grant on all tenants
+func (policy *Policy) IsAllowed(principal string, tenant string, operation Operation) bool {
+	if policy == nil {
+		return false
+	}
+	grants, ok := policy.Principals[principal]
+	if !ok {
+		return false
+	}
+	candidates := []string{WildcardTenant}
+	if tenant != "" && tenant != WildcardTenant {
+		candidates = append(candidates, tenant)
+	}
+	for _, candidate := range candidates {
+		for _, granted := range grants[candidate] {
+			if granted == operation {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// KeySet represents public keys trusted to sign bearer tokens indexed by key id
+type KeySet map[string]crypto.PublicKey
+
+// LoadKeySet loads PEM encoded public keys from directory, key id is name of
+// file without .pem extension
+func LoadKeySet(directory string) (KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(filepath.Clean(directory), "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	result := make(KeySet)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("invalid key %s", file)
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s because %+v", file, err)
+		}
+		result[strings.TrimSuffix(filepath.Base(file), ".pem")] = key
+	}
+	return result, nil
+}
+
+// TokenAuthenticator validates signed bearer tokens against local key set
+type TokenAuthenticator struct {
+	keys   KeySet
+	policy *Policy
+}
+
+// NewTokenAuthenticator returns bearer token authenticator
+func NewTokenAuthenticator(keys KeySet, policy *Policy) *TokenAuthenticator {
+	return &TokenAuthenticator{
+		keys:   keys,
+		policy: policy,
+	}
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// Authenticate returns principal of valid bearer token or nil if request
+// carries no bearer token
+func (authenticator *TokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if authenticator == nil {
+		return nil, nil
+	}
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, nil
+	}
+	claims, err := authenticator.verify(strings.TrimSpace(header[7:]), time.Now())
+	if err != nil {
+		log.Debug().Msgf("Rejected bearer token because %+v", err)
+		return nil, ErrUnauthenticated
+	}
+	if !authenticator.policy.IsKnown(claims.Subject) {
+		log.Debug().Msgf("Bearer token subject %s is not known", claims.Subject)
+		return nil, ErrUnauthenticated
+	}
+	return &Principal{
+		Name:   claims.Subject,
+		Method: "token",
+	}, nil
+}
+
+func (authenticator *TokenAuthenticator) verify(token string, now time.Time) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token header")
+	}
+	header := new(tokenHeader)
+	if err = json.Unmarshal(rawHeader, header); err != nil {
+		return nil, fmt.Errorf("malformed token header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature")
+	}
+
+	key, ok := authenticator.keys[header.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", header.KeyID)
+	}
+
+	if err = verifySignature(header.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token claims")
+	}
+	claims := new(tokenClaims)
+	if err = json.Unmarshal(rawClaims, claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims")
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("missing subject")
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("missing expiration")
+	}
+	if now.Unix() >= *claims.ExpiresAt {
+		return nil, fmt.Errorf("token expired")
+	}
+	if claims.NotBefore != nil && now.Unix() < *claims.NotBefore {
+		return nil, fmt.Errorf("token not yet valid")
+	}
+	return claims, nil
+}
+
+func verifySignature(algorithm string, key crypto.PublicKey, payload []byte, signature []byte) error {
+	switch algorithm {
+
+	case "EdDSA":
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(publicKey, payload, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+
+	case "ES256":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return fmt.Errorf("invalid signature")
+		}
+		digest := sha256.Sum256(payload)
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+
+	case "RS256":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("invalid signature")
+		}
+		digest := sha256.Sum256(payload)
+		if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) != nil {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported algorithm %s", algorithm)
+
+	}
+}
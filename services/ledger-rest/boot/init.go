@@ -15,15 +15,21 @@
 package boot
 
 import (
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/jancajthaml-openbank/ledger-rest/actor"
 	"github.com/jancajthaml-openbank/ledger-rest/api"
+	"github.com/jancajthaml-openbank/ledger-rest/audit"
 	"github.com/jancajthaml-openbank/ledger-rest/config"
+	"github.com/jancajthaml-openbank/ledger-rest/metrics"
+	"github.com/jancajthaml-openbank/ledger-rest/offboarding"
 	"github.com/jancajthaml-openbank/ledger-rest/support/concurrent"
 	"github.com/jancajthaml-openbank/ledger-rest/support/logging"
 	"github.com/jancajthaml-openbank/ledger-rest/system"
+	"github.com/jancajthaml-openbank/ledger-rest/tracing"
+	"github.com/jancajthaml-openbank/ledger-rest/webhook"
 )
 
 // Program encapsulate program
@@ -42,15 +48,34 @@ func NewProgram() Program {
 	}
 }
 
-// Setup setups program
-func (prog *Program) Setup() {
+// ExitCodeInvalidConfiguration is exit code of program refusing to start
+// because of invalid configuration, it corresponds to EX_CONFIG of sysexits
+const ExitCodeInvalidConfiguration = 78
+
+// Setup setups program, returns error if configuration is invalid
+func (prog *Program) Setup() error {
 	if prog == nil {
-		return
+		return fmt.Errorf("nil pointer")
 	}
 
 	logging.SetupLogger(prog.cfg.LogLevel)
 
-	systemControl := system.NewSystemControl()
+	var systemControl system.Control
+	var processControl *system.ProcessControl
+
+	switch prog.cfg.SystemControl {
+	case "process":
+		processControl = system.NewProcessControl(
+			prog.cfg.UnitBinary,
+			prog.cfg.UnitLogDirectory,
+			prog.cfg.RootStorage,
+		)
+		if processControl != nil {
+			systemControl = processControl
+		}
+	default:
+		systemControl = system.NewSystemControl()
+	}
 
 	diskMonitorWorker := system.NewDiskMonitor(
 		prog.cfg.MinFreeDiskSpace,
@@ -65,16 +90,79 @@ func (prog *Program) Setup() {
 		prog.cfg.LakeHostname,
 	)
 
-	restWorker := api.NewServer(
-		prog.cfg.ServerPort,
+	webhookWorker := webhook.NewDeliveryWorker(
+		prog.cfg.RootStorage,
+		prog.cfg.WebhookMaxAttempts,
+		prog.cfg.WebhookBackoff,
+		prog.cfg.WebhookTestMode,
+	)
+
+	certificateWorker := api.NewCertificateReloader(
 		prog.cfg.ServerCert,
 		prog.cfg.ServerKey,
+	)
+
+	offboardingWorker := offboarding.NewOffboarder(
 		prog.cfg.RootStorage,
+		prog.cfg.ArchiveDirectory,
+		prog.cfg.OffboardingDrainTimeout,
+		prog.cfg.OffboardingRetention,
+		systemControl,
+	)
+
+	metricsCollector := metrics.NewPrometheus(
+		prog.cfg.RootStorage,
+		diskMonitorWorker,
+		memoryMonitorWorker,
+	)
+
+	tracingExporter := tracing.NewExporter(
+		prog.cfg.TracingEndpoint,
+		"ledger-rest",
+	)
+
+	auditSink := audit.NewSink(
+		prog.cfg.AuditDirectory,
+		"ledger-rest",
+		prog.cfg.AuditMaxSize,
+		prog.cfg.AuditRetention,
+	)
+
+	restWorker := api.NewServer(
+		api.ServerConfig{
+			BindAddress:                   prog.cfg.ServerBindAddress,
+			Port:                          prog.cfg.ServerPort,
+			TLSMinVersion:                 prog.cfg.TLSMinVersion,
+			TLSMaxVersion:                 prog.cfg.TLSMaxVersion,
+			ClientCA:                      prog.cfg.ClientCA,
+			AuthPolicy:                    prog.cfg.AuthPolicy,
+			AuthKeys:                      prog.cfg.AuthKeys,
+			AuthDisabled:                  prog.cfg.AuthDisabled,
+			IBANMapping:                   prog.cfg.IBANMapping,
+			ReconciliationAmountTolerance: prog.cfg.ReconciliationAmountTolerance,
+			ReconciliationDateTolerance:   prog.cfg.ReconciliationDateTolerance,
+			RootStorage:                   prog.cfg.RootStorage,
+		},
+		certificateWorker,
 		actorSystem,
 		systemControl,
+		offboardingWorker,
+		metricsCollector,
+		tracingExporter,
+		auditSink,
 		diskMonitorWorker,
 		memoryMonitorWorker,
 	)
+	if restWorker == nil {
+		return fmt.Errorf("invalid rest server configuration")
+	}
+
+	if processControl != nil {
+		prog.pool.Register(concurrent.NewOneShotDaemon(
+			"process-supervisor",
+			processControl,
+		))
+	}
 
 	prog.pool.Register(concurrent.NewOneShotDaemon(
 		"actor-system",
@@ -93,9 +181,40 @@ func (prog *Program) Setup() {
 		time.Second,
 	))
 
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"webhook-delivery",
+		webhookWorker,
+		time.Second,
+	))
+
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"offboarding-purge",
+		offboardingWorker,
+		time.Minute,
+	))
+
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"certificate-reload",
+		certificateWorker,
+		time.Second,
+	))
+
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"audit",
+		auditSink,
+		time.Minute,
+	))
+
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"tracing",
+		tracingExporter,
+		time.Second,
+	))
+
 	prog.pool.Register(concurrent.NewOneShotDaemon(
 		"rest",
 		restWorker,
 	))
 
+	return nil
 }
@@ -14,18 +14,64 @@
 
 package config
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // Configuration of application
 type Configuration struct {
 	// RootStorage gives where to store journals
 	RootStorage string
+	// ServerBindAddress is address which server is bound to
+	ServerBindAddress string
 	// ServerPort is port which server is bound to
 	ServerPort int
 	// ServerKey path to server tls key file
 	ServerKey string
 	// ServerCert path to server tls cert file
 	ServerCert string
+	// TLSMinVersion minimal accepted tls protocol version
+	TLSMinVersion string
+	// TLSMaxVersion maximal accepted tls protocol version
+	TLSMaxVersion string
+	// ClientCA path to bundle of CA certificates trusted to issue client
+	// certificates
+	ClientCA string
+	// AuthPolicy path to authorization policy, required unless authorization
+	// is disabled
+	AuthPolicy string
+	// AuthDisabled represents explicit opt-out of authorization when no
+	// policy is provided, API is then open to anyone
+	AuthDisabled bool
+	// AuthKeys path to directory of public keys trusted to sign bearer tokens
+	AuthKeys string
+	// IBANMapping path to json mapping of IBAN to tenant/account used by
+	// payment import, empty disables payment import
+	IBANMapping string
+	// ReconciliationAmountTolerance represents maximal difference of amounts
+	// of paired ledger and statement entries
+	ReconciliationAmountTolerance string
+	// ReconciliationDateTolerance represents maximal difference of value
+	// dates of paired ledger and statement entries, in whole days
+	ReconciliationDateTolerance int
+	// SystemControl represents how ledger units are managed, either systemd
+	// or process
+	SystemControl string
+	// UnitBinary path to ledger-unit binary spawned by process control
+	UnitBinary string
+	// UnitLogDirectory path to directory where output of ledger-unit processes
+	// spawned by process control is captured
+	UnitLogDirectory string
+	// ArchiveDirectory path to directory where data of offboarded tenants are
+	// archived
+	ArchiveDirectory string
+	// OffboardingDrainTimeout represents how long offboarding waits for
+	// pending transactions to finish
+	OffboardingDrainTimeout time.Duration
+	// OffboardingRetention represents how long data of offboarded tenants
+	// are kept before purge, zero keeps them indefinitely
+	OffboardingRetention time.Duration
 	// LakeHostname represent hostname of openbank lake service
 	LakeHostname string
 	// LogLevel ignorecase log level
@@ -36,18 +82,60 @@ type Configuration struct {
 	// MinFreeMemory respresents threshold for minimum available memory to
 	// be possible operating
 	MinFreeMemory uint64
+	// AuditDirectory represents directory of audit trail, empty disables it
+	AuditDirectory string
+	// AuditMaxSize represents size in bytes after which audit file is rotated,
+	// zero disables rotation
+	AuditMaxSize int64
+	// AuditRetention represents how long rotated audit files are kept, zero
+	// keeps them indefinitely
+	AuditRetention time.Duration
+	// TracingEndpoint represents OTLP/HTTP traces endpoint of collector, empty
+	// disables export of spans
+	TracingEndpoint string
+	// WebhookMaxAttempts represents number of failed deliveries after which
+	// event is dead-lettered
+	WebhookMaxAttempts int
+	// WebhookBackoff represents initial backoff between failed deliveries
+	WebhookBackoff time.Duration
+	// WebhookTestMode represents whenever webhooks are delivered to local
+	// stand-in instead of their targets
+	WebhookTestMode bool
 }
 
 // LoadConfig loads application configuration
 func LoadConfig() Configuration {
 	return Configuration{
-		RootStorage:      envString("LEDGER_STORAGE", "/data"),
-		ServerPort:       envInteger("LEDGER_HTTP_PORT", 4401),
-		ServerKey:        envString("LEDGER_SERVER_KEY", ""),
-		ServerCert:       envString("LEDGER_SERVER_CERT", ""),
-		LakeHostname:     envString("LEDGER_LAKE_HOSTNAME", "127.0.0.1"),
-		LogLevel:         strings.ToUpper(envString("LEDGER_LOG_LEVEL", "INFO")),
-		MinFreeDiskSpace: uint64(envInteger("VAULT_STORAGE_THRESHOLD", 0)),
-		MinFreeMemory:    uint64(envInteger("VAULT_MEMORY_THRESHOLD", 0)),
+		RootStorage:                   envString("LEDGER_STORAGE", "/data"),
+		ServerBindAddress:             envString("LEDGER_HTTP_BIND_ADDRESS", "127.0.0.1"),
+		ServerPort:                    envInteger("LEDGER_HTTP_PORT", 4401),
+		ServerKey:                     envString("LEDGER_SERVER_KEY", ""),
+		ServerCert:                    envString("LEDGER_SERVER_CERT", ""),
+		TLSMinVersion:                 envString("LEDGER_TLS_MIN_VERSION", "1.2"),
+		TLSMaxVersion:                 envString("LEDGER_TLS_MAX_VERSION", "1.3"),
+		ClientCA:                      envString("LEDGER_CLIENT_CA", ""),
+		AuthPolicy:                    envString("LEDGER_AUTH_POLICY", ""),
+		AuthDisabled:                  envBoolean("LEDGER_AUTH_DISABLED", false),
+		AuthKeys:                      envString("LEDGER_AUTH_KEYS", ""),
+		IBANMapping:                   envString("LEDGER_IBAN_MAPPING", ""),
+		ReconciliationAmountTolerance: envString("LEDGER_RECONCILIATION_AMOUNT_TOLERANCE", "0"),
+		ReconciliationDateTolerance:   envInteger("LEDGER_RECONCILIATION_DATE_TOLERANCE", 0),
+		SystemControl:                 strings.ToLower(envString("LEDGER_SYSTEM_CONTROL", "systemd")),
+		UnitBinary:                    envString("LEDGER_UNIT_BINARY", "/usr/bin/ledger-unit"),
+		UnitLogDirectory:              envString("LEDGER_UNIT_LOG_DIRECTORY", "/var/log/ledger"),
+		ArchiveDirectory:              envString("LEDGER_ARCHIVE_DIRECTORY", envString("LEDGER_STORAGE", "/data")+"/archive"),
+		OffboardingDrainTimeout:       envDuration("LEDGER_OFFBOARDING_DRAIN_TIMEOUT", 30*time.Second),
+		OffboardingRetention:          envDuration("LEDGER_OFFBOARDING_RETENTION", 0),
+		LakeHostname:                  envString("LEDGER_LAKE_HOSTNAME", "127.0.0.1"),
+		LogLevel:                      strings.ToUpper(envString("LEDGER_LOG_LEVEL", "INFO")),
+		MinFreeDiskSpace:              uint64(envInteger("VAULT_STORAGE_THRESHOLD", 0)),
+		MinFreeMemory:                 uint64(envInteger("VAULT_MEMORY_THRESHOLD", 0)),
+		TracingEndpoint:               envString("LEDGER_TRACING_ENDPOINT", ""),
+		AuditDirectory:                envString("LEDGER_AUDIT_DIRECTORY", "/var/log/ledger/audit"),
+		AuditMaxSize:                  int64(envInteger("LEDGER_AUDIT_MAX_SIZE", 100*1024*1024)),
+		AuditRetention:                envDuration("LEDGER_AUDIT_RETENTION", 0),
+		WebhookMaxAttempts:            envInteger("LEDGER_WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookBackoff:                envDuration("LEDGER_WEBHOOK_BACKOFF", time.Second),
+		WebhookTestMode:               envBoolean("LEDGER_WEBHOOK_TEST_MODE", false),
 	}
 }
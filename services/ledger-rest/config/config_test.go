@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetConfig(t *testing.T) {
@@ -21,6 +22,9 @@ func TestGetConfig(t *testing.T) {
 		if config.RootStorage != "/data" {
 			t.Errorf("RootStorage default value is not /data")
 		}
+		if config.ServerBindAddress != "127.0.0.1" {
+			t.Errorf("ServerBindAddress default value is not 127.0.0.1")
+		}
 		if config.ServerPort != 4401 {
 			t.Errorf("ServerPort default value is not 4401")
 		}
@@ -30,6 +34,51 @@ func TestGetConfig(t *testing.T) {
 		if config.ServerCert != "" {
 			t.Errorf("ServerCert default value is not empty")
 		}
+		if config.TLSMinVersion != "1.2" {
+			t.Errorf("TLSMinVersion default value is not 1.2")
+		}
+		if config.TLSMaxVersion != "1.3" {
+			t.Errorf("TLSMaxVersion default value is not 1.3")
+		}
+		if config.ClientCA != "" {
+			t.Errorf("ClientCA default value is not empty")
+		}
+		if config.AuthPolicy != "" {
+			t.Errorf("AuthPolicy default value is not empty")
+		}
+		if config.AuthDisabled != false {
+			t.Errorf("AuthDisabled default value is not false")
+		}
+		if config.AuthKeys != "" {
+			t.Errorf("AuthKeys default value is not empty")
+		}
+		if config.IBANMapping != "" {
+			t.Errorf("IBANMapping default value is not empty")
+		}
+		if config.ReconciliationAmountTolerance != "0" {
+			t.Errorf("ReconciliationAmountTolerance default value is not 0")
+		}
+		if config.ReconciliationDateTolerance != 0 {
+			t.Errorf("ReconciliationDateTolerance default value is not 0")
+		}
+		if config.SystemControl != "systemd" {
+			t.Errorf("SystemControl default value is not systemd")
+		}
+		if config.UnitBinary != "/usr/bin/ledger-unit" {
+			t.Errorf("UnitBinary default value is not /usr/bin/ledger-unit")
+		}
+		if config.UnitLogDirectory != "/var/log/ledger" {
+			t.Errorf("UnitLogDirectory default value is not /var/log/ledger")
+		}
+		if config.ArchiveDirectory != "/data/archive" {
+			t.Errorf("ArchiveDirectory default value is not /data/archive")
+		}
+		if config.OffboardingDrainTimeout != 30*time.Second {
+			t.Errorf("OffboardingDrainTimeout default value is not 30s")
+		}
+		if config.OffboardingRetention != 0 {
+			t.Errorf("OffboardingRetention default value is not 0")
+		}
 		if config.LakeHostname != "127.0.0.1" {
 			t.Errorf("LakeHostname default value is not 127.0.0.1")
 		}
@@ -42,6 +91,27 @@ func TestGetConfig(t *testing.T) {
 		if config.MinFreeMemory != uint64(0) {
 			t.Errorf("MinFreeMemory default value is not 0")
 		}
+		if config.TracingEndpoint != "" {
+			t.Errorf("TracingEndpoint default value is not empty")
+		}
+		if config.AuditDirectory != "/var/log/ledger/audit" {
+			t.Errorf("AuditDirectory default value is not /var/log/ledger/audit")
+		}
+		if config.AuditMaxSize != 100*1024*1024 {
+			t.Errorf("AuditMaxSize default value is not 100MiB")
+		}
+		if config.AuditRetention != 0 {
+			t.Errorf("AuditRetention default value is not 0")
+		}
+		if config.WebhookMaxAttempts != 10 {
+			t.Errorf("WebhookMaxAttempts default value is not 10")
+		}
+		if config.WebhookBackoff != time.Second {
+			t.Errorf("WebhookBackoff default value is not 1s")
+		}
+		if config.WebhookTestMode != false {
+			t.Errorf("WebhookTestMode default value is not false")
+		}
 
 	}
 }
@@ -0,0 +1,85 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// Export writes transfers of given transactions of tenant accepted by filter
+func Export(storage localfs.Storage, tenant string, ids []string, filter Filter, writer Writer) error {
+	for _, id := range ids {
+		recordedAt, ok := persistence.LoadTransactionCreation(storage, tenant, id)
+		if !ok {
+			// journal entry missing in creation index
+			modification, err := persistence.LoadTransactionModification(storage, tenant, id)
+			if err != nil {
+				return err
+			}
+			recordedAt = modification
+		}
+		if !filter.AcceptsRecorded(recordedAt) {
+			continue
+		}
+		transaction, err := persistence.LoadTransaction(storage, tenant, id)
+		if err != nil {
+			return err
+		}
+		if transaction == nil {
+			continue
+		}
+		for _, row := range rowsOf(transaction, recordedAt, filter) {
+			if err = writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rowsOf flattens transfers of transaction accepted by filter
+func rowsOf(transaction *model.Transaction, recordedAt time.Time, filter Filter) []Row {
+	result := make([]Row, 0, len(transaction.Transfers))
+	for _, transfer := range transaction.Transfers {
+		valueDate := ""
+		if !transfer.ValueDate.IsZero() {
+			if !filter.AcceptsValueDate(transfer.ValueDate) {
+				continue
+			}
+			valueDate = transfer.ValueDate.UTC().Format(time.RFC3339)
+		} else if filter.HasValueRange() {
+			continue
+		}
+		result = append(result, Row{
+			TransactionID:     transaction.IDTransaction,
+			TransactionStatus: transaction.Status,
+			RecordedAt:        recordedAt.UTC().Format(time.RFC3339),
+			TransferID:        transfer.IDTransfer,
+			ValueDate:         valueDate,
+			CreditTenant:      transfer.Credit.Tenant,
+			CreditAccount:     transfer.Credit.Name,
+			DebitTenant:       transfer.Debit.Tenant,
+			DebitAccount:      transfer.Debit.Name,
+			Amount:            transfer.Amount,
+			Currency:          transfer.Currency,
+		})
+	}
+	return result
+}
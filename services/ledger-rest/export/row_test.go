@@ -0,0 +1,67 @@
+package export
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChunk(t *testing.T) {
+	ids := []string{"c", "a", "e", "b", "d"}
+
+	chunk, next := Chunk(ids, "", 2)
+	if !reflect.DeepEqual(chunk, []string{"a", "b"}) || next != "b" {
+		t.Errorf("unexpected first chunk %v next %q", chunk, next)
+	}
+	chunk, next = Chunk(ids, next, 2)
+	if !reflect.DeepEqual(chunk, []string{"c", "d"}) || next != "d" {
+		t.Errorf("unexpected second chunk %v next %q", chunk, next)
+	}
+	chunk, next = Chunk(ids, next, 2)
+	if !reflect.DeepEqual(chunk, []string{"e"}) || next != "" {
+		t.Errorf("unexpected last chunk %v next %q", chunk, next)
+	}
+	chunk, next = Chunk(ids, "bb", 0)
+	if !reflect.DeepEqual(chunk, []string{"c", "d", "e"}) || next != "" {
+		t.Errorf("expected chunk to resume after removed cursor got %v next %q", chunk, next)
+	}
+	chunk, next = Chunk(ids, "e", 2)
+	if len(chunk) != 0 || next != "" {
+		t.Errorf("expected empty chunk after last id got %v next %q", chunk, next)
+	}
+}
+
+func TestParseBound(t *testing.T) {
+	if at, err := ParseBound(""); err != nil || !at.IsZero() {
+		t.Errorf("expected open bound got %v %+v", at, err)
+	}
+	if at, err := ParseBound("2020-02-01"); err != nil || !at.Equal(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected date bound got %v %+v", at, err)
+	}
+	if at, err := ParseBound("2020-02-01T10:00:00+01:00"); err != nil || !at.Equal(time.Date(2020, 2, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected time bound got %v %+v", at, err)
+	}
+	if _, err := ParseBound("yesterday"); err == nil {
+		t.Errorf("expected error for invalid bound")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	filter := Filter{
+		From:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+		ValueTo: time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+	if !filter.AcceptsRecorded(filter.From) {
+		t.Errorf("expected lower bound to be inclusive")
+	}
+	if filter.AcceptsRecorded(filter.To) {
+		t.Errorf("expected upper bound to be exclusive")
+	}
+	if !filter.AcceptsValueDate(time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected zero lower bound to be open")
+	}
+	if !filter.HasValueRange() || (Filter{}).HasValueRange() {
+		t.Errorf("unexpected value range detection")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	// FormatCSV represents comma separated values with header line
+	FormatCSV = "csv"
+	// FormatJSONL represents JSON object per line
+	FormatJSONL = "jsonl"
+	// FormatParquet represents Apache Parquet file
+	FormatParquet = "parquet"
+)
+
+// Writer represents encoder of exported rows
+type Writer interface {
+	// Write encodes single row
+	Write(row Row) error
+	// Close flushes buffered rows and finishes output
+	Close() error
+}
+
+// NewWriter returns writer of rows in given format
+func NewWriter(format string, output io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(output), nil
+	case FormatJSONL:
+		return newJSONLWriter(output), nil
+	case FormatParquet:
+		return newParquetWriter(output), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ContentType returns media type of given format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+type csvWriter struct {
+	output *csv.Writer
+	header bool
+}
+
+func newCSVWriter(output io.Writer) *csvWriter {
+	return &csvWriter{
+		output: csv.NewWriter(output),
+	}
+}
+
+func (writer *csvWriter) writeHeader() error {
+	if writer.header {
+		return nil
+	}
+	writer.header = true
+	return writer.output.Write(Columns)
+}
+
+func (writer *csvWriter) Write(row Row) error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	if err := writer.output.Write(row.values()); err != nil {
+		return err
+	}
+	writer.output.Flush()
+	return writer.output.Error()
+}
+
+func (writer *csvWriter) Close() error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	writer.output.Flush()
+	return writer.output.Error()
+}
+
+type jsonlWriter struct {
+	output *json.Encoder
+}
+
+func newJSONLWriter(output io.Writer) *jsonlWriter {
+	return &jsonlWriter{
+		output: json.NewEncoder(output),
+	}
+}
+
+func (writer *jsonlWriter) Write(row Row) error {
+	return writer.output.Encode(row)
+}
+
+func (writer *jsonlWriter) Close() error {
+	return nil
+}
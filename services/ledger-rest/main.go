@@ -18,13 +18,17 @@ import (
 	"context"
 	"fmt"
 	"github.com/jancajthaml-openbank/ledger-rest/boot"
+	"os"
 )
 
 func main() {
 	fmt.Println(">>> Start <<<")
 
 	program := boot.NewProgram()
-	program.Setup()
+	if err := program.Setup(); err != nil {
+		fmt.Println(">>> Refused to start <<<")
+		os.Exit(boot.ExitCodeInvalidConfiguration)
+	}
 
 	defer func() {
 		program.Stop()
@@ -0,0 +1,197 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/system"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// snapshotMaxAge is age after which snapshot of ledger unit is considered
+// stale and is not exposed
+const snapshotMaxAge = time.Minute
+
+// Metrics represents contract of metrics collector
+type Metrics interface {
+	RequestServed(method string, route string, status int, duration time.Duration)
+}
+
+// Prometheus is metrics collector exposing metrics of service and of all
+// ledger units in prometheus text format
+type Prometheus struct {
+	storage   localfs.Storage
+	registry  *Registry
+	requests  *Family
+	latencies *Family
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// NewPrometheus returns prometheus metrics collector
+func NewPrometheus(rootStorage string, diskMonitor system.CapacityCheck, memoryMonitor system.CapacityCheck) *Prometheus {
+	storage, err := localfs.NewPlaintextStorage(rootStorage)
+	if err != nil {
+		log.Error().Msgf("Failed to ensure storage %+v", err)
+		return nil
+	}
+	registry := NewRegistry()
+	result := &Prometheus{
+		storage:   storage,
+		registry:  registry,
+		requests:  registry.Counter("ledger_rest_http_requests_total", "Number of http requests by route and status", "method", "route", "status"),
+		latencies: registry.Histogram("ledger_rest_http_request_duration_seconds", "Latency of http requests by route and status", DefaultBuckets, "method", "route", "status"),
+	}
+	if diskMonitor != nil {
+		registry.GaugeFunc("ledger_rest_disk_free_bytes", "Free space of storage", func() float64 { return float64(diskMonitor.GetFree()) })
+		registry.GaugeFunc("ledger_rest_disk_used_bytes", "Used space of storage", func() float64 { return float64(diskMonitor.GetUsed()) })
+		registry.GaugeFunc("ledger_rest_disk_healthy", "Whenever there is enough space of storage", func() float64 { return boolToFloat(diskMonitor.IsHealthy()) })
+	}
+	if memoryMonitor != nil {
+		registry.GaugeFunc("ledger_rest_memory_free_bytes", "Free memory of host", func() float64 { return float64(memoryMonitor.GetFree()) })
+		registry.GaugeFunc("ledger_rest_memory_used_bytes", "Memory allocated by service", func() float64 { return float64(memoryMonitor.GetUsed()) })
+		registry.GaugeFunc("ledger_rest_memory_healthy", "Whenever there is enough memory", func() float64 { return boolToFloat(memoryMonitor.IsHealthy()) })
+	}
+	return result
+}
+
+// RequestServed records served http request
+func (instance *Prometheus) RequestServed(method string, route string, status int, duration time.Duration) {
+	if instance == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	instance.requests.Inc(method, route, code)
+	instance.latencies.Observe(duration.Seconds(), method, route, code)
+}
+
+func (instance *Prometheus) unitSnapshots() [][]byte {
+	result := make([][]byte, 0)
+	items, err := instance.storage.ListDirectory("", true)
+	if err != nil {
+		return result
+	}
+	for _, item := range items {
+		if !strings.HasPrefix(item, "t_") {
+			continue
+		}
+		path := item + "/metrics"
+		modification, err := instance.storage.LastModification(path)
+		if err != nil || time.Now().Sub(modification) > snapshotMaxAge {
+			continue
+		}
+		data, err := instance.storage.ReadFileFully(path)
+		if err != nil {
+			continue
+		}
+		result = append(result, data)
+	}
+	return result
+}
+
+// Bytes returns metrics of service merged with snapshots of ledger units
+func (instance *Prometheus) Bytes() []byte {
+	if instance == nil {
+		return nil
+	}
+	return Merge(append([][]byte{instance.registry.Bytes()}, instance.unitSnapshots()...))
+}
+
+// ServeHTTP exposes metrics in prometheus text format
+func (instance *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(instance.Bytes())
+}
+
+type mergedFamily struct {
+	help    string
+	kind    string
+	samples []string
+}
+
+func familyOf(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return ""
+	}
+	return fields[2]
+}
+
+// Merge combines several prometheus text expositions so that samples of the
+// same metric family are grouped under single HELP and TYPE
+func Merge(chunks [][]byte) []byte {
+	order := make([]string, 0)
+	families := make(map[string]*mergedFamily)
+
+	lookup := func(name string) *mergedFamily {
+		family, ok := families[name]
+		if !ok {
+			family = new(mergedFamily)
+			families[name] = family
+			order = append(order, name)
+		}
+		return family
+	}
+
+	for _, chunk := range chunks {
+		current := ""
+		for _, line := range strings.Split(string(chunk), "\n") {
+			switch {
+			case line == "":
+				continue
+			case strings.HasPrefix(line, "# HELP "):
+				current = familyOf(line)
+				if family := lookup(current); family.help == "" {
+					family.help = line
+				}
+			case strings.HasPrefix(line, "# TYPE "):
+				current = familyOf(line)
+				if family := lookup(current); family.kind == "" {
+					family.kind = line
+				}
+			case strings.HasPrefix(line, "#"):
+				continue
+			default:
+				family := lookup(current)
+				family.samples = append(family.samples, line)
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	for _, name := range order {
+		family := families[name]
+		for _, line := range append([]string{family.help, family.kind}, family.samples...) {
+			if line == "" {
+				continue
+			}
+			buffer.WriteString(line)
+			buffer.WriteString("\n")
+		}
+	}
+	return buffer.Bytes()
+}
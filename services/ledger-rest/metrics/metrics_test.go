@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	a := []byte("# HELP x_total X\n# TYPE x_total counter\nx_total{tenant=\"a\"} 1\n# HELP y Y\n# TYPE y gauge\ny 2\n")
+	b := []byte("# HELP x_total X\n# TYPE x_total counter\nx_total{tenant=\"b\"} 3\n")
+
+	expected := "# HELP x_total X\n# TYPE x_total counter\nx_total{tenant=\"a\"} 1\nx_total{tenant=\"b\"} 3\n# HELP y Y\n# TYPE y gauge\ny 2\n"
+
+	assert.Equal(t, expected, string(Merge([][]byte{a, b})))
+}
+
+func TestPrometheus(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_metrics")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	require.Nil(t, err)
+
+	require.Nil(t, storage.WriteFile("t_a/metrics", []byte("# HELP ledger_transactions_total T\n# TYPE ledger_transactions_total counter\nledger_transactions_total{tenant=\"a\",outcome=\"committed\"} 5\n")))
+	require.Nil(t, storage.WriteFile("t_b/metrics", []byte("# HELP ledger_transactions_total T\n# TYPE ledger_transactions_total counter\nledger_transactions_total{tenant=\"b\",outcome=\"committed\"} 7\n")))
+	stale := time.Now().Add(-2 * snapshotMaxAge)
+	require.Nil(t, storage.WriteFile("t_c/metrics", []byte("ledger_transactions_total{tenant=\"c\",outcome=\"committed\"} 9\n")))
+	require.Nil(t, os.Chtimes(tmpdir+"/t_c/metrics", stale, stale))
+
+	collector := NewPrometheus(tmpdir, nil, nil)
+	require.NotNil(t, collector)
+
+	collector.RequestServed("GET", "/health", 200, 10*time.Millisecond)
+
+	exposition := string(collector.Bytes())
+
+	assert.True(t, strings.Contains(exposition, `ledger_rest_http_requests_total{method="GET",route="/health",status="200"} 1`))
+	assert.True(t, strings.Contains(exposition, `ledger_rest_http_request_duration_seconds_count{method="GET",route="/health",status="200"} 1`))
+	assert.True(t, strings.Contains(exposition, `ledger_transactions_total{tenant="a",outcome="committed"} 5`+"\n"+`ledger_transactions_total{tenant="b",outcome="committed"} 7`))
+	assert.False(t, strings.Contains(exposition, `tenant="c"`))
+	assert.Equal(t, 1, strings.Count(exposition, "# TYPE ledger_transactions_total counter"))
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// DefaultBuckets are histogram buckets in seconds suited for latencies
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type series struct {
+	labels  string
+	value   float64
+	sum     float64
+	count   uint64
+	buckets []uint64
+}
+
+// Family represents metric with given name and its series distinguished by
+// label values
+type Family struct {
+	mutex      sync.Mutex
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	buckets    []float64
+	series     map[string]*series
+	callback   func() float64
+}
+
+// Registry is minimal holder of metrics exposed in prometheus text format
+type Registry struct {
+	mutex    sync.Mutex
+	families []*Family
+}
+
+// NewRegistry returns empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make([]*Family, 0),
+	}
+}
+
+func (registry *Registry) register(family *Family) *Family {
+	family.series = make(map[string]*series)
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.families = append(registry.families, family)
+	return family
+}
+
+// Counter registers monotonic counter
+func (registry *Registry) Counter(name string, help string, labelNames ...string) *Family {
+	return registry.register(&Family{
+		name:       name,
+		help:       help,
+		kind:       kindCounter,
+		labelNames: labelNames,
+	})
+}
+
+// Gauge registers gauge
+func (registry *Registry) Gauge(name string, help string, labelNames ...string) *Family {
+	return registry.register(&Family{
+		name:       name,
+		help:       help,
+		kind:       kindGauge,
+		labelNames: labelNames,
+	})
+}
+
+// GaugeFunc registers gauge without labels whose value is obtained on
+// exposition
+func (registry *Registry) GaugeFunc(name string, help string, callback func() float64) *Family {
+	return registry.register(&Family{
+		name:     name,
+		help:     help,
+		kind:     kindGauge,
+		callback: callback,
+	})
+}
+
+// Histogram registers histogram with given upper bounds of buckets
+func (registry *Registry) Histogram(name string, help string, buckets []float64, labelNames ...string) *Family {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return registry.register(&Family{
+		name:       name,
+		help:       help,
+		kind:       kindHistogram,
+		labelNames: labelNames,
+		buckets:    sorted,
+	})
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	return strings.Replace(value, `"`, `\"`, -1)
+}
+
+func formatLabels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var buffer bytes.Buffer
+	for i, name := range names {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString(name)
+		buffer.WriteString(`="`)
+		if i < len(values) {
+			buffer.WriteString(escapeLabelValue(values[i]))
+		}
+		buffer.WriteString(`"`)
+	}
+	return buffer.String()
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+func (family *Family) get(labelValues []string) *series {
+	key := formatLabels(family.labelNames, labelValues)
+	item, ok := family.series[key]
+	if !ok {
+		item = &series{
+			labels: key,
+		}
+		if family.kind == kindHistogram {
+			item.buckets = make([]uint64, len(family.buckets))
+		}
+		family.series[key] = item
+	}
+	return item
+}
+
+// Add adds delta to counter or gauge with given label values
+func (family *Family) Add(delta float64, labelValues ...string) {
+	if family == nil {
+		return
+	}
+	family.mutex.Lock()
+	defer family.mutex.Unlock()
+	family.get(labelValues).value += delta
+}
+
+// Inc increments counter or gauge with given label values by one
+func (family *Family) Inc(labelValues ...string) {
+	family.Add(1, labelValues...)
+}
+
+// Set sets gauge with given label values
+func (family *Family) Set(value float64, labelValues ...string) {
+	if family == nil {
+		return
+	}
+	family.mutex.Lock()
+	defer family.mutex.Unlock()
+	family.get(labelValues).value = value
+}
+
+// Observe records value into histogram with given label values
+func (family *Family) Observe(value float64, labelValues ...string) {
+	if family == nil {
+		return
+	}
+	family.mutex.Lock()
+	defer family.mutex.Unlock()
+	item := family.get(labelValues)
+	item.sum += value
+	item.count++
+	for i, bound := range family.buckets {
+		if value <= bound {
+			item.buckets[i]++
+		}
+	}
+}
+
+func writeSample(buffer *bytes.Buffer, name string, labels string, value string) {
+	buffer.WriteString(name)
+	if labels != "" {
+		buffer.WriteString("{")
+		buffer.WriteString(labels)
+		buffer.WriteString("}")
+	}
+	buffer.WriteString(" ")
+	buffer.WriteString(value)
+	buffer.WriteString("\n")
+}
+
+func joinLabels(labels string, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func (family *Family) write(buffer *bytes.Buffer) {
+	buffer.WriteString("# HELP " + family.name + " " + family.help + "\n")
+	buffer.WriteString("# TYPE " + family.name + " " + family.kind + "\n")
+
+	if family.callback != nil {
+		writeSample(buffer, family.name, "", formatValue(family.callback()))
+		return
+	}
+
+	family.mutex.Lock()
+	defer family.mutex.Unlock()
+
+	keys := make([]string, 0, len(family.series))
+	for key := range family.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		item := family.series[key]
+		if family.kind != kindHistogram {
+			writeSample(buffer, family.name, item.labels, formatValue(item.value))
+			continue
+		}
+		for i, bound := range family.buckets {
+			writeSample(buffer, family.name+"_bucket", joinLabels(item.labels, `le="`+formatValue(bound)+`"`), strconv.FormatUint(item.buckets[i], 10))
+		}
+		writeSample(buffer, family.name+"_bucket", joinLabels(item.labels, `le="+Inf"`), strconv.FormatUint(item.count, 10))
+		writeSample(buffer, family.name+"_sum", item.labels, formatValue(item.sum))
+		writeSample(buffer, family.name+"_count", item.labels, strconv.FormatUint(item.count, 10))
+	}
+}
+
+// Bytes returns all metrics in prometheus text exposition format
+func (registry *Registry) Bytes() []byte {
+	if registry == nil {
+		return nil
+	}
+	registry.mutex.Lock()
+	families := append([]*Family(nil), registry.families...)
+	registry.mutex.Unlock()
+
+	var buffer bytes.Buffer
+	for _, family := range families {
+		family.write(&buffer)
+	}
+	return buffer.Bytes()
+}
+
+// WriteTo writes all metrics in prometheus text exposition format
+func (registry *Registry) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(registry.Bytes())
+	return int64(n), err
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	counter := registry.Counter("test_total", "Test counter", "tenant", "outcome")
+	gauge := registry.Gauge("test_gauge", "Test gauge")
+	histogram := registry.Histogram("test_seconds", "Test histogram", []float64{1, 0.1}, "phase")
+	registry.GaugeFunc("test_func", "Test gauge func", func() float64 { return 42 })
+
+	counter.Inc("a", "committed")
+	counter.Add(2, "a", "committed")
+	counter.Inc("b\"\n", "rollbacked")
+	gauge.Set(1.5)
+	histogram.Observe(0.05, "promise")
+	histogram.Observe(0.5, "promise")
+
+	expected := `# HELP test_total Test counter
+# TYPE test_total counter
+test_total{tenant="a",outcome="committed"} 3
+test_total{tenant="b\"\n",outcome="rollbacked"} 1
+# HELP test_gauge Test gauge
+# TYPE test_gauge gauge
+test_gauge 1.5
+# HELP test_seconds Test histogram
+# TYPE test_seconds histogram
+test_seconds_bucket{phase="promise",le="0.1"} 1
+test_seconds_bucket{phase="promise",le="1"} 2
+test_seconds_bucket{phase="promise",le="+Inf"} 2
+test_seconds_sum{phase="promise"} 0.55
+test_seconds_count{phase="promise"} 2
+# HELP test_func Test gauge func
+# TYPE test_func gauge
+test_func 42
+`
+
+	assert.Equal(t, expected, string(registry.Bytes()))
+}
@@ -26,6 +26,14 @@ type Account struct {
 	Name   string `json:"name"`
 }
 
+// AccountEntry represents committed transfer as seen by one of its accounts
+type AccountEntry struct {
+	IDTransaction string
+	Origin        string
+	Transfer      Transfer
+	Credit        bool
+}
+
 // UnmarshalJSON is json Account unmarhalling companion
 func (entity *Account) UnmarshalJSON(data []byte) error {
 	if entity == nil {
@@ -0,0 +1,251 @@
+package model
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	money "gopkg.in/inf.v0"
+)
+
+func randomToken(random *rand.Rand) string {
+	alphabet := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-:."
+	result := make([]byte, 1+random.Intn(16))
+	for i := range result {
+		result[i] = alphabet[random.Intn(len(alphabet))]
+	}
+	return string(result)
+}
+
+// serializeTransaction writes transaction as ledger-unit does in journal
+func serializeTransaction(entity Transaction) []byte {
+	var buffer strings.Builder
+	buffer.WriteString(entity.Status + "\n")
+	if entity.Metadata != nil {
+		buffer.WriteString(entity.Metadata.Serialize() + "\n")
+	}
+	for _, transfer := range entity.Transfers {
+		fields := []string{
+			transfer.IDTransfer,
+			transfer.Credit.Tenant,
+			transfer.Credit.Name,
+			transfer.Debit.Tenant,
+			transfer.Debit.Name,
+			transfer.ValueDate.Format(time.RFC3339),
+			transfer.Amount,
+			transfer.Currency,
+		}
+		if transfer.Metadata != nil {
+			fields = append(fields, transfer.Metadata.Serialize())
+		}
+		buffer.WriteString(strings.Join(fields, " ") + "\n")
+	}
+	return []byte(buffer.String())
+}
+
+func TestTransactionCodec(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	t.Log("round trip")
+	{
+		for i := 0; i < 1000; i++ {
+			expected := Transaction{
+				IDTransaction: "xxx",
+				Status:        randomToken(random),
+				Metadata:      randomMetadata(random),
+				Transfers:     make([]Transfer, random.Intn(5)),
+			}
+			for j := range expected.Transfers {
+				expected.Transfers[j] = Transfer{
+					IDTransfer: randomToken(random),
+					Credit:     Account{Tenant: randomToken(random), Name: randomToken(random)},
+					Debit:      Account{Tenant: randomToken(random), Name: randomToken(random)},
+					ValueDate:  time.Unix(random.Int63n(1<<32), 0).UTC(),
+					Amount:     randomToken(random),
+					Currency:   randomToken(random),
+					Metadata:   randomMetadata(random),
+				}
+			}
+
+			actual := Transaction{IDTransaction: "xxx"}
+			require.Nil(t, actual.Deserialize(serializeTransaction(expected)))
+			assert.Equal(t, expected, actual)
+		}
+	}
+
+	t.Log("malformed")
+	{
+		for _, data := range []string{
+			"",
+			"committed",
+			"committed\nt1 one A one B 2020-01-01T00:00:00Z 1",
+			"committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR x\n",
+			"committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR d=\n",
+			"committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR\nd=x\n",
+		} {
+			entity := new(Transaction)
+			assert.NotNil(t, entity.Deserialize([]byte(data)), data)
+		}
+
+		var nothing *Transaction
+		assert.NotNil(t, nothing.Deserialize([]byte("committed\n")))
+	}
+}
+
+func randomMetadata(random *rand.Rand) *Metadata {
+	if random.Intn(2) == 0 {
+		return nil
+	}
+	return &Metadata{
+		Description: "a b;c&d=e%f+g ř",
+		EndToEndID:  randomToken(random),
+		Tags: map[string]string{
+			randomToken(random): randomToken(random) + " " + randomToken(random),
+		},
+	}
+}
+
+func TestTransactionJSON(t *testing.T) {
+	t.Log("carries bounded metadata")
+	{
+		entity := new(Transaction)
+		require.Nil(t, json.Unmarshal([]byte(`{"id":"xyz","metadata":{"description":"payroll","tags":{"batch":"7"}},"transfers":[{"id":"1","credit":{"tenant":"one","name":"A"},"debit":{"tenant":"one","name":"B"},"amount":"1","currency":"EUR","metadata":{"endToEndId":"E2E"}}]}`), entity))
+		require.NotNil(t, entity.Metadata)
+		assert.Equal(t, "payroll", entity.Metadata.Description)
+		assert.Equal(t, "7", entity.Metadata.Tags["batch"])
+		require.NotNil(t, entity.Transfers[0].Metadata)
+		assert.Equal(t, "E2E", entity.Transfers[0].Metadata.EndToEndID)
+
+		chunk, err := json.Marshal(entity.Transfers[0])
+		require.Nil(t, err)
+		assert.Contains(t, string(chunk), `"metadata":{"endToEndId":"E2E"}`)
+	}
+
+	t.Log("drops empty metadata")
+	{
+		entity := new(Transaction)
+		require.Nil(t, json.Unmarshal([]byte(`{"id":"xyz","metadata":{},"transfers":[{"credit":{"tenant":"one","name":"A"},"debit":{"tenant":"one","name":"B"},"amount":"1","currency":"EUR"}]}`), entity))
+		assert.Nil(t, entity.Metadata)
+		assert.Nil(t, entity.Transfers[0].Metadata)
+
+		chunk, err := json.Marshal(entity)
+		require.Nil(t, err)
+		assert.NotContains(t, string(chunk), "metadata")
+	}
+
+	t.Log("rejects unbounded metadata")
+	{
+		entity := new(Transaction)
+		assert.NotNil(t, json.Unmarshal([]byte(`{"id":"xyz","metadata":{"endToEndId":"`+strings.Repeat("x", MaxEndToEndIDLength+1)+`"},"transfers":[]}`), entity))
+		assert.NotNil(t, json.Unmarshal([]byte(`{"id":"xyz","transfers":[{"credit":{"tenant":"one","name":"A"},"debit":{"tenant":"one","name":"B"},"amount":"1","currency":"EUR","metadata":{"tags":{"a b":"c"}}}]}`), entity))
+	}
+}
+
+func TestWebhookCodec(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	t.Log("round trip")
+	{
+		for i := 0; i < 1000; i++ {
+			expected := Webhook{
+				URL:    "https://" + randomToken(random) + "/" + randomToken(random),
+				Secret: randomToken(random),
+				Events: make([]string, 0),
+			}
+			for _, event := range WebhookEvents {
+				if random.Intn(2) == 0 {
+					expected.Events = append(expected.Events, event)
+				}
+			}
+
+			actual := Webhook{}
+			require.Nil(t, actual.Deserialize(expected.Serialize()))
+			assert.Equal(t, expected, actual)
+		}
+	}
+
+	t.Log("malformed")
+	{
+		entity := new(Webhook)
+		assert.NotNil(t, entity.Deserialize([]byte("https://example.com\nsecret")))
+	}
+}
+
+func TestEventCodec(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	t.Log("round trip")
+	{
+		for i := 0; i < 1000; i++ {
+			expected := Event{
+				IDTransaction: randomToken(random),
+				Status:        randomToken(random),
+				Timestamp:     time.Unix(random.Int63n(1<<32), random.Int63n(1e9)).UTC(),
+			}
+			data := expected.IDTransaction + " " + expected.Status + " " + expected.Timestamp.Format(time.RFC3339Nano)
+
+			actual := Event{}
+			require.Nil(t, actual.Deserialize([]byte(data)))
+			assert.Equal(t, expected, actual)
+		}
+	}
+
+	t.Log("malformed")
+	{
+		for _, data := range []string{"", "xxx", "xxx new", "xxx new yesterday"} {
+			entity := new(Event)
+			assert.NotNil(t, entity.Deserialize([]byte(data)), data)
+		}
+	}
+}
+
+// acceptedByUnit tells whether ledger-unit loads configuration document
+func acceptedByUnit(data []byte) bool {
+	all := struct {
+		FinalizerInterval string `json:"finalizerInterval"`
+		MaxAmount         string `json:"maxAmount"`
+	}{}
+	if json.Unmarshal(data, &all) != nil {
+		return false
+	}
+	if all.FinalizerInterval != "" {
+		if _, err := time.ParseDuration(all.FinalizerInterval); err != nil {
+			return false
+		}
+	}
+	if all.MaxAmount != "" {
+		amount, ok := new(money.Dec).SetString(all.MaxAmount)
+		if !ok || amount.Sign() <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTenantConfigCodec(t *testing.T) {
+	t.Log("maxAmount accepted by ledger-unit")
+	{
+		for _, value := range []string{"1", "1000.5", ".5", "0.01"} {
+			entity := new(TenantConfig)
+			require.Nil(t, json.Unmarshal([]byte(`{"maxAmount":"`+value+`"}`), entity), value)
+
+			data, err := json.Marshal(entity)
+			require.Nil(t, err)
+			assert.True(t, acceptedByUnit(data), value)
+		}
+	}
+
+	t.Log("maxAmount rejected by ledger-unit")
+	{
+		for _, value := range []string{"0", "-1", "1e10", "Inf", "NaN", "1_000", "0x10", " 1"} {
+			assert.False(t, acceptedByUnit([]byte(`{"maxAmount":"`+value+`"}`)), value)
+
+			entity := new(TenantConfig)
+			assert.NotNil(t, json.Unmarshal([]byte(`{"maxAmount":"`+value+`"}`), entity), value)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event represents state change of transaction
+type Event struct {
+	Sequence      uint64    `json:"-"`
+	IDTransaction string    `json:"id"`
+	Status        string    `json:"status"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Name returns name of event by transaction status
+func (entity Event) Name() string {
+	if entity.Status == "new" {
+		return "created"
+	}
+	return entity.Status
+}
+
+// Deserialize event from binary data
+func (entity *Event) Deserialize(data []byte) error {
+	if entity == nil {
+		return fmt.Errorf("cannot deserialize to nil pointer")
+	}
+	parts := strings.SplitN(string(bytes.TrimSpace(data)), " ", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed event")
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed event timestamp")
+	}
+	entity.IDTransaction = parts[0]
+	entity.Status = parts[1]
+	entity.Timestamp = timestamp
+	return nil
+}
+
+// MarshalSSE serializes event as server sent event
+func (entity Event) MarshalSSE() []byte {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("id: ")
+	buffer.WriteString(strconv.FormatUint(entity.Sequence, 10))
+	buffer.WriteString("\nevent: ")
+	buffer.WriteString(entity.Name())
+	buffer.WriteString("\ndata: {\"id\":\"")
+	buffer.WriteString(entity.IDTransaction)
+	buffer.WriteString("\",\"status\":\"")
+	buffer.WriteString(entity.Status)
+	buffer.WriteString("\",\"timestamp\":\"")
+	buffer.WriteString(entity.Timestamp.Format(time.RFC3339Nano))
+	buffer.WriteString("\"}\n\n")
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,61 @@
+//go:build go1.18
+// +build go1.18
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzTransactionDeserialize(f *testing.F) {
+	f.Add([]byte("committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR\n"))
+	f.Add([]byte("new\n"))
+	f.Add([]byte("committed\nd=payroll&t.batch=7\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR e=E2E%201\n"))
+	f.Add([]byte("committed"))
+	f.Add([]byte(""))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		entity := new(Transaction)
+		if entity.Deserialize(data) != nil {
+			return
+		}
+		again := new(Transaction)
+		require.Nil(t, again.Deserialize(serializeTransaction(*entity)))
+		require.Equal(t, entity.Status, again.Status)
+		require.Equal(t, entity.Metadata, again.Metadata)
+		require.Equal(t, len(entity.Transfers), len(again.Transfers))
+		for i := range entity.Transfers {
+			require.True(t, entity.Transfers[i].ValueDate.Equal(again.Transfers[i].ValueDate))
+			again.Transfers[i].ValueDate = entity.Transfers[i].ValueDate
+			require.Equal(t, entity.Transfers[i], again.Transfers[i])
+		}
+	})
+}
+
+func FuzzEventDeserialize(f *testing.F) {
+	f.Add([]byte("xxx committed 2020-01-01T00:00:00.123456789Z"))
+	f.Add([]byte("xxx committed"))
+	f.Add([]byte(""))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		entity := new(Event)
+		entity.Deserialize(data)
+	})
+}
+
+func FuzzWebhookDeserialize(f *testing.F) {
+	f.Add([]byte("https://example.com/hook\nsecret\ncommitted rollbacked"))
+	f.Add([]byte("\n\n"))
+	f.Add([]byte(""))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		entity := new(Webhook)
+		if entity.Deserialize(data) != nil {
+			return
+		}
+		again := new(Webhook)
+		require.Nil(t, again.Deserialize(entity.Serialize()))
+	})
+}
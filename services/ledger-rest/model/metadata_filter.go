@@ -0,0 +1,76 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"strings"
+)
+
+// MetadataFilter selects transactions by metadata, each criterion has to be
+// met by metadata of transaction or of any of its transfers
+type MetadataFilter struct {
+	// Tags maps key to required value, empty value requires presence of tag
+	Tags map[string]string
+	// EndToEndID is required end to end id
+	EndToEndID string
+	// Description is case insensitive part of required description
+	Description string
+}
+
+// IsEmpty tells whenever filter accepts any transaction
+func (filter MetadataFilter) IsEmpty() bool {
+	return len(filter.Tags) == 0 && filter.EndToEndID == "" && filter.Description == ""
+}
+
+func anyMetadata(entity *Transaction, predicate func(metadata *Metadata) bool) bool {
+	if entity.Metadata != nil && predicate(entity.Metadata) {
+		return true
+	}
+	for _, transfer := range entity.Transfers {
+		if transfer.Metadata != nil && predicate(transfer.Metadata) {
+			return true
+		}
+	}
+	return false
+}
+
+// Accepts tells whenever transaction satisfies filter
+func (filter MetadataFilter) Accepts(entity *Transaction) bool {
+	if entity == nil {
+		return false
+	}
+	for key, value := range filter.Tags {
+		if !anyMetadata(entity, func(metadata *Metadata) bool {
+			candidate, ok := metadata.Tags[key]
+			return ok && (value == "" || candidate == value)
+		}) {
+			return false
+		}
+	}
+	if filter.EndToEndID != "" && !anyMetadata(entity, func(metadata *Metadata) bool {
+		return metadata.EndToEndID == filter.EndToEndID
+	}) {
+		return false
+	}
+	if filter.Description != "" {
+		description := strings.ToLower(filter.Description)
+		if !anyMetadata(entity, func(metadata *Metadata) bool {
+			return strings.Contains(strings.ToLower(metadata.Description), description)
+		}) {
+			return false
+		}
+	}
+	return true
+}
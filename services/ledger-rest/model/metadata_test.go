@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataCodec(t *testing.T) {
+	t.Log("round trip")
+	{
+		expected := Metadata{
+			Description:           "rent; march & april = 100% + fees",
+			EndToEndID:            "E2E/1",
+			RemittanceInformation: "invoice 42",
+			Tags:                  map[string]string{"ns:kind": "rent", "batch": "7"},
+		}
+		data := expected.Serialize()
+		assert.False(t, strings.ContainsAny(data, " ;\n"))
+
+		actual := Metadata{}
+		require.Nil(t, actual.Deserialize(data))
+		assert.Equal(t, expected, actual)
+		assert.True(t, expected.Equal(actual))
+	}
+
+	t.Log("malformed")
+	{
+		for _, data := range []string{"", "d", "d=", "d=a&d=b", "x=a", "d=%ZZ", "d=a b", "t.=a"} {
+			entity := new(Metadata)
+			assert.NotNil(t, entity.Deserialize(data), data)
+		}
+	}
+
+	t.Log("bounds")
+	{
+		assert.Nil(t, Metadata{Description: strings.Repeat("ř", MaxDescriptionLength)}.Validate())
+		assert.NotNil(t, Metadata{Description: strings.Repeat("x", MaxDescriptionLength+1)}.Validate())
+		assert.NotNil(t, Metadata{EndToEndID: strings.Repeat("x", MaxEndToEndIDLength+1)}.Validate())
+		assert.NotNil(t, Metadata{RemittanceInformation: "invoice\t42"}.Validate())
+		assert.NotNil(t, Metadata{Tags: map[string]string{"k": ""}}.Validate())
+		assert.NotNil(t, Metadata{Tags: map[string]string{"a/b": "v"}}.Validate())
+	}
+}
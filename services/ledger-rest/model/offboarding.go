@@ -0,0 +1,62 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	// StepOK represents successfully finished offboarding step
+	StepOK = "ok"
+	// StepFailed represents failed offboarding step
+	StepFailed = "failed"
+	// StepSkipped represents offboarding step that was not performed
+	StepSkipped = "skipped"
+)
+
+// OffboardingStep represents outcome of single step of tenant offboarding
+type OffboardingStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Archive represents compressed snapshot of tenant's data
+type Archive struct {
+	Path     string `json:"path"`
+	Checksum string `json:"sha256"`
+	Size     int64  `json:"size"`
+}
+
+// Offboarding represents report of tenant offboarding
+type Offboarding struct {
+	Tenant  string            `json:"tenant"`
+	Steps   []OffboardingStep `json:"steps"`
+	Archive *Archive          `json:"archive,omitempty"`
+	PurgeAt *time.Time        `json:"purgeAt,omitempty"`
+}
+
+// AddStep appends outcome of step to report
+func (entity *Offboarding) AddStep(name string, status string, detail string) {
+	if entity == nil {
+		return
+	}
+	entity.Steps = append(entity.Steps, OffboardingStep{
+		Name:   name,
+		Status: status,
+		Detail: detail,
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// ResolutionMatched represents break paired manually with break of other
+	// side
+	ResolutionMatched = "matched"
+	// ResolutionAccepted represents break acknowledged without counterpart
+	ResolutionAccepted = "accepted"
+)
+
+// ReconciliationItem represents entry of either ledger or external statement
+type ReconciliationItem struct {
+	ID          string    `json:"id"`
+	Reference   string    `json:"reference,omitempty"`
+	Transaction string    `json:"transaction,omitempty"`
+	Origin      string    `json:"origin,omitempty"`
+	ValueDate   time.Time `json:"valueDate"`
+	Amount      string    `json:"amount"`
+	Currency    string    `json:"currency"`
+	Credit      bool      `json:"credit"`
+}
+
+// ReconciliationMatch represents ledger entry paired with external entry
+type ReconciliationMatch struct {
+	Internal   ReconciliationItem `json:"internal"`
+	External   ReconciliationItem `json:"external"`
+	Difference string             `json:"difference,omitempty"`
+}
+
+// ReconciliationResolution represents how break was resolved
+type ReconciliationResolution struct {
+	Action      string    `json:"action"`
+	Counterpart string    `json:"counterpart,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	ResolvedAt  time.Time `json:"resolvedAt"`
+}
+
+// ReconciliationBreak represents item without counterpart on other side
+type ReconciliationBreak struct {
+	ReconciliationItem
+	Resolution *ReconciliationResolution `json:"resolution,omitempty"`
+}
+
+// Reconciliation represents outcome of reconciling account against external
+// statement
+type Reconciliation struct {
+	ID                string                `json:"id"`
+	Account           Account               `json:"account"`
+	CreatedAt         time.Time             `json:"createdAt"`
+	From              time.Time             `json:"from"`
+	To                time.Time             `json:"to"`
+	AmountTolerance   string                `json:"amountTolerance"`
+	DateTolerance     int                   `json:"dateTolerance"`
+	Matched           []ReconciliationMatch `json:"matched"`
+	UnmatchedInternal []ReconciliationBreak `json:"unmatchedInternal"`
+	UnmatchedExternal []ReconciliationBreak `json:"unmatchedExternal"`
+}
+
+// OpenBreaks returns number of breaks not resolved yet
+func (entity *Reconciliation) OpenBreaks() int {
+	if entity == nil {
+		return 0
+	}
+	result := 0
+	for _, item := range entity.UnmatchedInternal {
+		if item.Resolution == nil {
+			result++
+		}
+	}
+	for _, item := range entity.UnmatchedExternal {
+		if item.Resolution == nil {
+			result++
+		}
+	}
+	return result
+}
+
+// Break returns break of either side with given id
+func (entity *Reconciliation) Break(id string) *ReconciliationBreak {
+	if entity == nil {
+		return nil
+	}
+	for i := range entity.UnmatchedInternal {
+		if entity.UnmatchedInternal[i].ID == id {
+			return &entity.UnmatchedInternal[i]
+		}
+	}
+	for i := range entity.UnmatchedExternal {
+		if entity.UnmatchedExternal[i].ID == id {
+			return &entity.UnmatchedExternal[i]
+		}
+	}
+	return nil
+}
+
+// MarshalJSON is json Reconciliation marhalling companion
+func (entity Reconciliation) MarshalJSON() ([]byte, error) {
+	type plain Reconciliation
+	return json.Marshal(struct {
+		plain
+		Status string `json:"status"`
+	}{
+		plain:  plain(entity),
+		Status: entity.status(),
+	})
+}
+
+func (entity Reconciliation) status() string {
+	if entity.OpenBreaks() == 0 {
+		return "reconciled"
+	}
+	return "open"
+}
+
+// UnmarshalJSON is json ReconciliationResolution unmarhalling companion
+func (entity *ReconciliationResolution) UnmarshalJSON(data []byte) error {
+	if entity == nil {
+		return fmt.Errorf("cannot unmarshal to nil pointer")
+	}
+	all := struct {
+		Action      *string    `json:"action"`
+		Counterpart string     `json:"counterpart"`
+		Note        string     `json:"note"`
+		ResolvedAt  *time.Time `json:"resolvedAt"`
+	}{}
+	err := json.Unmarshal(data, &all)
+	if err != nil {
+		return err
+	}
+	if all.Action == nil {
+		return fmt.Errorf("required field \"action\" is missing")
+	}
+	switch *all.Action {
+	case ResolutionMatched:
+		if all.Counterpart == "" {
+			return fmt.Errorf("required field \"counterpart\" is missing")
+		}
+	case ResolutionAccepted:
+		if all.Note == "" {
+			return fmt.Errorf("required field \"note\" is missing")
+		}
+		if all.Counterpart != "" {
+			return fmt.Errorf("accepted break has no counterpart")
+		}
+	default:
+		return fmt.Errorf("unknown action %s", *all.Action)
+	}
+	entity.Action = *all.Action
+	entity.Counterpart = all.Counterpart
+	entity.Note = all.Note
+	if all.ResolvedAt != nil {
+		entity.ResolvedAt = *all.ResolvedAt
+	}
+	return nil
+}
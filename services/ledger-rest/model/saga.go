@@ -0,0 +1,40 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"time"
+)
+
+// SagaAccount represents vault account taking part in negotiation of saga
+type SagaAccount struct {
+	Tenant string `json:"tenant"`
+	Name   string `json:"name"`
+	Reply  string `json:"reply,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Saga represents in-flight negotiation of transaction in ledger unit
+type Saga struct {
+	Actor          string        `json:"actor"`
+	Transaction    string        `json:"transaction"`
+	Phase          string        `json:"phase"`
+	StartedAt      time.Time     `json:"startedAt"`
+	PhaseStartedAt time.Time     `json:"phaseStartedAt"`
+	Age            float64       `json:"ageSeconds"`
+	PromiseRetries int           `json:"promiseRetries"`
+	Outstanding    []SagaAccount `json:"outstanding"`
+	Responses      []SagaAccount `json:"responses"`
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	money "gopkg.in/inf.v0"
+)
+
+// TenantConfig represents runtime configuration of tenant's ledger unit,
+// unset values fall back to configuration of service
+type TenantConfig struct {
+	Currencies        []string `json:"currencies,omitempty"`
+	FinalizerInterval string   `json:"finalizerInterval,omitempty"`
+	MaxTransfers      int      `json:"maxTransfers,omitempty"`
+	MaxAmount         string   `json:"maxAmount,omitempty"`
+	LogLevel          string   `json:"logLevel,omitempty"`
+}
+
+func isCurrency(value string) bool {
+	if len(value) != 3 {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < 'A' || value[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// UnmarshalJSON is json TenantConfig unmarhalling companion
+func (entity *TenantConfig) UnmarshalJSON(data []byte) error {
+	if entity == nil {
+		return fmt.Errorf("cannot unmarshal to nil pointer")
+	}
+
+	all := struct {
+		Currencies        []string `json:"currencies"`
+		FinalizerInterval string   `json:"finalizerInterval"`
+		MaxTransfers      int      `json:"maxTransfers"`
+		MaxAmount         string   `json:"maxAmount"`
+		LogLevel          string   `json:"logLevel"`
+	}{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&all)
+	if err != nil {
+		return err
+	}
+
+	for _, currency := range all.Currencies {
+		if !isCurrency(currency) {
+			return fmt.Errorf("invalid currency %q", currency)
+		}
+	}
+	if all.FinalizerInterval != "" {
+		interval, err := time.ParseDuration(all.FinalizerInterval)
+		if err != nil || interval < time.Second {
+			return fmt.Errorf("invalid finalizerInterval, expected duration of at least 1s")
+		}
+	}
+	if all.MaxTransfers < 0 {
+		return fmt.Errorf("invalid maxTransfers")
+	}
+	if all.MaxAmount != "" {
+		amount, ok := new(money.Dec).SetString(all.MaxAmount)
+		if !ok || amount.Sign() <= 0 {
+			return fmt.Errorf("invalid maxAmount")
+		}
+	}
+	all.LogLevel = strings.ToUpper(all.LogLevel)
+	switch all.LogLevel {
+	case "", "DEBUG", "INFO", "ERROR":
+	default:
+		return fmt.Errorf("invalid logLevel, expected one of DEBUG, INFO, ERROR")
+	}
+
+	entity.Currencies = all.Currencies
+	entity.FinalizerInterval = all.FinalizerInterval
+	entity.MaxTransfers = all.MaxTransfers
+	entity.MaxAmount = all.MaxAmount
+	entity.LogLevel = all.LogLevel
+
+	return nil
+}
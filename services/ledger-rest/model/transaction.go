@@ -28,6 +28,8 @@ import (
 type Transaction struct {
 	IDTransaction string     `json:"id"`
 	Status        string     `json:"status,omitempty"`
+	Origin        string     `json:"origin,omitempty"`
+	Metadata      *Metadata  `json:"metadata,omitempty"`
 	Transfers     []Transfer `json:"transfers"`
 }
 
@@ -39,6 +41,22 @@ type Transfer struct {
 	ValueDate  time.Time `json:"valueDate"`
 	Amount     string    `json:"amount"`
 	Currency   string    `json:"currency"`
+	Metadata   *Metadata `json:"metadata,omitempty"`
+}
+
+// TransfersOf returns transfers of transaction crediting or debiting account
+// of given tenant
+func (entity *Transaction) TransfersOf(tenant string) []Transfer {
+	result := make([]Transfer, 0)
+	if entity == nil {
+		return result
+	}
+	for _, transfer := range entity.Transfers {
+		if transfer.Credit.Tenant == tenant || transfer.Debit.Tenant == tenant {
+			result = append(result, transfer)
+		}
+	}
+	return result
 }
 
 // UnmarshalJSON is json Transaction unmarhalling companion
@@ -49,6 +67,7 @@ func (entity *Transaction) UnmarshalJSON(data []byte) error {
 
 	all := struct {
 		IDTransaction *string    `json:"id"`
+		Metadata      *Metadata  `json:"metadata"`
 		Transfers     []Transfer `json:"transfers"`
 	}{}
 
@@ -57,6 +76,12 @@ func (entity *Transaction) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	metadata, err := boundedMetadata(all.Metadata)
+	if err != nil {
+		return err
+	}
+	entity.Metadata = metadata
+
 	if all.IDTransaction != nil {
 		entity.IDTransaction = *all.IDTransaction
 	} else {
@@ -75,12 +100,13 @@ func (entity *Transfer) UnmarshalJSON(data []byte) error {
 	}
 
 	all := struct {
-		ID        *string  `json:"id"`
-		Credit    *Account `json:"credit"`
-		Debit     *Account `json:"debit"`
-		ValueDate *string  `json:"valueDate"`
-		Amount    *string  `json:"amount"`
-		Currency  *string  `json:"currency"`
+		ID        *string   `json:"id"`
+		Credit    *Account  `json:"credit"`
+		Debit     *Account  `json:"debit"`
+		ValueDate *string   `json:"valueDate"`
+		Amount    *string   `json:"amount"`
+		Currency  *string   `json:"currency"`
+		Metadata  *Metadata `json:"metadata"`
 	}{}
 
 	err := json.Unmarshal(data, &all)
@@ -109,10 +135,16 @@ func (entity *Transfer) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("invalid amount")
 	}
 
+	metadata, err := boundedMetadata(all.Metadata)
+	if err != nil {
+		return err
+	}
+
 	entity.Credit = *all.Credit
 	entity.Debit = *all.Debit
 	entity.Amount = *all.Amount
 	entity.Currency = *all.Currency
+	entity.Metadata = metadata
 
 	if all.ValueDate == nil {
 		entity.ValueDate = time.Now()
@@ -129,6 +161,17 @@ func (entity *Transfer) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// boundedMetadata validates metadata of request, empty metadata are dropped
+func boundedMetadata(metadata *Metadata) (*Metadata, error) {
+	if metadata == nil || metadata.IsEmpty() {
+		return nil, nil
+	}
+	if err := metadata.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid metadata, %s", err.Error())
+	}
+	return metadata, nil
+}
+
 // MarshalJSON is json Transfer marhalling companion
 func (entity Transfer) MarshalJSON() ([]byte, error) {
 	var buffer bytes.Buffer
@@ -149,23 +192,60 @@ func (entity Transfer) MarshalJSON() ([]byte, error) {
 	buffer.WriteString(entity.Amount)
 	buffer.WriteString("\",\"currency\":\"")
 	buffer.WriteString(entity.Currency)
-	buffer.WriteString("\"}")
+	buffer.WriteString("\"")
+	if entity.Metadata != nil {
+		metadata, err := json.Marshal(entity.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString(",\"metadata\":")
+		buffer.Write(metadata)
+	}
+	buffer.WriteString("}")
 
 	return buffer.Bytes(), nil
 }
 
 // Deserialize transaction from binary data
-func (entity *Transaction) Deserialize(data []byte) {
+func (entity *Transaction) Deserialize(data []byte) error {
+	if entity == nil {
+		return fmt.Errorf("cannot deserialize to nil pointer")
+	}
 	lines := strings.Split(string(data), "\n")
+	if len(lines) < 2 {
+		return fmt.Errorf("malformed transaction")
+	}
 	entity.Status = lines[0]
-	entity.Transfers = make([]Transfer, len(lines)-2)
+	entity.Metadata = nil
+	entity.Transfers = make([]Transfer, 0, len(lines)-1)
 
-	for i := range entity.Transfers {
-		transfer := strings.SplitN(lines[i+1], " ", 8)
+	for idx, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+		transfer := strings.Split(line, " ")
+		if len(transfer) == 1 && idx == 0 {
+			entity.Metadata = new(Metadata)
+			if err := entity.Metadata.Deserialize(transfer[0]); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(transfer) != 8 && len(transfer) != 9 {
+			return fmt.Errorf("malformed transfer")
+		}
+
+		var metadata *Metadata
+		if len(transfer) == 9 {
+			metadata = new(Metadata)
+			if err := metadata.Deserialize(transfer[8]); err != nil {
+				return err
+			}
+		}
 
 		valueDate, _ := time.Parse(time.RFC3339, transfer[5])
 
-		entity.Transfers[i] = Transfer{
+		entity.Transfers = append(entity.Transfers, Transfer{
 			IDTransfer: transfer[0],
 			Credit: Account{
 				Tenant: transfer[1],
@@ -178,8 +258,9 @@ func (entity *Transaction) Deserialize(data []byte) {
 			ValueDate: valueDate,
 			Amount:    transfer[6],
 			Currency:  transfer[7],
-		}
+			Metadata:  metadata,
+		})
 	}
 
-	return
+	return nil
 }
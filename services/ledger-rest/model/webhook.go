@@ -0,0 +1,120 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// WebhookEvents are names of events webhook can subscribe to
+var WebhookEvents = []string{"created", "accepted", "committed", "rejected", "rollbacked"}
+
+// Webhook represents subscription of tenant's transaction events
+type Webhook struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Secret string   `json:"-"`
+	Events []string `json:"events"`
+}
+
+// IsSubscribedTo tells whenever webhook should be notified about event
+func (entity Webhook) IsSubscribedTo(event string) bool {
+	for _, candidate := range entity.Events {
+		if candidate == event {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON is json Webhook unmarhalling companion
+func (entity *Webhook) UnmarshalJSON(data []byte) error {
+	if entity == nil {
+		return fmt.Errorf("cannot unmarshal to nil pointer")
+	}
+	all := struct {
+		URL    *string  `json:"url"`
+		Secret *string  `json:"secret"`
+		Events []string `json:"events"`
+	}{}
+	err := json.Unmarshal(data, &all)
+	if err != nil {
+		return err
+	}
+	if all.URL == nil {
+		return fmt.Errorf("required field \"url\" is missing")
+	}
+	if all.Secret == nil || *all.Secret == "" {
+		return fmt.Errorf("required field \"secret\" is missing")
+	}
+	target, err := url.Parse(*all.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("invalid url")
+	}
+	if strings.ContainsAny(*all.Secret, "\n") {
+		return fmt.Errorf("invalid secret")
+	}
+	if len(all.Events) == 0 {
+		all.Events = []string{"committed", "rollbacked"}
+	}
+	for _, event := range all.Events {
+		known := false
+		for _, candidate := range WebhookEvents {
+			if candidate == event {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown event %s", event)
+		}
+	}
+	entity.URL = target.String()
+	entity.Secret = *all.Secret
+	entity.Events = all.Events
+	return nil
+}
+
+// Serialize webhook to binary data
+func (entity *Webhook) Serialize() []byte {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(entity.URL)
+	buffer.WriteString("\n")
+	buffer.WriteString(entity.Secret)
+	buffer.WriteString("\n")
+	buffer.WriteString(strings.Join(entity.Events, " "))
+
+	return buffer.Bytes()
+}
+
+// Deserialize webhook from binary data
+func (entity *Webhook) Deserialize(data []byte) error {
+	if entity == nil {
+		return fmt.Errorf("cannot deserialize to nil pointer")
+	}
+	lines := strings.SplitN(string(data), "\n", 3)
+	if len(lines) != 3 {
+		return fmt.Errorf("malformed webhook")
+	}
+	entity.URL = lines[0]
+	entity.Secret = lines[1]
+	entity.Events = strings.Fields(lines[2])
+	return nil
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookUnmarshalJSON(t *testing.T) {
+	t.Log("defaults to terminal events")
+	{
+		entity := new(Webhook)
+		require.Nil(t, json.Unmarshal([]byte(`{"url":"https://example.com/hook","secret":"s"}`), entity))
+		assert.Equal(t, "https://example.com/hook", entity.URL)
+		assert.Equal(t, "s", entity.Secret)
+		assert.Equal(t, []string{"committed", "rollbacked"}, entity.Events)
+	}
+
+	t.Log("subscribes to given events")
+	{
+		entity := new(Webhook)
+		require.Nil(t, json.Unmarshal([]byte(`{"url":"http://example.com:8080","secret":"s","events":["created","rejected"]}`), entity))
+		assert.Equal(t, []string{"created", "rejected"}, entity.Events)
+		assert.True(t, entity.IsSubscribedTo("created"))
+		assert.False(t, entity.IsSubscribedTo("committed"))
+	}
+
+	t.Log("rejects invalid webhook")
+	{
+		for _, body := range []string{
+			`{"secret":"s"}`,
+			`{"url":"https://example.com"}`,
+			`{"url":"https://example.com","secret":""}`,
+			`{"url":"ftp://example.com","secret":"s"}`,
+			`{"url":"https://","secret":"s"}`,
+			`{"url":"://","secret":"s"}`,
+			`{"url":"https://example.com","secret":"a\nb"}`,
+			`{"url":"https://example.com","secret":"s","events":["deleted"]}`,
+			`[]`,
+		} {
+			assert.NotNil(t, json.Unmarshal([]byte(body), new(Webhook)), body)
+		}
+	}
+
+	t.Log("does not unmarshal to nil pointer")
+	{
+		var entity *Webhook
+		assert.NotNil(t, entity.UnmarshalJSON([]byte(`{"url":"https://example.com","secret":"s"}`)))
+	}
+
+	t.Log("secret is never marshalled")
+	{
+		data, err := json.Marshal(Webhook{ID: "w", URL: "https://example.com", Secret: "s", Events: []string{"committed"}})
+		require.Nil(t, err)
+		assert.Equal(t, `{"id":"w","url":"https://example.com","events":["committed"]}`, string(data))
+	}
+}
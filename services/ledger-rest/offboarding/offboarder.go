@@ -0,0 +1,268 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offboarding
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+	"github.com/jancajthaml-openbank/ledger-rest/system"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+const drainPollInterval = 250 * time.Millisecond
+
+// ErrPendingTransactions is returned when tenant cannot be offboarded because
+// of non terminal transactions
+var ErrPendingTransactions = fmt.Errorf("tenant has pending transactions")
+
+// Offboarder disables tenant's ledger unit, archives its data and purges them
+// after retention period
+type Offboarder struct {
+	rootStorage      string
+	archiveDirectory string
+	storage          localfs.Storage
+	control          system.Control
+	drainTimeout     time.Duration
+	retention        time.Duration
+	mutex            sync.Mutex
+	fenced           map[string]bool
+	intake           map[string]int
+}
+
+// NewOffboarder returns offboarding fascade
+func NewOffboarder(rootStorage string, archiveDirectory string, drainTimeout time.Duration, retention time.Duration, control system.Control) *Offboarder {
+	storage, err := localfs.NewPlaintextStorage(rootStorage)
+	if err != nil {
+		log.Error().Msgf("Failed to ensure storage %+v", err)
+		return nil
+	}
+	return &Offboarder{
+		rootStorage:      rootStorage,
+		archiveDirectory: archiveDirectory,
+		storage:          storage,
+		control:          control,
+		drainTimeout:     drainTimeout,
+		retention:        retention,
+		fenced:           make(map[string]bool),
+		intake:           make(map[string]int),
+	}
+}
+
+// Admit admits new transaction intake of tenant unless tenant is fenced by
+// offboarding, admitted intake must be released once it is finished
+func (offboarder *Offboarder) Admit(tenant string) bool {
+	if offboarder == nil {
+		return true
+	}
+	offboarder.mutex.Lock()
+	defer offboarder.mutex.Unlock()
+	if offboarder.fenced[tenant] {
+		return false
+	}
+	offboarder.intake[tenant]++
+	return true
+}
+
+// Release marks admitted transaction intake of tenant as finished
+func (offboarder *Offboarder) Release(tenant string) {
+	if offboarder == nil {
+		return
+	}
+	offboarder.mutex.Lock()
+	defer offboarder.mutex.Unlock()
+	offboarder.intake[tenant]--
+	if offboarder.intake[tenant] <= 0 {
+		delete(offboarder.intake, tenant)
+	}
+}
+
+// Unfence resumes transaction intake of tenant
+func (offboarder *Offboarder) Unfence(tenant string) {
+	if offboarder == nil {
+		return
+	}
+	offboarder.mutex.Lock()
+	defer offboarder.mutex.Unlock()
+	delete(offboarder.fenced, tenant)
+}
+
+func (offboarder *Offboarder) fence(tenant string) {
+	offboarder.mutex.Lock()
+	defer offboarder.mutex.Unlock()
+	offboarder.fenced[tenant] = true
+}
+
+func (offboarder *Offboarder) intakeOf(tenant string) int {
+	offboarder.mutex.Lock()
+	defer offboarder.mutex.Unlock()
+	return offboarder.intake[tenant]
+}
+
+func (offboarder *Offboarder) drain(ctx context.Context, tenant string, wait bool) (int, error) {
+	deadline := time.Now().Add(offboarder.drainTimeout)
+	for {
+		ids, err := persistence.LoadPendingTransactionsIDs(offboarder.storage, tenant)
+		if err != nil {
+			return 0, err
+		}
+		pending := len(ids) + offboarder.intakeOf(tenant)
+		if pending == 0 || !wait || time.Now().After(deadline) {
+			return pending, nil
+		}
+		select {
+		case <-ctx.Done():
+			return pending, nil
+		case <-time.After(drainPollInterval):
+		}
+	}
+}
+
+// Offboard stops transaction intake of tenant, disables ledger-unit@{tenant}
+// once it has no pending transactions, archives its data and schedules purge
+// of them, with drain set waits for pending transactions to finish first,
+// intake is resumed when tenant cannot be disabled
+func (offboarder *Offboarder) Offboard(ctx context.Context, tenant string, drain bool) (*model.Offboarding, error) {
+	if offboarder == nil {
+		return nil, fmt.Errorf("nil pointer")
+	}
+
+	report := &model.Offboarding{
+		Tenant: tenant,
+		Steps:  make([]model.OffboardingStep, 0),
+	}
+
+	offboarder.fence(tenant)
+	report.AddStep("fence", model.StepOK, "intake stopped")
+
+	pending, err := offboarder.drain(ctx, tenant, drain)
+	if err != nil {
+		offboarder.Unfence(tenant)
+		report.AddStep("drain", model.StepFailed, err.Error())
+		return report, err
+	}
+	if pending > 0 {
+		offboarder.Unfence(tenant)
+		report.AddStep("drain", model.StepFailed, fmt.Sprintf("%d pending transactions", pending))
+		return report, ErrPendingTransactions
+	}
+	report.AddStep("drain", model.StepOK, "no pending transactions")
+
+	if err := offboarder.control.DisableUnit("unit@" + tenant + ".service"); err != nil {
+		offboarder.Unfence(tenant)
+		report.AddStep("disable", model.StepFailed, err.Error())
+		return report, err
+	}
+	report.AddStep("disable", model.StepOK, "")
+
+	if _, err := os.Stat(filepath.Join(offboarder.rootStorage, "t_"+tenant)); os.IsNotExist(err) {
+		report.AddStep("archive", model.StepSkipped, "no data")
+		report.AddStep("purge", model.StepSkipped, "no data")
+		return report, nil
+	}
+
+	archive, err := persistence.CreateTenantArchive(offboarder.rootStorage, offboarder.archiveDirectory, tenant, time.Now())
+	if err != nil {
+		report.AddStep("archive", model.StepFailed, err.Error())
+		return report, err
+	}
+	report.Archive = archive
+	report.AddStep("archive", model.StepOK, archive.Path)
+
+	if offboarder.retention <= 0 {
+		report.AddStep("purge", model.StepSkipped, "retention not configured")
+		return report, nil
+	}
+
+	purgeAt := time.Now().Add(offboarder.retention).UTC()
+	if err := persistence.CreatePurgeSchedule(offboarder.storage, tenant, purgeAt, archive.Path); err != nil {
+		report.AddStep("purge", model.StepFailed, err.Error())
+		return report, err
+	}
+	report.PurgeAt = &purgeAt
+	report.AddStep("purge", model.StepOK, "scheduled at "+purgeAt.Format(time.RFC3339))
+
+	return report, nil
+}
+
+// CancelPurge cancels scheduled purge of tenant's data
+func (offboarder *Offboarder) CancelPurge(tenant string) error {
+	if offboarder == nil {
+		return nil
+	}
+	return persistence.DeletePurgeSchedule(offboarder.storage, tenant)
+}
+
+func (offboarder *Offboarder) purge(tenant string) {
+	at, archivePath, err := persistence.LoadPurgeSchedule(offboarder.storage, tenant)
+	if err != nil {
+		log.Warn().Msgf("Unable to load purge schedule of tenant %s %+v", tenant, err)
+		return
+	}
+	if time.Now().Before(at) {
+		return
+	}
+	if err := persistence.VerifyTenantArchive(archivePath); err != nil {
+		log.Error().Msgf("Refusing to purge data of tenant %s, archive %s is not valid %+v", tenant, archivePath, err)
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(offboarder.rootStorage, "t_"+tenant)); err != nil {
+		log.Warn().Msgf("Unable to purge data of tenant %s %+v", tenant, err)
+		return
+	}
+	if err := persistence.DeletePurgeSchedule(offboarder.storage, tenant); err != nil {
+		log.Warn().Msgf("Unable to delete purge schedule of tenant %s %+v", tenant, err)
+		return
+	}
+	log.Info().Msgf("Purged data of tenant %s, archived at %s", tenant, archivePath)
+}
+
+// Setup does nothing
+func (offboarder *Offboarder) Setup() error {
+	return nil
+}
+
+// Done always returns done
+func (offboarder *Offboarder) Done() <-chan interface{} {
+	done := make(chan interface{})
+	close(done)
+	return done
+}
+
+// Cancel does nothing
+func (offboarder *Offboarder) Cancel() {
+}
+
+// Work purges data of tenants whose retention period has passed
+func (offboarder *Offboarder) Work() {
+	if offboarder == nil {
+		return
+	}
+	tenants, err := persistence.LoadPurgeSchedulesTenants(offboarder.storage)
+	if err != nil {
+		log.Warn().Msgf("Unable to list purge schedules %+v", err)
+		return
+	}
+	for _, tenant := range tenants {
+		offboarder.purge(tenant)
+	}
+}
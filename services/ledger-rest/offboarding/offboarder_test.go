@@ -0,0 +1,155 @@
+package offboarding
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+	"github.com/jancajthaml-openbank/ledger-rest/system"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSystemControl struct {
+	system.Control
+	disabled []string
+}
+
+func (sys *mockSystemControl) DisableUnit(name string) error {
+	sys.disabled = append(sys.disabled, name)
+	return nil
+}
+
+func archivedFiles(t *testing.T, path string) map[string]string {
+	file, err := os.Open(path)
+	require.Nil(t, err)
+	defer file.Close()
+	compressed, err := gzip.NewReader(file)
+	require.Nil(t, err)
+	tarball := tar.NewReader(compressed)
+	result := make(map[string]string)
+	for {
+		header, err := tarball.Next()
+		if err != nil {
+			break
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tarball)
+		require.Nil(t, err)
+		result[header.Name] = string(data)
+	}
+	return result
+}
+
+func TestOffboard(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_offboarding")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	require.Nil(t, err)
+	require.Nil(t, storage.WriteFile("t_a/transaction/x", []byte("committed")))
+	require.Nil(t, storage.WriteFile("t_a/transaction/y", []byte("new")))
+
+	control := new(mockSystemControl)
+	offboarder := NewOffboarder(tmpdir, filepath.Join(tmpdir, "archive"), 300*time.Millisecond, time.Hour, control)
+	require.NotNil(t, offboarder)
+
+	t.Log("refuses with pending transactions")
+	{
+		report, err := offboarder.Offboard(context.Background(), "a", false)
+		assert.Equal(t, ErrPendingTransactions, err)
+		require.NotNil(t, report)
+		assert.Equal(t, "fence", report.Steps[0].Name)
+		assert.Equal(t, model.StepFailed, report.Steps[1].Status)
+		assert.Empty(t, control.disabled)
+		assert.True(t, offboarder.Admit("a"))
+		offboarder.Release("a")
+	}
+
+	t.Log("admitted intake is pending transaction")
+	{
+		require.Nil(t, storage.WriteFile("t_b/transaction/x", []byte("committed")))
+		assert.True(t, offboarder.Admit("b"))
+
+		_, err := offboarder.Offboard(context.Background(), "b", false)
+		assert.Equal(t, ErrPendingTransactions, err)
+
+		offboarder.Release("b")
+	}
+
+	t.Log("drain gives up after timeout")
+	{
+		_, err := offboarder.Offboard(context.Background(), "a", true)
+		assert.Equal(t, ErrPendingTransactions, err)
+	}
+
+	t.Log("drain waits for pending transactions")
+	{
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			storage.WriteFile("t_a/transaction/y", []byte("rollbacked"))
+		}()
+
+		report, err := offboarder.Offboard(context.Background(), "a", true)
+		require.Nil(t, err)
+		assert.Equal(t, []string{"unit@a.service"}, control.disabled)
+		assert.False(t, offboarder.Admit("a"))
+		require.NotNil(t, report.Archive)
+		require.NotNil(t, report.PurgeAt)
+		assert.Nil(t, persistence.VerifyTenantArchive(report.Archive.Path))
+		assert.Equal(t, map[string]string{
+			"t_a/transaction/x": "committed",
+			"t_a/transaction/y": "rollbacked",
+		}, archivedFiles(t, report.Archive.Path))
+	}
+
+	t.Log("purge waits for retention")
+	{
+		offboarder.Work()
+		exists, err := storage.Exists("t_a")
+		require.Nil(t, err)
+		assert.True(t, exists)
+	}
+
+	t.Log("purge refuses tampered archive")
+	{
+		_, archive, err := persistence.LoadPurgeSchedule(storage, "a")
+		require.Nil(t, err)
+		require.Nil(t, persistence.CreatePurgeSchedule(storage, "a", time.Now().Add(-time.Second), archive))
+
+		original, err := ioutil.ReadFile(archive)
+		require.Nil(t, err)
+		require.Nil(t, ioutil.WriteFile(archive, []byte("tampered"), 0600))
+
+		offboarder.Work()
+		exists, err := storage.Exists("t_a")
+		require.Nil(t, err)
+		assert.True(t, exists)
+
+		require.Nil(t, ioutil.WriteFile(archive, original, 0600))
+	}
+
+	t.Log("purge after retention")
+	{
+		offboarder.Work()
+		exists, err := storage.Exists("t_a")
+		require.Nil(t, err)
+		assert.False(t, exists)
+
+		tenants, err := persistence.LoadPurgeSchedulesTenants(storage)
+		require.Nil(t, err)
+		assert.Empty(t, tenants)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	money "gopkg.in/inf.v0"
+)
+
+// Submitter submits transaction to ledger and returns status of payments
+// and reason code of rejection
+type Submitter func(transaction model.Transaction) (string, string)
+
+var currencyPattern = regexp.MustCompile("^[A-Z]{3}$")
+
+// transactionID derives id of transaction from payment information so that
+// repeated import of same message is refused as duplicate
+func transactionID(messageID string, paymentID string) string {
+	sum := sha256.Sum256([]byte(messageID + "/" + paymentID))
+	return hex.EncodeToString(sum[:])[:32]
+}
+
+func parseExecutionDate(value executionDate, now time.Time) (time.Time, bool) {
+	if value.DateTime != "" {
+		result, err := time.Parse(time.RFC3339, strings.TrimSpace(value.DateTime))
+		return result.UTC(), err == nil
+	}
+	date := strings.TrimSpace(value.Date)
+	if date == "" {
+		date = strings.TrimSpace(value.Value)
+	}
+	if date == "" {
+		return now.UTC(), true
+	}
+	result, err := time.Parse("2006-01-02", date)
+	return result, err == nil
+}
+
+func isSafeIdentifier(value string) bool {
+	return value != "" && !strings.ContainsAny(value, " ;\t\r\n")
+}
+
+// transferIDs returns ids of transfers of payment, end to end ids are used
+// when all of them are usable, position in block otherwise
+func transferIDs(transfers []creditTransfer) []string {
+	result := make([]string, len(transfers))
+	seen := make(map[string]bool)
+	for i, transfer := range transfers {
+		id := strings.TrimSpace(transfer.EndToEndID)
+		if !isSafeIdentifier(id) || id == "NOTPROVIDED" || seen[id] {
+			for j := range transfers {
+				result[j] = strconv.Itoa(j + 1)
+			}
+			return result
+		}
+		seen[id] = true
+		result[i] = id
+	}
+	return result
+}
+
+func rejected(reason string) *statusReason {
+	return &statusReason{
+		Code: reason,
+	}
+}
+
+func importPayment(messageID string, payment paymentInformation, tenant string, mapping *IBANMapping, submit Submitter, now time.Time) paymentStatus {
+	result := paymentStatus{
+		PaymentID: payment.ID,
+		Transfers: make([]transactionStatus, len(payment.Transfers)),
+	}
+	for i, transfer := range payment.Transfers {
+		result.Transfers[i] = transactionStatus{
+			InstructionID: transfer.InstructionID,
+			EndToEndID:    transfer.EndToEndID,
+		}
+	}
+
+	rejectAll := func(reason string) paymentStatus {
+		result.Status = StatusRejected
+		result.Reason = rejected(reason)
+		for i := range result.Transfers {
+			result.Transfers[i].Status = StatusRejected
+		}
+		return result
+	}
+
+	debtor, ok := mapping.Resolve(payment.DebtorAccount.IBAN)
+	if !ok || debtor.Tenant != tenant {
+		return rejectAll(ReasonInvalidDebtorAccount)
+	}
+	valueDate, ok := parseExecutionDate(payment.ExecutionDate, now)
+	if !ok {
+		return rejectAll(ReasonInvalidDate)
+	}
+	if len(payment.Transfers) == 0 {
+		return rejectAll(ReasonNotSpecified)
+	}
+
+	ids := transferIDs(payment.Transfers)
+	transaction := model.Transaction{
+		IDTransaction: transactionID(messageID, payment.ID),
+		Transfers:     make([]model.Transfer, 0, len(payment.Transfers)),
+	}
+	submitted := make([]int, 0, len(payment.Transfers))
+
+	for i, transfer := range payment.Transfers {
+		creditor, ok := mapping.Resolve(transfer.CreditorAccount.IBAN)
+		if !ok {
+			result.Transfers[i].Status = StatusRejected
+			result.Transfers[i].Reason = rejected(ReasonInvalidCreditorAccount)
+			continue
+		}
+		amount, ok := new(money.Dec).SetString(strings.TrimSpace(transfer.Amount.Value))
+		if !ok || amount.Sign() <= 0 {
+			result.Transfers[i].Status = StatusRejected
+			result.Transfers[i].Reason = rejected(ReasonInvalidAmount)
+			continue
+		}
+		if !currencyPattern.MatchString(transfer.Amount.Currency) {
+			result.Transfers[i].Status = StatusRejected
+			result.Transfers[i].Reason = rejected(ReasonInvalidCurrency)
+			continue
+		}
+		transaction.Transfers = append(transaction.Transfers, model.Transfer{
+			IDTransfer: ids[i],
+			Credit:     creditor,
+			Debit:      debtor,
+			ValueDate:  valueDate,
+			Amount:     amount.String(),
+			Currency:   transfer.Amount.Currency,
+		})
+		submitted = append(submitted, i)
+	}
+
+	if len(submitted) != 0 {
+		status, reason := submit(transaction)
+		for _, i := range submitted {
+			result.Transfers[i].Status = status
+			if reason != "" {
+				result.Transfers[i].Reason = rejected(reason)
+			}
+		}
+	}
+
+	statuses := make([]string, len(result.Transfers))
+	for i, transfer := range result.Transfers {
+		statuses[i] = transfer.Status
+	}
+	result.Status = aggregateStatus(statuses)
+	return result
+}
+
+// Import submits one transaction per payment information block of given
+// initiation on behalf of tenant and returns status report of outcome
+func Import(id string, document *Pain001, tenant string, mapping *IBANMapping, submit Submitter, createdAt time.Time) *Pain002 {
+	if document == nil {
+		return nil
+	}
+	result := &Pain002{
+		Namespace: Pain002Namespace,
+		Report: report{
+			MessageID: id,
+			CreatedAt: createdAt.UTC().Format("2006-01-02T15:04:05"),
+			Group: groupStatus{
+				MessageID:   document.Initiation.MessageID,
+				MessageName: document.MessageName(),
+			},
+			Payments: make([]paymentStatus, 0, len(document.Initiation.Payments)),
+		},
+	}
+	statuses := make([]string, 0)
+	for _, payment := range document.Initiation.Payments {
+		status := importPayment(document.Initiation.MessageID, payment, tenant, mapping, submit, createdAt)
+		for _, transfer := range status.Transfers {
+			statuses = append(statuses, transfer.Status)
+		}
+		result.Report.Payments = append(result.Report.Payments, status)
+	}
+	result.Report.Group.NumberOfTransactions = strconv.Itoa(len(statuses))
+	result.Report.Group.Status = aggregateStatus(statuses)
+	return result
+}
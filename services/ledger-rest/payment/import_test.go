@@ -0,0 +1,206 @@
+package payment
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pain001Document = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>MSG-1</MsgId>
+      <CreDtTm>2020-01-01T10:00:00</CreDtTm>
+      <NbOfTxs>5</NbOfTxs>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <ReqdExctnDt>2020-01-02</ReqdExctnDt>
+      <DbtrAcct><Id><IBAN>CZ0708000000001234567890</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><InstrId>I-1</InstrId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">10.50</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>CZ6408000000002222222222</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">1</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-3</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">-1</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>CZ6408000000002222222222</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+    <PmtInf>
+      <PmtInfId>PMT-2</PmtInfId>
+      <ReqdExctnDt>2020-01-03</ReqdExctnDt>
+      <DbtrAcct><Id><IBAN>CZ5701000000003333333333</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-4</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">5</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>CZ6408000000002222222222</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+    <PmtInf>
+      <PmtInfId>PMT-3</PmtInfId>
+      <ReqdExctnDt><Dt>2020-01-04</Dt></ReqdExctnDt>
+      <DbtrAcct><Id><IBAN>CZ6408000000002222222222</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>NOTPROVIDED</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="CZK">100</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>CZ0708000000001234567890</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func TestParsePain001(t *testing.T) {
+	t.Log("parses payment information blocks")
+	{
+		document, err := ParsePain001([]byte(pain001Document))
+		require.Nil(t, err)
+		assert.Equal(t, "MSG-1", document.Initiation.MessageID)
+		assert.Equal(t, "pain.001.001.03", document.MessageName())
+		require.Equal(t, 3, len(document.Initiation.Payments))
+		assert.Equal(t, 3, len(document.Initiation.Payments[0].Transfers))
+		assert.Equal(t, "EUR", document.Initiation.Payments[0].Transfers[0].Amount.Currency)
+		assert.Equal(t, "10.50", document.Initiation.Payments[0].Transfers[0].Amount.Value)
+	}
+
+	t.Log("rejects other documents")
+	{
+		_, err := ParsePain001([]byte(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"><CstmrCdtTrfInitn><GrpHdr><MsgId>A</MsgId></GrpHdr><PmtInf/></CstmrCdtTrfInitn></Document>`))
+		assert.NotNil(t, err)
+		_, err = ParsePain001([]byte(`<Document><CstmrCdtTrfInitn><GrpHdr><MsgId>A</MsgId></GrpHdr></CstmrCdtTrfInitn></Document>`))
+		assert.NotNil(t, err)
+		_, err = ParsePain001([]byte(`<Document><CstmrCdtTrfInitn><PmtInf/></CstmrCdtTrfInitn></Document>`))
+		assert.NotNil(t, err)
+		_, err = ParsePain001([]byte(`not xml`))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	mapping, err := NewIBANMapping(map[string]string{
+		"CZ0708000000001234567890": "one/A",
+		"CZ6408000000002222222222": "two/B",
+		"CZ5701000000003333333333": "two/C",
+	})
+	require.Nil(t, err)
+
+	document, err := ParsePain001([]byte(pain001Document))
+	require.Nil(t, err)
+
+	submitted := make([]model.Transaction, 0)
+	submit := func(transaction model.Transaction) (string, string) {
+		submitted = append(submitted, transaction)
+		return StatusSettled, ""
+	}
+
+	createdAt := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	report := Import("report", document, "one", mapping, submit, createdAt)
+	require.NotNil(t, report)
+
+	t.Log("submits valid transfers of own blocks")
+	{
+		require.Equal(t, 1, len(submitted))
+		transaction := submitted[0]
+		assert.Equal(t, transactionID("MSG-1", "PMT-1"), transaction.IDTransaction)
+		assert.Equal(t, 32, len(transaction.IDTransaction))
+		require.Equal(t, 1, len(transaction.Transfers))
+		assert.Equal(t, model.Transfer{
+			IDTransfer: "E2E-1",
+			Credit:     model.Account{Tenant: "two", Name: "B"},
+			Debit:      model.Account{Tenant: "one", Name: "A"},
+			ValueDate:  time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+			Amount:     "10.50",
+			Currency:   "EUR",
+		}, transaction.Transfers[0])
+	}
+
+	t.Log("reports status of each item")
+	{
+		group := report.Report.Group
+		assert.Equal(t, "MSG-1", group.MessageID)
+		assert.Equal(t, "pain.001.001.03", group.MessageName)
+		assert.Equal(t, "5", group.NumberOfTransactions)
+		assert.Equal(t, StatusPartial, group.Status)
+
+		require.Equal(t, 3, len(report.Report.Payments))
+
+		first := report.Report.Payments[0]
+		assert.Equal(t, "PMT-1", first.PaymentID)
+		assert.Equal(t, StatusPartial, first.Status)
+		require.Equal(t, 3, len(first.Transfers))
+		assert.Equal(t, "I-1", first.Transfers[0].InstructionID)
+		assert.Equal(t, StatusSettled, first.Transfers[0].Status)
+		assert.Nil(t, first.Transfers[0].Reason)
+		assert.Equal(t, StatusRejected, first.Transfers[1].Status)
+		assert.Equal(t, ReasonInvalidCreditorAccount, first.Transfers[1].Reason.Code)
+		assert.Equal(t, StatusRejected, first.Transfers[2].Status)
+		assert.Equal(t, ReasonInvalidAmount, first.Transfers[2].Reason.Code)
+
+		for _, other := range report.Report.Payments[1:] {
+			assert.Equal(t, StatusRejected, other.Status)
+			assert.Equal(t, ReasonInvalidDebtorAccount, other.Reason.Code)
+			assert.Equal(t, StatusRejected, other.Transfers[0].Status)
+		}
+	}
+
+	t.Log("serializes status report")
+	{
+		chunk, err := report.Serialize()
+		require.Nil(t, err)
+
+		var decoded Pain002
+		require.Nil(t, xml.Unmarshal(chunk, &decoded))
+		assert.Equal(t, Pain002Namespace, decoded.XMLName.Space)
+		assert.Equal(t, "report", decoded.Report.MessageID)
+		assert.Equal(t, "2020-01-01T12:00:00", decoded.Report.CreatedAt)
+		assert.Equal(t, StatusPartial, decoded.Report.Group.Status)
+		assert.Equal(t, 3, len(decoded.Report.Payments))
+	}
+
+	t.Log("propagates outcome of ledger")
+	{
+		submitted = submitted[:0]
+		rejecting := func(transaction model.Transaction) (string, string) {
+			submitted = append(submitted, transaction)
+			return StatusRejected, ReasonDuplicate
+		}
+		report := Import("report", document, "two", mapping, rejecting, createdAt)
+
+		require.Equal(t, 2, len(submitted))
+		assert.Equal(t, "1", submitted[1].Transfers[0].IDTransfer)
+		assert.Equal(t, time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), submitted[1].Transfers[0].ValueDate)
+
+		assert.Equal(t, StatusRejected, report.Report.Group.Status)
+		assert.Equal(t, ReasonInvalidDebtorAccount, report.Report.Payments[0].Reason.Code)
+		assert.Equal(t, ReasonDuplicate, report.Report.Payments[1].Transfers[0].Reason.Code)
+		assert.Equal(t, ReasonDuplicate, report.Report.Payments[2].Transfers[0].Reason.Code)
+	}
+}
+
+func TestTransferIDs(t *testing.T) {
+	t.Log("end to end ids when usable")
+	{
+		ids := transferIDs([]creditTransfer{{EndToEndID: "A"}, {EndToEndID: "B"}})
+		assert.Equal(t, []string{"A", "B"}, ids)
+	}
+
+	t.Log("positions when any is unusable")
+	{
+		assert.Equal(t, []string{"1", "2"}, transferIDs([]creditTransfer{{EndToEndID: "A"}, {EndToEndID: "A"}}))
+		assert.Equal(t, []string{"1", "2"}, transferIDs([]creditTransfer{{EndToEndID: "A"}, {EndToEndID: "B C"}}))
+		assert.Equal(t, []string{"1", "2"}, transferIDs([]creditTransfer{{EndToEndID: "A;"}, {EndToEndID: "B"}}))
+	}
+}
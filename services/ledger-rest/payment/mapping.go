@@ -0,0 +1,127 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/support/naming"
+)
+
+// IBANMapping resolves IBAN of local account to account of ledger
+type IBANMapping struct {
+	accounts map[string]model.Account
+}
+
+// NormalizeIBAN returns IBAN in electronic format
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Replace(strings.TrimSpace(iban), " ", "", -1))
+}
+
+// ValidateIBAN returns error describing why value is not valid IBAN in
+// electronic format
+func ValidateIBAN(iban string) error {
+	if len(iban) < 15 || len(iban) > 34 {
+		return fmt.Errorf("IBAN %s has invalid length", iban)
+	}
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c >= 'A' && c <= 'Z':
+			digits.WriteString(fmt.Sprintf("%d", c-'A'+10))
+		default:
+			return fmt.Errorf("IBAN %s contains invalid character %q", iban, c)
+		}
+	}
+	number, _ := new(big.Int).SetString(digits.String(), 10)
+	if new(big.Int).Mod(number, big.NewInt(97)).Int64() != 1 {
+		return fmt.Errorf("IBAN %s has invalid check digits", iban)
+	}
+	return nil
+}
+
+func parseAccount(value string) (model.Account, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return model.Account{}, fmt.Errorf("account %q is not in form tenant/name", value)
+	}
+	if err := naming.ValidateTenant(parts[0]); err != nil {
+		return model.Account{}, err
+	}
+	if parts[1] == "" || strings.ContainsAny(parts[1], " ;") {
+		return model.Account{}, fmt.Errorf("account %q has invalid name", value)
+	}
+	return model.Account{
+		Tenant: parts[0],
+		Name:   parts[1],
+	}, nil
+}
+
+// NewIBANMapping returns mapping of IBAN to account given as "tenant/name"
+func NewIBANMapping(entries map[string]string) (*IBANMapping, error) {
+	result := &IBANMapping{
+		accounts: make(map[string]model.Account),
+	}
+	for iban, value := range entries {
+		normalized := NormalizeIBAN(iban)
+		if err := ValidateIBAN(normalized); err != nil {
+			return nil, err
+		}
+		account, err := parseAccount(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid account of IBAN %s, %s", normalized, err.Error())
+		}
+		result.accounts[normalized] = account
+	}
+	return result, nil
+}
+
+// LoadIBANMapping loads mapping from json file of IBAN to "tenant/name"
+func LoadIBANMapping(path string) (*IBANMapping, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]string)
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid IBAN mapping %s because %+v", path, err)
+	}
+	return NewIBANMapping(entries)
+}
+
+// Resolve returns account of given IBAN
+func (mapping *IBANMapping) Resolve(iban string) (model.Account, bool) {
+	if mapping == nil {
+		return model.Account{}, false
+	}
+	account, ok := mapping.accounts[NormalizeIBAN(iban)]
+	return account, ok
+}
+
+// Size returns number of mapped accounts
+func (mapping *IBANMapping) Size() int {
+	if mapping == nil {
+		return 0
+	}
+	return len(mapping.accounts)
+}
@@ -0,0 +1,94 @@
+package payment
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	t.Log("valid")
+	{
+		assert.Nil(t, ValidateIBAN("DE89370400440532013000"))
+		assert.Nil(t, ValidateIBAN(NormalizeIBAN("cz07 0800 0000 0012 3456 7890")))
+	}
+
+	t.Log("invalid")
+	{
+		assert.NotNil(t, ValidateIBAN("DE88370400440532013000"))
+		assert.NotNil(t, ValidateIBAN("DE89"))
+		assert.NotNil(t, ValidateIBAN("DE89-370400440532013000"))
+	}
+}
+
+func TestIBANMapping(t *testing.T) {
+	t.Log("resolves normalized IBAN")
+	{
+		mapping, err := NewIBANMapping(map[string]string{
+			"CZ07 0800 0000 0012 3456 7890": "one/A",
+		})
+		require.Nil(t, err)
+		assert.Equal(t, 1, mapping.Size())
+
+		account, ok := mapping.Resolve("cz0708000000001234567890")
+		assert.True(t, ok)
+		assert.Equal(t, model.Account{Tenant: "one", Name: "A"}, account)
+
+		_, ok = mapping.Resolve("DE89370400440532013000")
+		assert.False(t, ok)
+	}
+
+	t.Log("rejects invalid entries")
+	{
+		_, err := NewIBANMapping(map[string]string{"DE88370400440532013000": "one/A"})
+		assert.NotNil(t, err)
+		_, err = NewIBANMapping(map[string]string{"DE89370400440532013000": "one"})
+		assert.NotNil(t, err)
+		_, err = NewIBANMapping(map[string]string{"DE89370400440532013000": "one/A;B"})
+		assert.NotNil(t, err)
+		_, err = NewIBANMapping(map[string]string{"DE89370400440532013000": "../A"})
+		assert.NotNil(t, err)
+	}
+
+	t.Log("nil mapping resolves nothing")
+	{
+		var mapping *IBANMapping
+		_, ok := mapping.Resolve("DE89370400440532013000")
+		assert.False(t, ok)
+	}
+}
+
+func TestLoadIBANMapping(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_iban_mapping")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	t.Log("loads json file")
+	{
+		path := filepath.Join(tmpdir, "valid.json")
+		require.Nil(t, ioutil.WriteFile(path, []byte(`{"DE89370400440532013000":"one/A"}`), 0600))
+		mapping, err := LoadIBANMapping(path)
+		require.Nil(t, err)
+		assert.Equal(t, 1, mapping.Size())
+	}
+
+	t.Log("malformed file")
+	{
+		path := filepath.Join(tmpdir, "malformed.json")
+		require.Nil(t, ioutil.WriteFile(path, []byte(`["DE89370400440532013000"]`), 0600))
+		_, err := LoadIBANMapping(path)
+		assert.NotNil(t, err)
+	}
+
+	t.Log("missing file")
+	{
+		_, err := LoadIBANMapping(filepath.Join(tmpdir, "missing.json"))
+		assert.NotNil(t, err)
+	}
+}
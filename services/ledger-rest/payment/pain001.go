@@ -0,0 +1,94 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payment
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const isoNamespacePrefix = "urn:iso:std:iso:20022:tech:xsd:"
+
+// MaxPain001Size is maximum size of accepted initiation document in bytes
+const MaxPain001Size = 4 << 20
+
+// Pain001 represents ISO 20022 customer credit transfer initiation
+type Pain001 struct {
+	XMLName    xml.Name   `xml:"Document"`
+	Initiation initiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type initiation struct {
+	MessageID string               `xml:"GrpHdr>MsgId"`
+	Payments  []paymentInformation `xml:"PmtInf"`
+}
+
+type paymentInformation struct {
+	ID            string           `xml:"PmtInfId"`
+	ExecutionDate executionDate    `xml:"ReqdExctnDt"`
+	DebtorAccount accountReference `xml:"DbtrAcct"`
+	Transfers     []creditTransfer `xml:"CdtTrfTxInf"`
+}
+
+// executionDate is plain date in older versions and choice of date or date
+// time in newer ones
+type executionDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+	Value    string `xml:",chardata"`
+}
+
+type accountReference struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+type instructedAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type creditTransfer struct {
+	InstructionID   string           `xml:"PmtId>InstrId"`
+	EndToEndID      string           `xml:"PmtId>EndToEndId"`
+	Amount          instructedAmount `xml:"Amt>InstdAmt"`
+	CreditorAccount accountReference `xml:"CdtrAcct"`
+}
+
+// ParsePain001 parses customer credit transfer initiation document
+func ParsePain001(data []byte) (*Pain001, error) {
+	result := new(Pain001)
+	if err := xml.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	if result.XMLName.Space != "" && !strings.HasPrefix(result.XMLName.Space, isoNamespacePrefix+"pain.001.") {
+		return nil, fmt.Errorf("unsupported namespace %s", result.XMLName.Space)
+	}
+	if strings.TrimSpace(result.Initiation.MessageID) == "" {
+		return nil, fmt.Errorf("missing message identification")
+	}
+	if len(result.Initiation.Payments) == 0 {
+		return nil, fmt.Errorf("missing payment information")
+	}
+	return result, nil
+}
+
+// MessageName returns name of message definition of document
+func (entity *Pain001) MessageName() string {
+	if entity == nil || !strings.HasPrefix(entity.XMLName.Space, isoNamespacePrefix) {
+		return "pain.001.001.03"
+	}
+	return strings.TrimPrefix(entity.XMLName.Space, isoNamespacePrefix)
+}
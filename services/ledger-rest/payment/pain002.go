@@ -0,0 +1,121 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payment
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// Pain002Namespace is namespace of generated payment status report
+const Pain002Namespace = isoNamespacePrefix + "pain.002.001.03"
+
+const (
+	// StatusSettled represents accepted and settled payment
+	StatusSettled = "ACSC"
+	// StatusPending represents payment with outcome not known yet
+	StatusPending = "PDNG"
+	// StatusRejected represents rejected payment
+	StatusRejected = "RJCT"
+	// StatusPartial represents group with payments in different status
+	StatusPartial = "PART"
+)
+
+const (
+	// ReasonInvalidDebtorAccount represents debtor account that is not local
+	// account of tenant
+	ReasonInvalidDebtorAccount = "AC02"
+	// ReasonInvalidCreditorAccount represents creditor account that is not
+	// known
+	ReasonInvalidCreditorAccount = "AC03"
+	// ReasonInvalidCurrency represents missing or invalid currency
+	ReasonInvalidCurrency = "AM03"
+	// ReasonDuplicate represents payment information that was already
+	// submitted
+	ReasonDuplicate = "AM05"
+	// ReasonInvalidAmount represents amount that is not positive decimal
+	ReasonInvalidAmount = "AM12"
+	// ReasonInvalidDate represents invalid requested execution date
+	ReasonInvalidDate = "DT01"
+	// ReasonNotSpecified represents rejection by ledger without specific
+	// reason
+	ReasonNotSpecified = "MS03"
+)
+
+// Pain002 represents ISO 20022 customer payment status report
+type Pain002 struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Report    report   `xml:"CstmrPmtStsRpt"`
+}
+
+type report struct {
+	MessageID string          `xml:"GrpHdr>MsgId"`
+	CreatedAt string          `xml:"GrpHdr>CreDtTm"`
+	Group     groupStatus     `xml:"OrgnlGrpInfAndSts"`
+	Payments  []paymentStatus `xml:"OrgnlPmtInfAndSts"`
+}
+
+type groupStatus struct {
+	MessageID            string `xml:"OrgnlMsgId"`
+	MessageName          string `xml:"OrgnlMsgNmId"`
+	NumberOfTransactions string `xml:"OrgnlNbOfTxs"`
+	Status               string `xml:"GrpSts"`
+}
+
+type statusReason struct {
+	Code        string `xml:"Rsn>Cd"`
+	Information string `xml:"AddtlInf,omitempty"`
+}
+
+type paymentStatus struct {
+	PaymentID string              `xml:"OrgnlPmtInfId"`
+	Status    string              `xml:"PmtInfSts"`
+	Reason    *statusReason       `xml:"StsRsnInf,omitempty"`
+	Transfers []transactionStatus `xml:"TxInfAndSts"`
+}
+
+type transactionStatus struct {
+	InstructionID string        `xml:"OrgnlInstrId,omitempty"`
+	EndToEndID    string        `xml:"OrgnlEndToEndId"`
+	Status        string        `xml:"TxSts"`
+	Reason        *statusReason `xml:"StsRsnInf,omitempty"`
+}
+
+// aggregateStatus returns common status of given statuses or partial status
+// if they differ
+func aggregateStatus(statuses []string) string {
+	if len(statuses) == 0 {
+		return StatusRejected
+	}
+	for _, status := range statuses[1:] {
+		if status != statuses[0] {
+			return StatusPartial
+		}
+	}
+	return statuses[0]
+}
+
+// Serialize returns report as XML document
+func (entity *Pain002) Serialize() ([]byte, error) {
+	if entity == nil {
+		return nil, fmt.Errorf("cannot serialize nil pointer")
+	}
+	data, err := xml.MarshalIndent(entity, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
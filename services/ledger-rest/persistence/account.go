@@ -0,0 +1,154 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// AccountIndexMark is file marking that account index of tenant covers all
+// committed transactions involving it
+const AccountIndexMark = "account_index"
+
+// loadTenantTransactions loads all transactions of journal of tenant and all
+// transactions of other tenants referencing it
+func loadTenantTransactions(storage localfs.Storage, tenant string) ([]*model.Transaction, error) {
+	transactions := make([]*model.Transaction, 0)
+
+	ids, err := LoadTransactionsIDs(storage, tenant)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		transaction, err := LoadTransaction(storage, tenant, id)
+		if err != nil {
+			return nil, err
+		}
+		if transaction != nil {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	inbound, err := LoadInboundTransactionsIDs(storage, tenant)
+	if err != nil {
+		return nil, err
+	}
+	for _, reference := range inbound {
+		parts := strings.SplitN(reference, "/", 2)
+		transaction, err := LoadInboundTransaction(storage, tenant, parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if transaction != nil {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	return transactions, nil
+}
+
+// loadIndexedTransactions loads transactions referenced by account index of
+// given account
+func loadIndexedTransactions(storage localfs.Storage, account model.Account) ([]*model.Transaction, error) {
+	transactions := make([]*model.Transaction, 0)
+
+	path := "t_" + account.Tenant + "/account/" + account.Name
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return transactions, nil
+	}
+	origins, err := storage.ListDirectory(path, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, origin := range origins {
+		ids, err := storage.ListDirectory(path+"/"+origin, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			var transaction *model.Transaction
+			if origin == account.Tenant {
+				transaction, err = LoadTransaction(storage, origin, id)
+			} else {
+				transaction, err = LoadInboundTransaction(storage, account.Tenant, origin, id)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if transaction != nil {
+				transactions = append(transactions, transaction)
+			}
+		}
+	}
+
+	return transactions, nil
+}
+
+// LoadAccountEntries loads committed transfers of given account from journal
+// of its tenant and from transactions of other tenants referencing it, entries
+// are ordered by value date, only transactions in account index are loaded
+// once tenant is indexed
+func LoadAccountEntries(storage localfs.Storage, account model.Account) ([]model.AccountEntry, error) {
+	var transactions []*model.Transaction
+
+	indexed, err := storage.Exists("t_" + account.Tenant + "/" + AccountIndexMark)
+	if err != nil {
+		return nil, err
+	}
+	if indexed {
+		transactions, err = loadIndexedTransactions(storage, account)
+	} else {
+		transactions, err = loadTenantTransactions(storage, account.Tenant)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.AccountEntry, 0)
+	for _, transaction := range transactions {
+		if transaction.Status != StatusCommitted {
+			continue
+		}
+		for _, transfer := range transaction.Transfers {
+			if transfer.Credit == account {
+				result = append(result, model.AccountEntry{
+					IDTransaction: transaction.IDTransaction,
+					Origin:        transaction.Origin,
+					Transfer:      transfer,
+					Credit:        true,
+				})
+			}
+			if transfer.Debit == account {
+				result = append(result, model.AccountEntry{
+					IDTransaction: transaction.IDTransaction,
+					Origin:        transaction.Origin,
+					Transfer:      transfer,
+					Credit:        false,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Transfer.ValueDate.Before(result[j].Transfer.ValueDate)
+	})
+	return result, nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// ErrMalformedEvent is returned when event cannot be deserialized
+var ErrMalformedEvent = fmt.Errorf("malformed event")
+
+// LoadLastEventSequence loads sequence number of last event of given tenant
+func LoadLastEventSequence(storage localfs.Storage, tenant string) (uint64, error) {
+	path := "t_" + tenant + "/event"
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return 0, nil
+	}
+	events, err := storage.ListDirectory(path, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseUint(events[0], 10, 64)
+}
+
+// LoadEvent loads event of given tenant with given sequence number
+func LoadEvent(storage localfs.Storage, tenant string, sequence uint64) (*model.Event, error) {
+	path := "t_" + tenant + "/event/" + fmt.Sprintf("%020d", sequence)
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return nil, nil
+	}
+	data, err := storage.ReadFileFully(path)
+	if err != nil {
+		return nil, err
+	}
+	result := new(model.Event)
+	result.Sequence = sequence
+	if err = result.Deserialize(data); err != nil {
+		return nil, ErrMalformedEvent
+	}
+	return result, nil
+}
+
+// LoadTransactionCreation loads time of first event of transaction of given
+// tenant from creation index
+func LoadTransactionCreation(storage localfs.Storage, tenant string, id string) (time.Time, bool) {
+	data, err := storage.ReadFileFully("t_" + tenant + "/creation/" + id)
+	if err != nil {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339Nano, string(data))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return at, true
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// LoadPendingTransactionsIDs loads ids of transactions of tenant that are
+// neither committed nor rollbacked
+func LoadPendingTransactionsIDs(storage localfs.Storage, tenant string) ([]string, error) {
+	ids, err := LoadTransactionsIDs(storage, tenant)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0)
+	for _, id := range ids {
+		data, err := storage.ReadFileFully("t_" + tenant + "/transaction/" + id)
+		if err != nil {
+			return nil, err
+		}
+		state := strings.SplitN(string(data), "\n", 2)[0]
+		if state == StatusCommitted || state == StatusRollbacked {
+			continue
+		}
+		result = append(result, id)
+	}
+	return result, nil
+}
+
+// CreateTenantArchive writes gzip compressed tarball of tenant's data into
+// archive directory alongside sha256sum compatible checksum file
+func CreateTenantArchive(rootStorage string, archiveDirectory string, tenant string, at time.Time) (*model.Archive, error) {
+	source := filepath.Join(rootStorage, "t_"+tenant)
+	if _, err := os.Stat(source); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(archiveDirectory, 0700); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-%s.tar.gz", tenant, at.UTC().Format("20060102T150405Z"))
+	path := filepath.Join(archiveDirectory, name)
+
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path + ".tmp")
+
+	digest := sha256.New()
+	compressed := gzip.NewWriter(io.MultiWriter(file, digest))
+	tarball := tar.NewWriter(compressed)
+
+	err = filepath.Walk(source, func(current string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(rootStorage, current)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+		if err := tarball.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		data, err := os.Open(current)
+		if err != nil {
+			return err
+		}
+		defer data.Close()
+		_, err = io.Copy(tarball, data)
+		return err
+	})
+	if err == nil {
+		err = tarball.Close()
+	}
+	if err == nil {
+		err = compressed.Close()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := hex.EncodeToString(digest.Sum(nil))
+	if err := ioutil.WriteFile(path+".sha256", []byte(checksum+"  "+name+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Archive{
+		Path:     path,
+		Checksum: checksum,
+		Size:     info.Size(),
+	}, nil
+}
+
+// VerifyTenantArchive returns error if archive is missing or does not match
+// its checksum
+func VerifyTenantArchive(path string) error {
+	expected, err := ioutil.ReadFile(path + ".sha256")
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(digest.Sum(nil))
+	fields := strings.Fields(string(expected))
+	if len(fields) == 0 || fields[0] != actual {
+		return fmt.Errorf("checksum mismatch of archive %s", path)
+	}
+	return nil
+}
+
+// CreatePurgeSchedule persist intent to purge tenant's data at given time
+// once archive is verified
+func CreatePurgeSchedule(storage localfs.Storage, tenant string, at time.Time, archivePath string) error {
+	return storage.WriteFile("offboarding/"+tenant, []byte(at.UTC().Format(time.RFC3339Nano)+"\n"+archivePath))
+}
+
+// LoadPurgeSchedule loads time and archive of scheduled purge of tenant's
+// data
+func LoadPurgeSchedule(storage localfs.Storage, tenant string) (time.Time, string, error) {
+	data, err := storage.ReadFileFully("offboarding/" + tenant)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	lines := strings.SplitN(string(data), "\n", 2)
+	if len(lines) != 2 {
+		return time.Time{}, "", fmt.Errorf("malformed purge schedule of tenant %s", tenant)
+	}
+	at, err := time.Parse(time.RFC3339Nano, lines[0])
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return at, lines[1], nil
+}
+
+// LoadPurgeSchedulesTenants loads tenants with scheduled purge
+func LoadPurgeSchedulesTenants(storage localfs.Storage) ([]string, error) {
+	ok, err := storage.Exists("offboarding")
+	if err != nil || !ok {
+		return make([]string, 0), nil
+	}
+	return storage.ListDirectory("offboarding", true)
+}
+
+// DeletePurgeSchedule cancels scheduled purge of tenant's data
+func DeletePurgeSchedule(storage localfs.Storage, tenant string) error {
+	ok, err := storage.Exists("offboarding/" + tenant)
+	if err != nil || !ok {
+		return err
+	}
+	return storage.DeleteFile("offboarding/" + tenant)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"encoding/json"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+func reconciliationPath(account model.Account) string {
+	return "t_" + account.Tenant + "/reconciliation/" + account.Name
+}
+
+// LoadReconciliationsIDs loads ids of reconciliations of given account
+func LoadReconciliationsIDs(storage localfs.Storage, account model.Account) ([]string, error) {
+	path := reconciliationPath(account)
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return make([]string, 0), nil
+	}
+	return storage.ListDirectory(path, true)
+}
+
+// LoadReconciliation loads reconciliation of given account
+func LoadReconciliation(storage localfs.Storage, account model.Account, id string) (*model.Reconciliation, error) {
+	path := reconciliationPath(account) + "/" + id
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return nil, nil
+	}
+	data, err := storage.ReadFileFully(path)
+	if err != nil {
+		return nil, err
+	}
+	result := new(model.Reconciliation)
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CreateReconciliation persists new reconciliation of its account
+func CreateReconciliation(storage localfs.Storage, entity *model.Reconciliation) error {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	return storage.WriteFileExclusive(reconciliationPath(entity.Account)+"/"+entity.ID, data)
+}
+
+// UpdateReconciliation persists resolutions of breaks of reconciliation
+func UpdateReconciliation(storage localfs.Storage, entity *model.Reconciliation) error {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(reconciliationPath(entity.Account)+"/"+entity.ID, data)
+}
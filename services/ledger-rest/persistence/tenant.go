@@ -0,0 +1,101 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// LoadTransactionsCount returns number of transactions in tenant's journal
+func LoadTransactionsCount(storage localfs.Storage, tenant string) (int, error) {
+	path := "t_" + tenant + "/transaction"
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return 0, nil
+	}
+	return storage.CountFiles(path)
+}
+
+// LoadLastFinalizerScan returns time of last successful stale transactions
+// scan of tenant's unit or nil if there was none yet
+func LoadLastFinalizerScan(storage localfs.Storage, tenant string) (*time.Time, error) {
+	path := "t_" + tenant + "/finalizer"
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return nil, nil
+	}
+	data, err := storage.ReadFileFully(path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := time.Parse(time.RFC3339Nano, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LoadTenantStorageUsage returns number of bytes occupied by tenant's data
+func LoadTenantStorageUsage(rootStorage string, tenant string) (uint64, error) {
+	var result uint64
+	err := filepath.Walk(filepath.Join(rootStorage, "t_"+tenant), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			result += uint64(info.Size())
+		}
+		return nil
+	})
+	return result, err
+}
+
+// LoadTenantConfig loads runtime configuration of tenant, empty configuration
+// if there is none
+func LoadTenantConfig(storage localfs.Storage, tenant string) (*model.TenantConfig, error) {
+	path := "t_" + tenant + "/config"
+	result := new(model.TenantConfig)
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return result, nil
+	}
+	data, err := storage.ReadFileFully(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateTenantConfig persists runtime configuration of tenant
+func UpdateTenantConfig(storage localfs.Storage, tenant string, entity *model.TenantConfig) error {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile("t_"+tenant+"/config", data)
+}
@@ -15,6 +15,8 @@
 package persistence
 
 import (
+	"time"
+
 	"github.com/jancajthaml-openbank/ledger-rest/model"
 
 	localfs "github.com/jancajthaml-openbank/local-fs"
@@ -47,6 +49,55 @@ func LoadTransaction(storage localfs.Storage, tenant string, id string) (*model.
 	}
 	result := new(model.Transaction)
 	result.IDTransaction = id
-	result.Deserialize(data)
+	if err = result.Deserialize(data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadInboundTransactionsIDs loads ids of transactions of other tenants that
+// involve given tenant in form of {origin}/{id}
+func LoadInboundTransactionsIDs(storage localfs.Storage, tenant string) ([]string, error) {
+	path := "t_" + tenant + "/inbound"
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return make([]string, 0), nil
+	}
+	origins, err := storage.ListDirectory(path, true)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0)
+	for _, origin := range origins {
+		transactions, err := storage.ListDirectory(path+"/"+origin, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, transaction := range transactions {
+			result = append(result, origin+"/"+transaction)
+		}
+	}
+	return result, nil
+}
+
+// LoadInboundTransaction loads transaction of origin tenant referenced by
+// given tenant, only transfers involving given tenant are loaded
+func LoadInboundTransaction(storage localfs.Storage, tenant string, origin string, id string) (*model.Transaction, error) {
+	path := "t_" + tenant + "/inbound/" + origin + "/" + id
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return nil, nil
+	}
+	result, err := LoadTransaction(storage, origin, id)
+	if err != nil || result == nil {
+		return result, err
+	}
+	result.Origin = origin
+	result.Transfers = result.TransfersOf(tenant)
 	return result, nil
 }
+
+// LoadTransactionModification returns time of last update of transaction
+func LoadTransactionModification(storage localfs.Storage, tenant string, id string) (time.Time, error) {
+	return storage.LastModification("t_" + tenant + "/transaction/" + id)
+}
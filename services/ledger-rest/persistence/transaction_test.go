@@ -0,0 +1,85 @@
+package persistence
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadInboundTransactions(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_inbound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("tenant without references has no inbound transactions")
+	{
+		ids, err := LoadInboundTransactionsIDs(storage, "x")
+		require.Nil(t, err)
+		assert.Equal(t, []string{}, ids)
+	}
+
+	storage.WriteFile("t_y/transaction/a", []byte("committed\n1 x A y B 2020-01-01T00:00:00Z 1 EUR\n"))
+	storage.WriteFile("t_z/transaction/b", []byte("committed\n2 z C x D 2020-01-02T00:00:00Z 2 EUR\n"))
+	storage.WriteFile("t_x/inbound/y/a", []byte("committed"))
+	storage.WriteFile("t_x/inbound/z/b", []byte("committed"))
+	storage.WriteFile("t_x/inbound/z/missing", []byte("committed"))
+
+	t.Log("lists references of all origin tenants")
+	{
+		ids, err := LoadInboundTransactionsIDs(storage, "x")
+		require.Nil(t, err)
+		sort.Strings(ids)
+		assert.Equal(t, []string{"y/a", "z/b", "z/missing"}, ids)
+	}
+
+	t.Log("loads referenced transaction from journal of origin tenant")
+	{
+		transaction, err := LoadInboundTransaction(storage, "x", "z", "b")
+		require.Nil(t, err)
+		require.NotNil(t, transaction)
+		assert.Equal(t, "b", transaction.IDTransaction)
+		assert.Equal(t, "z", transaction.Origin)
+		assert.Equal(t, "committed", transaction.Status)
+		require.Equal(t, 1, len(transaction.Transfers))
+		assert.Equal(t, "x", transaction.Transfers[0].Debit.Tenant)
+	}
+
+	t.Log("loads only transfers of referencing tenant")
+	{
+		storage.WriteFile("t_y/transaction/c", []byte("committed\n1 x A y B 2020-01-01T00:00:00Z 1 EUR\n2 y C w D 2020-01-01T00:00:00Z 2 EUR\n3 w E x F 2020-01-01T00:00:00Z 3 EUR\n"))
+		storage.WriteFile("t_x/inbound/y/c", []byte("committed"))
+
+		transaction, err := LoadInboundTransaction(storage, "x", "y", "c")
+		require.Nil(t, err)
+		require.NotNil(t, transaction)
+		require.Equal(t, 2, len(transaction.Transfers))
+		assert.Equal(t, "1", transaction.Transfers[0].IDTransfer)
+		assert.Equal(t, "3", transaction.Transfers[1].IDTransfer)
+	}
+
+	t.Log("does not load transaction without reference")
+	{
+		transaction, err := LoadInboundTransaction(storage, "z", "y", "a")
+		assert.Nil(t, err)
+		assert.Nil(t, transaction)
+	}
+
+	t.Log("does not load reference without transaction in journal of origin")
+	{
+		transaction, err := LoadInboundTransaction(storage, "x", "z", "missing")
+		assert.Nil(t, err)
+		assert.Nil(t, transaction)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// LoadWebhooksIDs loads webhook ids of given tenant
+func LoadWebhooksIDs(storage localfs.Storage, tenant string) ([]string, error) {
+	path := "t_" + tenant + "/webhook"
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return make([]string, 0), nil
+	}
+	return storage.ListDirectory(path, true)
+}
+
+// LoadWebhook loads webhook of given tenant
+func LoadWebhook(storage localfs.Storage, tenant string, id string) (*model.Webhook, error) {
+	path := "t_" + tenant + "/webhook/" + id
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return nil, nil
+	}
+	data, err := storage.ReadFileFully(path)
+	if err != nil {
+		return nil, err
+	}
+	result := new(model.Webhook)
+	result.ID = id
+	if err = result.Deserialize(data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CreateWebhook persists webhook of given tenant with cursor pointing at last
+// event
+func CreateWebhook(storage localfs.Storage, tenant string, entity *model.Webhook) error {
+	sequence, err := LoadLastEventSequence(storage, tenant)
+	if err != nil {
+		return err
+	}
+	err = storage.WriteFileExclusive("t_"+tenant+"/webhook/"+entity.ID, entity.Serialize())
+	if err != nil {
+		return err
+	}
+	return UpdateWebhookCursor(storage, tenant, entity.ID, sequence)
+}
+
+// DeleteWebhook removes webhook of given tenant
+func DeleteWebhook(storage localfs.Storage, tenant string, id string) (bool, error) {
+	path := "t_" + tenant + "/webhook/" + id
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err = storage.DeleteFile(path); err != nil {
+		return false, err
+	}
+	storage.DeleteFile("t_" + tenant + "/webhook_cursor/" + id)
+	return true, nil
+}
+
+// LoadWebhookCursor loads sequence of last event handled by webhook
+func LoadWebhookCursor(storage localfs.Storage, tenant string, id string) (uint64, error) {
+	data, err := storage.ReadFileFully("t_" + tenant + "/webhook_cursor/" + id)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// UpdateWebhookCursor persists sequence of last event handled by webhook
+func UpdateWebhookCursor(storage localfs.Storage, tenant string, id string, sequence uint64) error {
+	return storage.WriteFile("t_"+tenant+"/webhook_cursor/"+id, []byte(strconv.FormatUint(sequence, 10)))
+}
+
+// CreateWebhookDeadLetter persists payload of delivery that was given up
+func CreateWebhookDeadLetter(storage localfs.Storage, tenant string, id string, sequence uint64, payload []byte, reason string) error {
+	path := "t_" + tenant + "/webhook_deadletter/" + id + "/" + fmt.Sprintf("%020d", sequence)
+	return storage.WriteFile(path, append([]byte(reason+"\n"), payload...))
+}
+
+// LoadWebhookDeadLettersIDs loads sequences of events webhook gave up on
+func LoadWebhookDeadLettersIDs(storage localfs.Storage, tenant string, id string) ([]string, error) {
+	path := "t_" + tenant + "/webhook_deadletter/" + id
+	ok, err := storage.Exists(path)
+	if err != nil || !ok {
+		return make([]string, 0), nil
+	}
+	return storage.ListDirectory(path, true)
+}
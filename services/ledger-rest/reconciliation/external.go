@@ -0,0 +1,269 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconciliation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	money "gopkg.in/inf.v0"
+)
+
+const (
+	// FormatCSV represents statement as comma separated values with header
+	// naming reference, value_date, amount and currency columns
+	FormatCSV = "csv"
+	// FormatCamt053 represents ISO 20022 bank to customer statement
+	FormatCamt053 = "camt053"
+)
+
+// MaxStatementSize is maximum size of accepted external statement in bytes
+const MaxStatementSize = 4 << 20
+
+// Entry represents entry of external statement
+type Entry struct {
+	References []string
+	ValueDate  time.Time
+	Amount     *money.Dec
+	Currency   string
+	Credit     bool
+}
+
+// DetectFormat returns format of statement judging by its content
+func DetectFormat(data []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return FormatCamt053
+	}
+	return FormatCSV
+}
+
+// ParseStatement parses entries of external statement of given format
+func ParseStatement(format string, data []byte) ([]Entry, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(bytes.NewReader(data))
+	case FormatCamt053:
+		return ParseCamt053(data)
+	default:
+		return nil, fmt.Errorf("unsupported statement format %s", format)
+	}
+}
+
+func parseValueDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if result, err := time.Parse("2006-01-02", value); err == nil {
+		return result, nil
+	}
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid value date %q", value)
+	}
+	return result.UTC(), nil
+}
+
+func parseAmount(value string) (*money.Dec, error) {
+	result, ok := new(money.Dec).SetString(strings.TrimSpace(value))
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", value)
+	}
+	return result, nil
+}
+
+func parseCurrency(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if len(value) != 3 {
+		return "", fmt.Errorf("invalid currency %q", value)
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < 'A' || value[i] > 'Z' {
+			return "", fmt.Errorf("invalid currency %q", value)
+		}
+	}
+	return value, nil
+}
+
+// ParseCSV parses statement with header naming reference, value_date, amount
+// and currency columns, negative amounts represent debits
+func ParseCSV(input io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(input)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing header")
+	}
+	columns := make(map[string]int)
+	for idx, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+	for _, name := range []string{"value_date", "amount", "currency"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	result := make([]Entry, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		valueDate, err := parseValueDate(record[columns["value_date"]])
+		if err != nil {
+			return nil, fmt.Errorf("record %d, %s", len(result)+1, err.Error())
+		}
+		amount, err := parseAmount(record[columns["amount"]])
+		if err != nil {
+			return nil, fmt.Errorf("record %d, %s", len(result)+1, err.Error())
+		}
+		currency, err := parseCurrency(record[columns["currency"]])
+		if err != nil {
+			return nil, fmt.Errorf("record %d, %s", len(result)+1, err.Error())
+		}
+		entry := Entry{
+			References: make([]string, 0, 1),
+			ValueDate:  valueDate,
+			Amount:     new(money.Dec).Abs(amount),
+			Currency:   currency,
+			Credit:     amount.Sign() >= 0,
+		}
+		if idx, ok := columns["reference"]; ok && strings.TrimSpace(record[idx]) != "" {
+			entry.References = append(entry.References, strings.TrimSpace(record[idx]))
+		}
+		result = append(result, entry)
+	}
+}
+
+type camt053Document struct {
+	XMLName    xml.Name           `xml:"Document"`
+	Statements []camt053Statement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camt053Statement struct {
+	Entries []camt053Entry `xml:"Ntry"`
+}
+
+type camt053Date struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (value camt053Date) String() string {
+	if value.Date != "" {
+		return value.Date
+	}
+	return value.DateTime
+}
+
+type camt053Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camt053Details struct {
+	EndToEndID        string `xml:"Refs>EndToEndId"`
+	ServicerReference string `xml:"Refs>AcctSvcrRef"`
+}
+
+type camt053Entry struct {
+	Reference         string           `xml:"NtryRef"`
+	ServicerReference string           `xml:"AcctSvcrRef"`
+	Amount            camt053Amount    `xml:"Amt"`
+	Indicator         string           `xml:"CdtDbtInd"`
+	Reversal          bool             `xml:"RvslInd"`
+	BookingDate       camt053Date      `xml:"BookgDt"`
+	ValueDate         camt053Date      `xml:"ValDt"`
+	Details           []camt053Details `xml:"NtryDtls>TxDtls"`
+}
+
+// references returns references of entry ordered from most specific one
+func (entity camt053Entry) references() []string {
+	candidates := make([]string, 0)
+	for _, detail := range entity.Details {
+		candidates = append(candidates, detail.EndToEndID, detail.ServicerReference)
+	}
+	candidates = append(candidates, entity.Reference, entity.ServicerReference)
+
+	result := make([]string, 0, len(candidates))
+	for _, reference := range candidates {
+		reference = strings.TrimSpace(reference)
+		if reference != "" && reference != "NOTPROVIDED" {
+			result = append(result, reference)
+		}
+	}
+	return result
+}
+
+// ParseCamt053 parses entries of all statements of camt.053 document,
+// reversals are treated as entries of opposite direction
+func ParseCamt053(data []byte) ([]Entry, error) {
+	document := new(camt053Document)
+	if err := xml.Unmarshal(data, document); err != nil {
+		return nil, err
+	}
+	if document.XMLName.Space != "" && !strings.Contains(document.XMLName.Space, ":camt.053.") {
+		return nil, fmt.Errorf("unsupported namespace %s", document.XMLName.Space)
+	}
+	if len(document.Statements) == 0 {
+		return nil, fmt.Errorf("missing statement")
+	}
+
+	result := make([]Entry, 0)
+	for _, statement := range document.Statements {
+		for _, item := range statement.Entries {
+			date := item.ValueDate.String()
+			if date == "" {
+				date = item.BookingDate.String()
+			}
+			valueDate, err := parseValueDate(date)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d, %s", len(result)+1, err.Error())
+			}
+			amount, err := parseAmount(item.Amount.Value)
+			if err != nil || amount.Sign() < 0 {
+				return nil, fmt.Errorf("entry %d, invalid amount %q", len(result)+1, item.Amount.Value)
+			}
+			currency, err := parseCurrency(item.Amount.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d, %s", len(result)+1, err.Error())
+			}
+			var credit bool
+			switch item.Indicator {
+			case "CRDT":
+				credit = true
+			case "DBIT":
+				credit = false
+			default:
+				return nil, fmt.Errorf("entry %d, invalid credit debit indicator %q", len(result)+1, item.Indicator)
+			}
+			result = append(result, Entry{
+				References: item.references(),
+				ValueDate:  valueDate,
+				Amount:     amount,
+				Currency:   currency,
+				Credit:     credit != item.Reversal,
+			})
+		}
+	}
+	return result, nil
+}
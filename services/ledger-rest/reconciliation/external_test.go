@@ -0,0 +1,99 @@
+package reconciliation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Log("parses signed amounts")
+	{
+		entries, err := ParseCSV(strings.NewReader("Reference,Value_Date,Amount,Currency\nA,2020-01-02,10.50,EUR\n,2020-01-03T10:00:00+01:00,-3,CZK\n"))
+		require.Nil(t, err)
+		require.Equal(t, 2, len(entries))
+
+		assert.Equal(t, []string{"A"}, entries[0].References)
+		assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), entries[0].ValueDate)
+		assert.Equal(t, "10.50", entries[0].Amount.String())
+		assert.Equal(t, "EUR", entries[0].Currency)
+		assert.True(t, entries[0].Credit)
+
+		assert.Equal(t, 0, len(entries[1].References))
+		assert.Equal(t, time.Date(2020, 1, 3, 9, 0, 0, 0, time.UTC), entries[1].ValueDate)
+		assert.Equal(t, "3", entries[1].Amount.String())
+		assert.False(t, entries[1].Credit)
+	}
+
+	t.Log("rejects malformed statements")
+	{
+		_, err := ParseCSV(strings.NewReader(""))
+		assert.NotNil(t, err)
+		_, err = ParseCSV(strings.NewReader("value_date,amount\n2020-01-01,1\n"))
+		assert.NotNil(t, err)
+		_, err = ParseCSV(strings.NewReader("value_date,amount,currency\n2020-13-01,1,EUR\n"))
+		assert.NotNil(t, err)
+		_, err = ParseCSV(strings.NewReader("value_date,amount,currency\n2020-01-01,x,EUR\n"))
+		assert.NotNil(t, err)
+		_, err = ParseCSV(strings.NewReader("value_date,amount,currency\n2020-01-01,1,eur\n"))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestParseCamt053(t *testing.T) {
+	t.Log("parses entries of all statements")
+	{
+		entries, err := ParseCamt053([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Ntry>
+        <NtryRef>T1</NtryRef>
+        <Amt Ccy="EUR">10.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <ValDt><Dt>2020-01-02</Dt></ValDt>
+        <AcctSvcrRef>TX1</AcctSvcrRef>
+        <NtryDtls><TxDtls><Refs><EndToEndId>E2E</EndToEndId></Refs></TxDtls></NtryDtls>
+      </Ntry>
+    </Stmt>
+    <Stmt>
+      <Ntry>
+        <Amt Ccy="CZK">5</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <RvslInd>true</RvslInd>
+        <BookgDt><DtTm>2020-01-03T08:00:00Z</DtTm></BookgDt>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`))
+		require.Nil(t, err)
+		require.Equal(t, 2, len(entries))
+
+		assert.Equal(t, []string{"E2E", "T1", "TX1"}, entries[0].References)
+		assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), entries[0].ValueDate)
+		assert.Equal(t, "10.00", entries[0].Amount.String())
+		assert.True(t, entries[0].Credit)
+
+		assert.Equal(t, 0, len(entries[1].References))
+		assert.Equal(t, time.Date(2020, 1, 3, 8, 0, 0, 0, time.UTC), entries[1].ValueDate)
+		assert.False(t, entries[1].Credit)
+	}
+
+	t.Log("rejects malformed statements")
+	{
+		_, err := ParseCamt053([]byte(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"><BkToCstmrStmt><Stmt/></BkToCstmrStmt></Document>`))
+		assert.NotNil(t, err)
+		_, err = ParseCamt053([]byte(`<Document><BkToCstmrStmt/></Document>`))
+		assert.NotNil(t, err)
+		_, err = ParseCamt053([]byte(`<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="EUR">1</Amt><CdtDbtInd>X</CdtDbtInd><ValDt><Dt>2020-01-01</Dt></ValDt></Ntry></Stmt></BkToCstmrStmt></Document>`))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, FormatCamt053, DetectFormat([]byte("\n <?xml version=\"1.0\"?><Document/>")))
+	assert.Equal(t, FormatCSV, DetectFormat([]byte("value_date,amount,currency\n")))
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconciliation
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	money "gopkg.in/inf.v0"
+)
+
+const day = 24 * time.Hour
+
+// Tolerance represents how much paired entries may differ
+type Tolerance struct {
+	Amount *money.Dec
+	Days   int
+}
+
+// NewTolerance returns tolerance of given non negative amount and value date
+// difference in whole days
+func NewTolerance(amount string, days int) (*Tolerance, error) {
+	value, ok := new(money.Dec).SetString(amount)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount tolerance %q", amount)
+	}
+	if days < 0 {
+		return nil, fmt.Errorf("invalid date tolerance %d", days)
+	}
+	return &Tolerance{
+		Amount: value,
+		Days:   days,
+	}, nil
+}
+
+func truncateDay(value time.Time) time.Time {
+	value = value.UTC()
+	return time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(a time.Time, b time.Time) int {
+	result := int(truncateDay(a).Sub(truncateDay(b)) / day)
+	if result < 0 {
+		return -result
+	}
+	return result
+}
+
+type internalEntry struct {
+	model.AccountEntry
+	amount  *money.Dec
+	matched bool
+}
+
+func (entry internalEntry) item(id string) model.ReconciliationItem {
+	return model.ReconciliationItem{
+		ID:          id,
+		Reference:   entry.Transfer.IDTransfer,
+		Transaction: entry.IDTransaction,
+		Origin:      entry.Origin,
+		ValueDate:   entry.Transfer.ValueDate,
+		Amount:      entry.Transfer.Amount,
+		Currency:    entry.Transfer.Currency,
+		Credit:      entry.Credit,
+	}
+}
+
+func (entry Entry) item(id string) model.ReconciliationItem {
+	result := model.ReconciliationItem{
+		ID:        id,
+		ValueDate: entry.ValueDate,
+		Amount:    entry.Amount.String(),
+		Currency:  entry.Currency,
+		Credit:    entry.Credit,
+	}
+	if len(entry.References) != 0 {
+		result.Reference = entry.References[0]
+	}
+	return result
+}
+
+func (entry Entry) refersTo(candidate internalEntry) bool {
+	for _, reference := range entry.References {
+		if reference == candidate.Transfer.IDTransfer || reference == candidate.IDTransaction {
+			return true
+		}
+	}
+	return false
+}
+
+// difference returns absolute difference of amounts
+func difference(a *money.Dec, b *money.Dec) *money.Dec {
+	return new(money.Dec).Abs(new(money.Dec).Sub(a, b))
+}
+
+// pair returns index of best unmatched internal entry within tolerance, when
+// byReference is set only entries referred by external entry are considered
+func pair(entry Entry, candidates []internalEntry, tolerance Tolerance, byReference bool) int {
+	best := -1
+	var bestAmount *money.Dec
+	var bestDays int
+	for idx, candidate := range candidates {
+		if candidate.matched || candidate.Credit != entry.Credit || candidate.Transfer.Currency != entry.Currency {
+			continue
+		}
+		if byReference && !entry.refersTo(candidate) {
+			continue
+		}
+		amount := difference(entry.Amount, candidate.amount)
+		if amount.Cmp(tolerance.Amount) > 0 {
+			continue
+		}
+		days := daysBetween(entry.ValueDate, candidate.Transfer.ValueDate)
+		if days > tolerance.Days {
+			continue
+		}
+		if best == -1 || amount.Cmp(bestAmount) < 0 || (amount.Cmp(bestAmount) == 0 && days < bestDays) {
+			best, bestAmount, bestDays = idx, amount, days
+		}
+	}
+	return best
+}
+
+// Reconcile pairs committed entries of account with entries of external
+// statement for period given by first and last value date, both inclusive,
+// zero period spans entries of statement
+func Reconcile(id string, account model.Account, internal []model.AccountEntry, external []Entry, first time.Time, last time.Time, tolerance Tolerance, createdAt time.Time) (*model.Reconciliation, error) {
+	if first.IsZero() || last.IsZero() {
+		if len(external) == 0 {
+			return nil, fmt.Errorf("period of empty statement cannot be determined")
+		}
+		for _, entry := range external {
+			if first.IsZero() || entry.ValueDate.Before(first) {
+				first = entry.ValueDate
+			}
+			if last.IsZero() || entry.ValueDate.After(last) {
+				last = entry.ValueDate
+			}
+		}
+	}
+	first, last = truncateDay(first), truncateDay(last)
+	if last.Before(first) {
+		return nil, fmt.Errorf("invalid period")
+	}
+
+	margin := time.Duration(tolerance.Days) * day
+	candidates := make([]internalEntry, 0)
+	for _, entry := range internal {
+		valueDate := truncateDay(entry.Transfer.ValueDate)
+		if valueDate.Before(first.Add(-margin)) || valueDate.After(last.Add(margin)) {
+			continue
+		}
+		amount, ok := new(money.Dec).SetString(entry.Transfer.Amount)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, internalEntry{
+			AccountEntry: entry,
+			amount:       amount,
+		})
+	}
+
+	pairs := make([]int, len(external))
+	for idx := range pairs {
+		pairs[idx] = -1
+	}
+	for _, byReference := range []bool{true, false} {
+		for idx, entry := range external {
+			if pairs[idx] != -1 {
+				continue
+			}
+			if candidate := pair(entry, candidates, tolerance, byReference); candidate != -1 {
+				candidates[candidate].matched = true
+				pairs[idx] = candidate
+			}
+		}
+	}
+
+	result := &model.Reconciliation{
+		ID:                id,
+		Account:           account,
+		CreatedAt:         createdAt.UTC(),
+		From:              first,
+		To:                last,
+		AmountTolerance:   tolerance.Amount.String(),
+		DateTolerance:     tolerance.Days,
+		Matched:           make([]model.ReconciliationMatch, 0),
+		UnmatchedInternal: make([]model.ReconciliationBreak, 0),
+		UnmatchedExternal: make([]model.ReconciliationBreak, 0),
+	}
+	for idx, entry := range external {
+		if pairs[idx] == -1 {
+			result.UnmatchedExternal = append(result.UnmatchedExternal, model.ReconciliationBreak{
+				ReconciliationItem: entry.item("e" + strconv.Itoa(idx+1)),
+			})
+			continue
+		}
+		candidate := candidates[pairs[idx]]
+		match := model.ReconciliationMatch{
+			Internal: candidate.item("i" + strconv.Itoa(pairs[idx]+1)),
+			External: entry.item("e" + strconv.Itoa(idx+1)),
+		}
+		if delta := new(money.Dec).Sub(entry.Amount, candidate.amount); delta.Sign() != 0 {
+			match.Difference = delta.String()
+		}
+		result.Matched = append(result.Matched, match)
+	}
+	for idx, candidate := range candidates {
+		valueDate := truncateDay(candidate.Transfer.ValueDate)
+		if candidate.matched || valueDate.Before(first) || valueDate.After(last) {
+			continue
+		}
+		result.UnmatchedInternal = append(result.UnmatchedInternal, model.ReconciliationBreak{
+			ReconciliationItem: candidate.item("i" + strconv.Itoa(idx+1)),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,182 @@
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	money "gopkg.in/inf.v0"
+)
+
+func newAccountEntry(id string, credit bool, day int, amount string) model.AccountEntry {
+	return model.AccountEntry{
+		IDTransaction: "t" + id,
+		Transfer: model.Transfer{
+			IDTransfer: id,
+			ValueDate:  time.Date(2020, 1, day, 12, 0, 0, 0, time.UTC),
+			Amount:     amount,
+			Currency:   "EUR",
+		},
+		Credit: credit,
+	}
+}
+
+func newStatementEntry(reference string, credit bool, day int, amount string) Entry {
+	value, _ := new(money.Dec).SetString(amount)
+	entry := Entry{
+		References: make([]string, 0),
+		ValueDate:  time.Date(2020, 1, day, 0, 0, 0, 0, time.UTC),
+		Amount:     value,
+		Currency:   "EUR",
+		Credit:     credit,
+	}
+	if reference != "" {
+		entry.References = append(entry.References, reference)
+	}
+	return entry
+}
+
+func TestNewTolerance(t *testing.T) {
+	tolerance, err := NewTolerance("0.01", 2)
+	require.Nil(t, err)
+	assert.Equal(t, "0.01", tolerance.Amount.String())
+	assert.Equal(t, 2, tolerance.Days)
+
+	_, err = NewTolerance("-1", 0)
+	assert.NotNil(t, err)
+	_, err = NewTolerance("x", 0)
+	assert.NotNil(t, err)
+	_, err = NewTolerance("0", -1)
+	assert.NotNil(t, err)
+}
+
+func TestReconcile(t *testing.T) {
+	account := model.Account{Tenant: "one", Name: "A"}
+	createdAt := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	internal := []model.AccountEntry{
+		newAccountEntry("1", true, 1, "100"),
+		newAccountEntry("2", true, 2, "100"),
+		newAccountEntry("3", false, 3, "25.50"),
+		newAccountEntry("4", true, 5, "7"),
+		newAccountEntry("5", true, 20, "1"),
+	}
+
+	t.Log("pairs exactly by reference first")
+	{
+		tolerance, _ := NewTolerance("0", 0)
+		external := []Entry{
+			newStatementEntry("", true, 1, "100"),
+			newStatementEntry("2", true, 2, "100"),
+			newStatementEntry("", false, 3, "25.50"),
+			newStatementEntry("", true, 6, "7"),
+			newStatementEntry("", true, 4, "9"),
+		}
+		result, err := Reconcile("r", account, internal, external, time.Time{}, time.Time{}, *tolerance, createdAt)
+		require.Nil(t, err)
+
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), result.From)
+		assert.Equal(t, time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), result.To)
+
+		require.Equal(t, 3, len(result.Matched))
+		assert.Equal(t, "1", result.Matched[0].Internal.Reference)
+		assert.Equal(t, "e1", result.Matched[0].External.ID)
+		assert.Equal(t, "2", result.Matched[1].Internal.Reference)
+		assert.Equal(t, "t2", result.Matched[1].Internal.Transaction)
+		assert.Equal(t, "3", result.Matched[2].Internal.Reference)
+
+		require.Equal(t, 1, len(result.UnmatchedInternal))
+		assert.Equal(t, "4", result.UnmatchedInternal[0].Reference)
+
+		require.Equal(t, 2, len(result.UnmatchedExternal))
+		assert.Equal(t, "e4", result.UnmatchedExternal[0].ID)
+		assert.Equal(t, "e5", result.UnmatchedExternal[1].ID)
+		assert.Equal(t, 3, result.OpenBreaks())
+	}
+
+	t.Log("pairs within tolerance")
+	{
+		tolerance, _ := NewTolerance("0.5", 1)
+		external := []Entry{
+			newStatementEntry("", true, 6, "7.25"),
+			newStatementEntry("", false, 4, "25.50"),
+		}
+		result, err := Reconcile("r", account, internal, external, time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), *tolerance, createdAt)
+		require.Nil(t, err)
+
+		assert.Equal(t, "0.5", result.AmountTolerance)
+		assert.Equal(t, 1, result.DateTolerance)
+		require.Equal(t, 2, len(result.Matched))
+		assert.Equal(t, "4", result.Matched[0].Internal.Reference)
+		assert.Equal(t, "0.25", result.Matched[0].Difference)
+		assert.Equal(t, "3", result.Matched[1].Internal.Reference)
+		assert.Equal(t, "", result.Matched[1].Difference)
+		assert.Equal(t, 0, len(result.UnmatchedInternal))
+		assert.Equal(t, 0, len(result.UnmatchedExternal))
+		assert.Equal(t, 0, result.OpenBreaks())
+	}
+
+	t.Log("empty statement requires period")
+	{
+		tolerance, _ := NewTolerance("0", 0)
+		_, err := Reconcile("r", account, internal, nil, time.Time{}, time.Time{}, *tolerance, createdAt)
+		assert.NotNil(t, err)
+
+		result, err := Reconcile("r", account, internal, nil, time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), *tolerance, createdAt)
+		require.Nil(t, err)
+		require.Equal(t, 1, len(result.UnmatchedInternal))
+		assert.Equal(t, "5", result.UnmatchedInternal[0].Reference)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	newReconciliation := func() *model.Reconciliation {
+		return &model.Reconciliation{
+			UnmatchedInternal: []model.ReconciliationBreak{
+				{ReconciliationItem: model.ReconciliationItem{ID: "i1", Currency: "EUR", Credit: true}},
+				{ReconciliationItem: model.ReconciliationItem{ID: "i2", Currency: "CZK", Credit: true}},
+			},
+			UnmatchedExternal: []model.ReconciliationBreak{
+				{ReconciliationItem: model.ReconciliationItem{ID: "e1", Currency: "EUR", Credit: true}},
+			},
+		}
+	}
+	now := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Log("matches breaks of both sides")
+	{
+		entity := newReconciliation()
+		require.Nil(t, Resolve(entity, "e1", model.ReconciliationResolution{Action: model.ResolutionMatched, Counterpart: "i1", Note: "fee"}, now))
+		assert.Equal(t, "i1", entity.Break("e1").Resolution.Counterpart)
+		assert.Equal(t, "e1", entity.Break("i1").Resolution.Counterpart)
+		assert.Equal(t, now, entity.Break("i1").Resolution.ResolvedAt)
+		assert.Equal(t, 1, entity.OpenBreaks())
+
+		assert.Equal(t, ErrAlreadyResolved, Resolve(entity, "i1", model.ReconciliationResolution{Action: model.ResolutionAccepted, Note: "x"}, now))
+
+		require.Nil(t, Reopen(entity, "i1"))
+		assert.Nil(t, entity.Break("e1").Resolution)
+		assert.Equal(t, 3, entity.OpenBreaks())
+		assert.Equal(t, ErrNotResolved, Reopen(entity, "i1"))
+	}
+
+	t.Log("rejects invalid counterparts")
+	{
+		entity := newReconciliation()
+		assert.Equal(t, ErrInvalidCounterpart, Resolve(entity, "i1", model.ReconciliationResolution{Action: model.ResolutionMatched, Counterpart: "i2"}, now))
+		assert.Equal(t, ErrInvalidCounterpart, Resolve(entity, "i2", model.ReconciliationResolution{Action: model.ResolutionMatched, Counterpart: "e1"}, now))
+		assert.Equal(t, ErrInvalidCounterpart, Resolve(entity, "i1", model.ReconciliationResolution{Action: model.ResolutionMatched, Counterpart: "e9"}, now))
+		assert.Equal(t, ErrUnknownBreak, Resolve(entity, "x", model.ReconciliationResolution{Action: model.ResolutionAccepted, Note: "x"}, now))
+		assert.Equal(t, 3, entity.OpenBreaks())
+	}
+
+	t.Log("accepts break")
+	{
+		entity := newReconciliation()
+		require.Nil(t, Resolve(entity, "i2", model.ReconciliationResolution{Action: model.ResolutionAccepted, Note: "known"}, now))
+		assert.Equal(t, "known", entity.Break("i2").Resolution.Note)
+		assert.Equal(t, 2, entity.OpenBreaks())
+	}
+}
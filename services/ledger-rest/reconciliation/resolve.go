@@ -0,0 +1,99 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconciliation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+)
+
+// MaxResolutionSize is maximum size of accepted break resolution in bytes
+const MaxResolutionSize = 64 << 10
+
+var (
+	// ErrUnknownBreak means there is no break of given id
+	ErrUnknownBreak = fmt.Errorf("unknown break")
+	// ErrAlreadyResolved means break was resolved already
+	ErrAlreadyResolved = fmt.Errorf("break already resolved")
+	// ErrNotResolved means break is still open
+	ErrNotResolved = fmt.Errorf("break not resolved")
+	// ErrInvalidCounterpart means breaks cannot be matched to each other
+	ErrInvalidCounterpart = fmt.Errorf("invalid counterpart")
+)
+
+func isInternal(entity *model.Reconciliation, id string) bool {
+	for _, item := range entity.UnmatchedInternal {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve records resolution of open break, matched resolution resolves open
+// break of other side in same currency and direction as well
+func Resolve(entity *model.Reconciliation, id string, resolution model.ReconciliationResolution, resolvedAt time.Time) error {
+	target := entity.Break(id)
+	if target == nil {
+		return ErrUnknownBreak
+	}
+	if target.Resolution != nil {
+		return ErrAlreadyResolved
+	}
+	resolution.ResolvedAt = resolvedAt.UTC()
+
+	if resolution.Action != model.ResolutionMatched {
+		resolution.Counterpart = ""
+		target.Resolution = &resolution
+		return nil
+	}
+
+	counterpart := entity.Break(resolution.Counterpart)
+	if counterpart == nil || isInternal(entity, id) == isInternal(entity, resolution.Counterpart) {
+		return ErrInvalidCounterpart
+	}
+	if counterpart.Resolution != nil {
+		return ErrAlreadyResolved
+	}
+	if counterpart.Currency != target.Currency || counterpart.Credit != target.Credit {
+		return ErrInvalidCounterpart
+	}
+	target.Resolution = &resolution
+	counterpart.Resolution = &model.ReconciliationResolution{
+		Action:      resolution.Action,
+		Counterpart: id,
+		Note:        resolution.Note,
+		ResolvedAt:  resolution.ResolvedAt,
+	}
+	return nil
+}
+
+// Reopen discards resolution of break and of its counterpart
+func Reopen(entity *model.Reconciliation, id string) error {
+	target := entity.Break(id)
+	if target == nil {
+		return ErrUnknownBreak
+	}
+	if target.Resolution == nil {
+		return ErrNotResolved
+	}
+	if counterpart := entity.Break(target.Resolution.Counterpart); counterpart != nil {
+		counterpart.Resolution = nil
+	}
+	target.Resolution = nil
+	return nil
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	money "gopkg.in/inf.v0"
+)
+
+// Camt053Namespace is namespace of generated bank to customer statement
+const Camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+const isoDate = "2006-01-02"
+
+// NoCurrency is ISO 4217 code of "no currency" stated for account without
+// entries
+const NoCurrency = "XXX"
+
+// Camt053 represents ISO 20022 bank to customer statement document
+type Camt053 struct {
+	XMLName   xml.Name       `xml:"Document"`
+	Namespace string         `xml:"xmlns,attr"`
+	Statement bankToCustomer `xml:"BkToCstmrStmt"`
+}
+
+type bankToCustomer struct {
+	GroupHeader groupHeader `xml:"GrpHdr"`
+	Statements  []statement `xml:"Stmt"`
+}
+
+type groupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+type statement struct {
+	ID        string      `xml:"Id"`
+	CreatedAt string      `xml:"CreDtTm"`
+	Period    period      `xml:"FrToDt"`
+	Account   cashAccount `xml:"Acct"`
+	Balances  []balance   `xml:"Bal"`
+	Summary   summary     `xml:"TxsSummry"`
+	Entries   []entry     `xml:"Ntry"`
+}
+
+type period struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type cashAccount struct {
+	ID       otherID `xml:"Id"`
+	Currency string  `xml:"Ccy"`
+}
+
+type otherID struct {
+	ID string `xml:"Othr>Id"`
+}
+
+type amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type date struct {
+	Date string `xml:"Dt"`
+}
+
+type balance struct {
+	Type      string `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    amount `xml:"Amt"`
+	Indicator string `xml:"CdtDbtInd"`
+	Date      date   `xml:"Dt"`
+}
+
+type entriesSummary struct {
+	Count string `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type summary struct {
+	Total   entriesSummary `xml:"TtlNtries"`
+	Credits entriesSummary `xml:"TtlCdtNtries"`
+	Debits  entriesSummary `xml:"TtlDbtNtries"`
+}
+
+type transactionCode struct {
+	Domain    string `xml:"Domn>Cd"`
+	Family    string `xml:"Domn>Fmly>Cd"`
+	SubFamily string `xml:"Domn>Fmly>SubFmlyCd"`
+}
+
+type references struct {
+	ServicerReference string `xml:"AcctSvcrRef"`
+	EndToEndID        string `xml:"EndToEndId"`
+}
+
+type relatedParties struct {
+	DebtorAccount   *otherID `xml:"DbtrAcct>Id,omitempty"`
+	CreditorAccount *otherID `xml:"CdtrAcct>Id,omitempty"`
+}
+
+type transactionDetails struct {
+	References     references     `xml:"Refs"`
+	RelatedParties relatedParties `xml:"RltdPties"`
+}
+
+type entry struct {
+	Reference         string             `xml:"NtryRef"`
+	Amount            amount             `xml:"Amt"`
+	Indicator         string             `xml:"CdtDbtInd"`
+	Status            string             `xml:"Sts"`
+	BookingDate       date               `xml:"BookgDt"`
+	ValueDate         date               `xml:"ValDt"`
+	ServicerReference string             `xml:"AcctSvcrRef"`
+	Code              transactionCode    `xml:"BkTxCd"`
+	Details           transactionDetails `xml:"NtryDtls>TxDtls"`
+}
+
+func indicatorOf(value *money.Dec) string {
+	if value.Sign() < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func formatAmount(value *money.Dec) string {
+	return new(money.Dec).Abs(value).String()
+}
+
+func accountID(account model.Account) string {
+	return account.Tenant + "/" + account.Name
+}
+
+// currencyStatement accumulates entries of account in single currency
+type currencyStatement struct {
+	currency string
+	opening  *money.Dec
+	closing  *money.Dec
+	credits  *money.Dec
+	debits   *money.Dec
+	nCredits int
+	nDebits  int
+	entries  []entry
+}
+
+func newCurrencyStatement(currency string) *currencyStatement {
+	return &currencyStatement{
+		currency: currency,
+		opening:  new(money.Dec),
+		closing:  new(money.Dec),
+		credits:  new(money.Dec),
+		debits:   new(money.Dec),
+		entries:  make([]entry, 0),
+	}
+}
+
+func (acc *currencyStatement) add(item model.AccountEntry, value *money.Dec, from time.Time, to time.Time) {
+	valueDate := item.Transfer.ValueDate
+	if !valueDate.Before(to) {
+		return
+	}
+	signed := new(money.Dec).Set(value)
+	if !item.Credit {
+		signed.Neg(signed)
+	}
+	acc.closing.Add(acc.closing, signed)
+	if valueDate.Before(from) {
+		acc.opening.Add(acc.opening, signed)
+		return
+	}
+
+	code := transactionCode{
+		Domain:    "PMNT",
+		Family:    "RCDT",
+		SubFamily: "BOOK",
+	}
+	parties := relatedParties{
+		DebtorAccount: &otherID{ID: accountID(item.Transfer.Debit)},
+	}
+	if item.Credit {
+		acc.credits.Add(acc.credits, value)
+		acc.nCredits++
+	} else {
+		acc.debits.Add(acc.debits, value)
+		acc.nDebits++
+		code.Family = "ICDT"
+		parties = relatedParties{
+			CreditorAccount: &otherID{ID: accountID(item.Transfer.Credit)},
+		}
+	}
+
+	acc.entries = append(acc.entries, entry{
+		Reference: item.Transfer.IDTransfer,
+		Amount: amount{
+			Currency: acc.currency,
+			Value:    value.String(),
+		},
+		Indicator:         indicatorOf(signed),
+		Status:            "BOOK",
+		BookingDate:       date{valueDate.UTC().Format(isoDate)},
+		ValueDate:         date{valueDate.UTC().Format(isoDate)},
+		ServicerReference: item.IDTransaction,
+		Code:              code,
+		Details: transactionDetails{
+			References: references{
+				ServicerReference: item.IDTransaction,
+				EndToEndID:        item.Transfer.IDTransfer,
+			},
+			RelatedParties: parties,
+		},
+	})
+}
+
+// NewCamt053 returns statement of account for days from first to last
+// inclusive built from all committed entries of account, balances are derived
+// from entries booked before and within period and every currency of account
+// is stated separately, account without entries is stated with zero balances
+func NewCamt053(id string, account model.Account, entries []model.AccountEntry, first time.Time, last time.Time, createdAt time.Time) (*Camt053, error) {
+	from := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	byCurrency := make(map[string]*currencyStatement)
+	for _, item := range entries {
+		value, ok := new(money.Dec).SetString(item.Transfer.Amount)
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid amount %q of transfer %s", item.Transfer.Amount, item.Transfer.IDTransfer)
+		}
+		acc, ok := byCurrency[item.Transfer.Currency]
+		if !ok {
+			acc = newCurrencyStatement(item.Transfer.Currency)
+			byCurrency[item.Transfer.Currency] = acc
+		}
+		acc.add(item, value, from, to)
+	}
+	if len(byCurrency) == 0 {
+		byCurrency[NoCurrency] = newCurrencyStatement(NoCurrency)
+	}
+
+	currencies := make([]string, 0, len(byCurrency))
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	result := &Camt053{
+		Namespace: Camt053Namespace,
+		Statement: bankToCustomer{
+			GroupHeader: groupHeader{
+				MessageID: id,
+				CreatedAt: createdAt.UTC().Format(time.RFC3339),
+			},
+			Statements: make([]statement, 0, len(currencies)),
+		},
+	}
+
+	for idx, currency := range currencies {
+		acc := byCurrency[currency]
+		total := new(money.Dec).Add(acc.credits, acc.debits)
+		result.Statement.Statements = append(result.Statement.Statements, statement{
+			ID:        id + "-" + strconv.Itoa(idx+1),
+			CreatedAt: createdAt.UTC().Format(time.RFC3339),
+			Period: period{
+				From: from.Format(time.RFC3339),
+				To:   to.Add(-time.Second).Format(time.RFC3339),
+			},
+			Account: cashAccount{
+				ID:       otherID{ID: accountID(account)},
+				Currency: currency,
+			},
+			Balances: []balance{
+				{
+					Type:      "OPBD",
+					Amount:    amount{Currency: currency, Value: formatAmount(acc.opening)},
+					Indicator: indicatorOf(acc.opening),
+					Date:      date{from.Format(isoDate)},
+				},
+				{
+					Type:      "CLBD",
+					Amount:    amount{Currency: currency, Value: formatAmount(acc.closing)},
+					Indicator: indicatorOf(acc.closing),
+					Date:      date{to.AddDate(0, 0, -1).Format(isoDate)},
+				},
+			},
+			Summary: summary{
+				Total:   entriesSummary{strconv.Itoa(acc.nCredits + acc.nDebits), total.String()},
+				Credits: entriesSummary{strconv.Itoa(acc.nCredits), acc.credits.String()},
+				Debits:  entriesSummary{strconv.Itoa(acc.nDebits), acc.debits.String()},
+			},
+			Entries: acc.entries,
+		})
+	}
+
+	return result, nil
+}
+
+// Serialize returns statement as XML document
+func (entity *Camt053) Serialize() ([]byte, error) {
+	if entity == nil {
+		return nil, fmt.Errorf("cannot serialize nil pointer")
+	}
+	data, err := xml.MarshalIndent(entity, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,141 @@
+package statement
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEntry(id string, credit bool, day int, amount string, currency string) model.AccountEntry {
+	account := model.Account{Tenant: "one", Name: "A"}
+	counterparty := model.Account{Tenant: "two", Name: "B"}
+	transfer := model.Transfer{
+		IDTransfer: id,
+		Credit:     counterparty,
+		Debit:      account,
+		ValueDate:  time.Date(2020, 1, day, 12, 0, 0, 0, time.UTC),
+		Amount:     amount,
+		Currency:   currency,
+	}
+	if credit {
+		transfer.Credit, transfer.Debit = account, counterparty
+	}
+	return model.AccountEntry{
+		IDTransaction: "t" + id,
+		Transfer:      transfer,
+		Credit:        credit,
+	}
+}
+
+func TestNewCamt053(t *testing.T) {
+	account := model.Account{Tenant: "one", Name: "A"}
+	createdAt := time.Date(2020, 2, 1, 8, 0, 0, 0, time.UTC)
+	entries := []model.AccountEntry{
+		newEntry("1", true, 1, "100.5", "EUR"),
+		newEntry("2", false, 5, "20.25", "EUR"),
+		newEntry("3", true, 10, "1", "CZK"),
+		newEntry("4", false, 20, "30", "EUR"),
+		newEntry("5", true, 31, "1000", "EUR"),
+	}
+
+	t.Log("derives balances and entries of period")
+	{
+		document, err := NewCamt053("msg", account, entries, time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC), createdAt)
+		require.Nil(t, err)
+
+		assert.Equal(t, "msg", document.Statement.GroupHeader.MessageID)
+		require.Equal(t, 2, len(document.Statement.Statements))
+
+		czk := document.Statement.Statements[0]
+		assert.Equal(t, "CZK", czk.Account.Currency)
+		assert.Equal(t, "msg-1", czk.ID)
+		assert.Equal(t, "0", czk.Balances[0].Amount.Value)
+		assert.Equal(t, "1", czk.Balances[1].Amount.Value)
+
+		eur := document.Statement.Statements[1]
+		assert.Equal(t, "one/A", eur.Account.ID.ID)
+		assert.Equal(t, "2020-01-05T00:00:00Z", eur.Period.From)
+		assert.Equal(t, "2020-01-20T23:59:59Z", eur.Period.To)
+
+		assert.Equal(t, "OPBD", eur.Balances[0].Type)
+		assert.Equal(t, "100.5", eur.Balances[0].Amount.Value)
+		assert.Equal(t, "CRDT", eur.Balances[0].Indicator)
+		assert.Equal(t, "2020-01-05", eur.Balances[0].Date.Date)
+
+		assert.Equal(t, "CLBD", eur.Balances[1].Type)
+		assert.Equal(t, "50.25", eur.Balances[1].Amount.Value)
+		assert.Equal(t, "CRDT", eur.Balances[1].Indicator)
+		assert.Equal(t, "2020-01-20", eur.Balances[1].Date.Date)
+
+		require.Equal(t, 2, len(eur.Entries))
+		assert.Equal(t, "2", eur.Entries[0].Reference)
+		assert.Equal(t, "t2", eur.Entries[0].ServicerReference)
+		assert.Equal(t, "DBIT", eur.Entries[0].Indicator)
+		assert.Equal(t, "20.25", eur.Entries[0].Amount.Value)
+		assert.Equal(t, "2020-01-05", eur.Entries[0].BookingDate.Date)
+		assert.Equal(t, "2020-01-05", eur.Entries[0].ValueDate.Date)
+		assert.Equal(t, "ICDT", eur.Entries[0].Code.Family)
+		assert.Equal(t, "4", eur.Entries[1].Reference)
+
+		assert.Equal(t, "2", eur.Summary.Total.Count)
+		assert.Equal(t, "50.25", eur.Summary.Total.Sum)
+		assert.Equal(t, "0", eur.Summary.Credits.Count)
+		assert.Equal(t, "2", eur.Summary.Debits.Count)
+	}
+
+	t.Log("states debit balance")
+	{
+		document, err := NewCamt053("msg", account, entries[1:2], time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), createdAt)
+		require.Nil(t, err)
+		closing := document.Statement.Statements[0].Balances[1]
+		assert.Equal(t, "20.25", closing.Amount.Value)
+		assert.Equal(t, "DBIT", closing.Indicator)
+	}
+
+	t.Log("states account without entries with zero balances")
+	{
+		document, err := NewCamt053("msg", account, nil, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), createdAt)
+		require.Nil(t, err)
+		require.Equal(t, 1, len(document.Statement.Statements))
+		empty := document.Statement.Statements[0]
+		assert.Equal(t, NoCurrency, empty.Account.Currency)
+		assert.Equal(t, "0", empty.Balances[0].Amount.Value)
+		assert.Equal(t, "0", empty.Balances[1].Amount.Value)
+		assert.Equal(t, "0", empty.Summary.Total.Count)
+		assert.Equal(t, 0, len(empty.Entries))
+	}
+
+	t.Log("refuses invalid amount")
+	{
+		_, err := NewCamt053("msg", account, []model.AccountEntry{newEntry("x", true, 1, "1e5", "EUR")}, createdAt, createdAt, createdAt)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCamt053Serialize(t *testing.T) {
+	account := model.Account{Tenant: "one", Name: "A"}
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	document, err := NewCamt053("msg", account, []model.AccountEntry{newEntry("1", true, 1, "1.5", "EUR")}, day, day, day)
+	require.Nil(t, err)
+
+	data, err := document.Serialize()
+	require.Nil(t, err)
+
+	text := string(data)
+	assert.True(t, strings.HasPrefix(text, xml.Header))
+	assert.Contains(t, text, "<Document xmlns=\""+Camt053Namespace+"\">")
+	assert.Contains(t, text, "<Bal>\n        <Tp>\n          <CdOrPrtry>\n            <Cd>OPBD</Cd>")
+	assert.Contains(t, text, "<Amt Ccy=\"EUR\">1.5</Amt>")
+	assert.Contains(t, text, "<DbtrAcct>\n                <Id>\n                  <Othr>\n                    <Id>two/B</Id>")
+	assert.NotContains(t, text, "CdtrAcct")
+
+	var decoded Camt053
+	require.Nil(t, xml.Unmarshal(data, &decoded))
+	assert.Equal(t, "1", decoded.Statement.Statements[0].Entries[0].Reference)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package naming
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MinTenantLength is minimal length of tenant name
+const MinTenantLength = 1
+
+// MaxTenantLength is maximal length of tenant name
+const MaxTenantLength = 64
+
+// ReservedTenantNames are names that cannot be used as tenant because
+// ledger-unit is started with tenant as its first argument and they would be
+// taken for its subcommands
+var ReservedTenantNames = []string{
+	"export",
+	"fsck",
+}
+
+func isAllowedTenantCharacter(c byte) bool {
+	return (c >= 'a' && c <= 'z') ||
+		(c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9') ||
+		c == '_' ||
+		c == '-'
+}
+
+// ValidateTenant returns error describing why name is not valid tenant name
+func ValidateTenant(name string) error {
+	if len(name) < MinTenantLength {
+		return fmt.Errorf("tenant name is empty")
+	}
+	if len(name) > MaxTenantLength {
+		return fmt.Errorf("tenant name is longer than %d characters", MaxTenantLength)
+	}
+	for i := 0; i < len(name); i++ {
+		if !isAllowedTenantCharacter(name[i]) {
+			return fmt.Errorf("tenant name contains invalid character %q, allowed are letters, digits, \"_\" and \"-\"", name[i])
+		}
+	}
+	if name[0] == '-' || name[0] == '_' {
+		return fmt.Errorf("tenant name must start with letter or digit")
+	}
+	for _, reserved := range ReservedTenantNames {
+		if strings.EqualFold(name, reserved) {
+			return fmt.Errorf("tenant name %s is reserved", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTenant(t *testing.T) {
+	t.Log("valid names")
+	{
+		for _, name := range []string{"a", "T1", "CONFIGURATION_DEBUG", "lorem-ipsum", "0", strings.Repeat("x", MaxTenantLength)} {
+			assert.Nil(t, ValidateTenant(name), name)
+		}
+	}
+
+	t.Log("invalid names")
+	{
+		for _, name := range []string{"", "a/b", "..", "a@b", "a.service", "a b", "-a", "_a", "č", strings.Repeat("x", MaxTenantLength+1)} {
+			assert.NotNil(t, ValidateTenant(name), name)
+		}
+	}
+
+	t.Log("subcommands of ledger-unit")
+	{
+		for _, name := range []string{"fsck", "FSCK", "export"} {
+			assert.NotNil(t, ValidateTenant(name), name)
+		}
+	}
+
+	t.Log("reserved names")
+	{
+		defer func(names []string) {
+			ReservedTenantNames = names
+		}(ReservedTenantNames)
+		ReservedTenantNames = []string{"subcommand"}
+
+		for _, name := range []string{"subcommand", "SubCommand"} {
+			assert.NotNil(t, ValidateTenant(name), name)
+		}
+	}
+}
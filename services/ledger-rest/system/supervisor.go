@@ -0,0 +1,432 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/support/naming"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+const minRestartBackoff = 100 * time.Millisecond
+const maxRestartBackoff = time.Minute
+const stableUptime = 10 * time.Second
+const stopTimeout = 5 * time.Second
+const maxLogSize = 10 * 1024 * 1024
+
+// exitCodeInvalidConfiguration is exit code of ledger-unit refusing to start,
+// such unit is not restarted
+const exitCodeInvalidConfiguration = 78
+
+type supervisedProcess struct {
+	mutex           sync.RWMutex
+	name            string
+	tenant          string
+	status          string
+	statusChangedAt uint64
+	restarts        int
+	process         *os.Process
+	stop            chan interface{}
+	done            chan interface{}
+}
+
+func (proc *supervisedProcess) setStatus(status string) {
+	proc.mutex.Lock()
+	defer proc.mutex.Unlock()
+	if proc.status == status {
+		return
+	}
+	proc.status = status
+	proc.statusChangedAt = uint64(time.Now().UnixNano() / int64(time.Microsecond))
+}
+
+func (proc *supervisedProcess) unitStatus() UnitStatus {
+	proc.mutex.RLock()
+	defer proc.mutex.RUnlock()
+	return UnitStatus{
+		Status:          proc.status,
+		StatusChangedAt: proc.statusChangedAt,
+	}
+}
+
+// ProcessControl is control implementation spawning and supervising
+// ledger-unit processes directly without systemd
+type ProcessControl struct {
+	binary       string
+	logDirectory string
+	storage      localfs.Storage
+	mutex        sync.Mutex
+	processes    map[string]*supervisedProcess
+	done         chan interface{}
+}
+
+// NewProcessControl returns new process supervisor fascade
+func NewProcessControl(binary string, logDirectory string, rootStorage string) *ProcessControl {
+	storage, err := localfs.NewPlaintextStorage(rootStorage)
+	if err != nil {
+		log.Error().Msgf("Failed to ensure storage %+v", err)
+		return nil
+	}
+	if err := os.MkdirAll(logDirectory, 0700); err != nil {
+		log.Error().Msgf("Failed to ensure log directory %+v", err)
+		return nil
+	}
+	return &ProcessControl{
+		binary:       binary,
+		logDirectory: logDirectory,
+		storage:      storage,
+		processes:    make(map[string]*supervisedProcess),
+		done:         make(chan interface{}),
+	}
+}
+
+func tenantOfUnit(name string) (string, error) {
+	if !strings.HasPrefix(name, "unit@") || !strings.HasSuffix(name, ".service") {
+		return "", fmt.Errorf("unsupported unit %s", name)
+	}
+	tenant := strings.TrimSuffix(strings.TrimPrefix(name, "unit@"), ".service")
+	if err := naming.ValidateTenant(tenant); err != nil {
+		return "", fmt.Errorf("unsupported unit %s, %s", name, err.Error())
+	}
+	return tenant, nil
+}
+
+func childEnvironment(tenant string) []string {
+	result := make([]string, 0)
+	for _, item := range os.Environ() {
+		if strings.HasPrefix(item, "NOTIFY_SOCKET=") || strings.HasPrefix(item, "LEDGER_TENANT=") {
+			continue
+		}
+		result = append(result, item)
+	}
+	return append(result, "LEDGER_TENANT="+tenant)
+}
+
+func (sys *ProcessControl) openLog(name string) (*os.File, error) {
+	path := filepath.Join(sys.logDirectory, "ledger-"+strings.TrimSuffix(name, ".service")+".log")
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+}
+
+func (sys *ProcessControl) spawn(proc *supervisedProcess) (*exec.Cmd, *os.File, error) {
+	output, err := sys.openLog(proc.name)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd := exec.Command(sys.binary, proc.tenant)
+	cmd.Env = childEnvironment(proc.tenant)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Start(); err != nil {
+		output.Close()
+		return nil, nil, err
+	}
+	proc.mutex.Lock()
+	proc.process = cmd.Process
+	proc.mutex.Unlock()
+	proc.setStatus("running")
+	return cmd, output, nil
+}
+
+func (sys *ProcessControl) supervise(proc *supervisedProcess, cmd *exec.Cmd, output *os.File) {
+	defer close(proc.done)
+	backoff := minRestartBackoff
+	for {
+		startedAt := time.Now()
+		err := cmd.Wait()
+		output.Close()
+
+		select {
+		case <-proc.stop:
+			proc.setStatus("dead")
+			log.Info().Msgf("Stopped unit %s", proc.name)
+			return
+		default:
+		}
+
+		if cmd.ProcessState != nil && cmd.ProcessState.ExitCode() == exitCodeInvalidConfiguration {
+			proc.setStatus("failed")
+			log.Error().Msgf("Unit %s refused to start, not restarting", proc.name)
+			<-proc.stop
+			proc.setStatus("dead")
+			return
+		}
+
+		if time.Now().Sub(startedAt) >= stableUptime {
+			backoff = minRestartBackoff
+		}
+		log.Warn().Msgf("Unit %s exited %+v, restarting in %v", proc.name, err, backoff)
+
+		for {
+			proc.setStatus("auto-restart")
+			select {
+			case <-proc.stop:
+				proc.setStatus("dead")
+				log.Info().Msgf("Stopped unit %s", proc.name)
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < maxRestartBackoff {
+				backoff *= 2
+			}
+			if backoff > maxRestartBackoff {
+				backoff = maxRestartBackoff
+			}
+			proc.mutex.Lock()
+			proc.restarts++
+			proc.mutex.Unlock()
+			cmd, output, err = sys.spawn(proc)
+			if err == nil {
+				select {
+				case <-proc.stop:
+					cmd.Process.Signal(syscall.SIGTERM)
+				default:
+				}
+				break
+			}
+			proc.setStatus("failed")
+			log.Warn().Msgf("Unable to restart unit %s because %+v", proc.name, err)
+		}
+	}
+}
+
+func (sys *ProcessControl) start(name string) error {
+	tenant, err := tenantOfUnit(name)
+	if err != nil {
+		return err
+	}
+
+	sys.mutex.Lock()
+	defer sys.mutex.Unlock()
+
+	if _, ok := sys.processes[name]; ok {
+		return nil
+	}
+
+	proc := &supervisedProcess{
+		name:   name,
+		tenant: tenant,
+		stop:   make(chan interface{}),
+		done:   make(chan interface{}),
+	}
+	cmd, output, err := sys.spawn(proc)
+	if err != nil {
+		return fmt.Errorf("unable to start unit %s because %+v", "ledger-"+name, err)
+	}
+	sys.processes[name] = proc
+	go sys.supervise(proc, cmd, output)
+	log.Info().Msgf("Started unit ledger-%s", name)
+	return nil
+}
+
+func (sys *ProcessControl) stop(proc *supervisedProcess) error {
+	close(proc.stop)
+	proc.mutex.RLock()
+	process := proc.process
+	proc.mutex.RUnlock()
+	if process != nil {
+		process.Signal(syscall.SIGTERM)
+	}
+	select {
+	case <-proc.done:
+		return nil
+	case <-time.After(stopTimeout):
+		if process != nil {
+			process.Kill()
+		}
+	}
+	select {
+	case <-proc.done:
+		return nil
+	case <-time.After(stopTimeout):
+		return fmt.Errorf("unable to stop unit ledger-%s because timeout", proc.name)
+	}
+}
+
+// ListUnits returns list of unit names
+func (sys *ProcessControl) ListUnits(prefix string) ([]string, error) {
+	if sys == nil {
+		return nil, fmt.Errorf("cannot call method on nil")
+	}
+
+	sys.mutex.Lock()
+	defer sys.mutex.Unlock()
+
+	var result = make([]string, 0)
+	for name := range sys.processes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		result = append(result, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".service"))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+
+	return result, nil
+}
+
+// GetUnitsProperties return unit properties
+func (sys *ProcessControl) GetUnitsProperties(prefix string) (map[string]UnitStatus, error) {
+	if sys == nil {
+		return nil, fmt.Errorf("cannot call method on nil")
+	}
+
+	sys.mutex.Lock()
+	defer sys.mutex.Unlock()
+
+	var result = make(map[string]UnitStatus)
+	for name, proc := range sys.processes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		result["ledger-"+name] = proc.unitStatus()
+	}
+
+	return result, nil
+}
+
+// GetRestarts returns number of restarts of unit
+func (sys *ProcessControl) GetRestarts(name string) int {
+	if sys == nil {
+		return 0
+	}
+
+	sys.mutex.Lock()
+	proc, ok := sys.processes[name]
+	sys.mutex.Unlock()
+	if !ok {
+		return 0
+	}
+	proc.mutex.RLock()
+	defer proc.mutex.RUnlock()
+	return proc.restarts
+}
+
+// DisableUnit stops unit and forgets it
+func (sys *ProcessControl) DisableUnit(name string) error {
+	if sys == nil {
+		return fmt.Errorf("cannot call method on nil")
+	}
+
+	sys.mutex.Lock()
+	proc, ok := sys.processes[name]
+	delete(sys.processes, name)
+	sys.mutex.Unlock()
+
+	if err := sys.storage.DeleteFile("supervisor/" + name); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to disable unit ledger-%s because %+v", name, err)
+	}
+	log.Info().Msgf("Disabled unit ledger-%s", name)
+
+	if !ok {
+		return nil
+	}
+	return sys.stop(proc)
+}
+
+// EnableUnit starts unit and remembers it so it is started again after
+// restart of supervisor
+func (sys *ProcessControl) EnableUnit(name string) error {
+	if sys == nil {
+		return fmt.Errorf("cannot call method on nil")
+	}
+
+	if _, err := tenantOfUnit(name); err != nil {
+		return err
+	}
+	if ok, _ := sys.storage.Exists("supervisor/" + name); !ok {
+		if err := sys.storage.TouchFile("supervisor/" + name); err != nil {
+			return fmt.Errorf("unable to enable unit ledger-%s because %+v", name, err)
+		}
+	}
+	log.Info().Msgf("Enabled unit ledger-%s", name)
+
+	return sys.start(name)
+}
+
+// Setup starts previously enabled units
+func (sys *ProcessControl) Setup() error {
+	if sys == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	ok, err := sys.storage.Exists("supervisor")
+	if err != nil || !ok {
+		return err
+	}
+	units, err := sys.storage.ListDirectory("supervisor", true)
+	if err != nil {
+		return err
+	}
+	for _, name := range units {
+		if err := sys.start(name); err != nil {
+			log.Warn().Msg(err.Error())
+		}
+	}
+	return nil
+}
+
+// Done returns signal when all units were stopped
+func (sys *ProcessControl) Done() <-chan interface{} {
+	if sys == nil {
+		done := make(chan interface{})
+		close(done)
+		return done
+	}
+	return sys.done
+}
+
+// Cancel stops all units, they stay enabled
+func (sys *ProcessControl) Cancel() {
+	if sys == nil {
+		return
+	}
+	sys.mutex.Lock()
+	processes := sys.processes
+	sys.processes = make(map[string]*supervisedProcess)
+	sys.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, proc := range processes {
+		wg.Add(1)
+		go func(proc *supervisedProcess) {
+			defer wg.Done()
+			if err := sys.stop(proc); err != nil {
+				log.Warn().Msg(err.Error())
+			}
+		}(proc)
+	}
+	wg.Wait()
+	close(sys.done)
+}
+
+// Work does nothing, units are supervised in background
+func (sys *ProcessControl) Work() {
+}
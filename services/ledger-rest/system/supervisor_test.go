@@ -0,0 +1,104 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventually(condition func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestProcessControl(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_supervisor")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	binary := filepath.Join(tmpdir, "ledger-unit")
+	require.Nil(t, ioutil.WriteFile(binary, []byte("#!/bin/sh\necho started $1 $LEDGER_TENANT\nif [ \"$1\" = \"crash\" ]; then exit 1; fi\nif [ \"$1\" = \"refuse\" ]; then exit 78; fi\nexec sleep 60\n"), 0700))
+
+	control := NewProcessControl(binary, filepath.Join(tmpdir, "log"), filepath.Join(tmpdir, "data"))
+	require.NotNil(t, control)
+	require.Nil(t, control.Setup())
+
+	t.Log("rejects unknown unit")
+	{
+		assert.NotNil(t, control.EnableUnit("rest.service"))
+	}
+
+	t.Log("enable spawns unit and captures its output")
+	{
+		require.Nil(t, control.EnableUnit("unit@a.service"))
+		require.Nil(t, control.EnableUnit("unit@a.service"))
+
+		units, err := control.ListUnits("unit@")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"a"}, units)
+
+		properties, err := control.GetUnitsProperties("unit@")
+		require.Nil(t, err)
+		assert.Equal(t, "running", properties["ledger-unit@a.service"].Status)
+		assert.NotEqual(t, uint64(0), properties["ledger-unit@a.service"].StatusChangedAt)
+
+		assert.True(t, eventually(func() bool {
+			data, _ := ioutil.ReadFile(filepath.Join(tmpdir, "log", "ledger-unit@a.log"))
+			return string(data) == "started a a\n"
+		}))
+	}
+
+	t.Log("crashing unit is restarted")
+	{
+		require.Nil(t, control.EnableUnit("unit@crash.service"))
+		assert.True(t, eventually(func() bool {
+			return control.GetRestarts("unit@crash.service") >= 2
+		}))
+	}
+
+	t.Log("unit refusing to start is not restarted")
+	{
+		require.Nil(t, control.EnableUnit("unit@refuse.service"))
+		assert.True(t, eventually(func() bool {
+			properties, _ := control.GetUnitsProperties("unit@refuse")
+			return properties["ledger-unit@refuse.service"].Status == "failed"
+		}))
+		assert.Equal(t, 0, control.GetRestarts("unit@refuse.service"))
+	}
+
+	t.Log("disable stops unit")
+	{
+		require.Nil(t, control.DisableUnit("unit@crash.service"))
+		require.Nil(t, control.DisableUnit("unit@refuse.service"))
+
+		units, err := control.ListUnits("unit@")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"a"}, units)
+	}
+
+	t.Log("enabled units survive restart of supervisor")
+	{
+		control.Cancel()
+		<-control.Done()
+
+		control = NewProcessControl(binary, filepath.Join(tmpdir, "log"), filepath.Join(tmpdir, "data"))
+		require.NotNil(t, control)
+		require.Nil(t, control.Setup())
+		defer control.Cancel()
+
+		units, err := control.ListUnits("unit@")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"a"}, units)
+	}
+}
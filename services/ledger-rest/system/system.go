@@ -96,17 +96,16 @@ func (sys *DbusControl) GetUnitsProperties(prefix string) (map[string]UnitStatus
 		}
 		properties, err := sys.underlying.GetUnitProperties(unit.Name)
 
-		if err != nil {
-			result[unit.Name] = UnitStatus{
-				Status:          unit.SubState,
-				StatusChangedAt: 0,
-			}
-		} else {
-			result[unit.Name] = UnitStatus{
-				Status:          unit.SubState,
-				StatusChangedAt: properties["StateChangeTimestamp"].(uint64),
+		status := UnitStatus{
+			Status:          unit.SubState,
+			StatusChangedAt: 0,
+		}
+		if err == nil {
+			if changedAt, ok := properties["StateChangeTimestamp"].(uint64); ok {
+				status.StatusChangedAt = changedAt
 			}
 		}
+		result[unit.Name] = status
 	}
 
 	return result, nil
@@ -0,0 +1,130 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// SpanContext identifies span within trace as carried by W3C traceparent
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// FlagSampled marks trace as sampled
+const FlagSampled = 0x01
+
+var zeroTrace [16]byte
+var zeroSpan [8]byte
+
+// IsValid tells whenever context carries non zero trace and span identifiers
+func (ctx SpanContext) IsValid() bool {
+	return ctx.TraceID != zeroTrace && ctx.SpanID != zeroSpan
+}
+
+// TraceIDString returns hex encoded trace identifier
+func (ctx SpanContext) TraceIDString() string {
+	return hex.EncodeToString(ctx.TraceID[:])
+}
+
+// SpanIDString returns hex encoded span identifier
+func (ctx SpanContext) SpanIDString() string {
+	return hex.EncodeToString(ctx.SpanID[:])
+}
+
+// String serializes context to traceparent header value
+func (ctx SpanContext) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", ctx.TraceIDString(), ctx.SpanIDString(), ctx.Flags)
+}
+
+// Child returns context of new span within same trace, new sampled trace is
+// started when context is not valid
+func (ctx SpanContext) Child() SpanContext {
+	if !ctx.IsValid() {
+		return NewTrace()
+	}
+	child := SpanContext{
+		TraceID: ctx.TraceID,
+		Flags:   ctx.Flags,
+	}
+	randomize(child.SpanID[:])
+	return child
+}
+
+// NewTrace returns context of root span of new sampled trace
+func NewTrace() SpanContext {
+	ctx := SpanContext{
+		Flags: FlagSampled,
+	}
+	randomize(ctx.TraceID[:])
+	randomize(ctx.SpanID[:])
+	return ctx
+}
+
+func randomize(buffer []byte) {
+	for {
+		if _, err := rand.Read(buffer); err != nil {
+			continue
+		}
+		for _, b := range buffer {
+			if b != 0 {
+				return
+			}
+		}
+	}
+}
+
+// ParseTraceparent parses W3C traceparent header value
+func ParseTraceparent(value string) (SpanContext, error) {
+	ctx := SpanContext{}
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return ctx, fmt.Errorf("invalid traceparent %q", value)
+	}
+	if len(parts[0]) != 2 || parts[0] == "ff" {
+		return ctx, fmt.Errorf("invalid traceparent version %q", parts[0])
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return ctx, fmt.Errorf("invalid traceparent %q", value)
+	}
+	if err := decodeHex(ctx.TraceID[:], parts[1]); err != nil {
+		return ctx, fmt.Errorf("invalid trace id %q", parts[1])
+	}
+	if err := decodeHex(ctx.SpanID[:], parts[2]); err != nil {
+		return ctx, fmt.Errorf("invalid span id %q", parts[2])
+	}
+	var flags [1]byte
+	if err := decodeHex(flags[:], parts[3]); err != nil {
+		return ctx, fmt.Errorf("invalid trace flags %q", parts[3])
+	}
+	ctx.Flags = flags[0]
+	if !ctx.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+	return ctx, nil
+}
+
+func decodeHex(target []byte, value string) error {
+	if len(value) != 2*len(target) || strings.ToLower(value) != value {
+		return fmt.Errorf("invalid length")
+	}
+	_, err := hex.Decode(target, []byte(value))
+	return err
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExporter(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunk, _ := ioutil.ReadAll(r.Body)
+		received <- chunk
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := NewExporter(server.URL+"/v1/traces", "test")
+
+	parent := NewTrace()
+	span := parent.Child()
+	now := time.Unix(1, 0)
+
+	exporter.Export(Span{
+		Name:       "promise",
+		Kind:       KindInternal,
+		Context:    span,
+		Parent:     parent,
+		Start:      now,
+		End:        now.Add(time.Second),
+		Attributes: map[string]string{"transaction": "xyz"},
+		Failed:     true,
+	})
+	exporter.Export(Span{
+		Name:    "unsampled",
+		Context: SpanContext{TraceID: span.TraceID, SpanID: span.SpanID},
+	})
+	exporter.Work()
+
+	var chunk []byte
+	select {
+	case chunk = <-received:
+	default:
+		t.Fatalf("expected spans to be pushed")
+	}
+
+	var request otlpRequest
+	if err := json.Unmarshal(chunk, &request); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span got %d", len(spans))
+	}
+	if spans[0].TraceID != span.TraceIDString() || spans[0].SpanID != span.SpanIDString() || spans[0].ParentSpanID != parent.SpanIDString() {
+		t.Errorf("unexpected identifiers %+v", spans[0])
+	}
+	if spans[0].StartTimeUnixNano != "1000000000" || spans[0].EndTimeUnixNano != "2000000000" {
+		t.Errorf("unexpected timestamps %+v", spans[0])
+	}
+	if spans[0].Status.Code != 2 {
+		t.Errorf("expected error status got %d", spans[0].Status.Code)
+	}
+	if request.ResourceSpans[0].Resource.Attributes[0].Value.StringValue != "test" {
+		t.Errorf("expected service name test")
+	}
+}
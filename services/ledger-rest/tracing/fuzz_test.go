@@ -0,0 +1,29 @@
+//go:build go1.18
+// +build go1.18
+
+package tracing
+
+import (
+	"testing"
+)
+
+func FuzzParseTraceparent(f *testing.F) {
+	f.Add("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	f.Add("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	f.Add("---")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		ctx, err := ParseTraceparent(value)
+		if err != nil {
+			return
+		}
+		again, err := ParseTraceparent(ctx.String())
+		if err != nil {
+			t.Fatalf("formatted traceparent %q does not parse %+v", ctx.String(), err)
+		}
+		if again != ctx {
+			t.Fatalf("traceparent %q does not round trip, got %q", ctx.String(), again.String())
+		}
+	})
+}
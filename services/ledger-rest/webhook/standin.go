@@ -0,0 +1,126 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Delivery represents delivery received by stand-in
+type Delivery struct {
+	Tenant    string
+	Webhook   string
+	Event     string
+	Payload   []byte
+	Signature string
+}
+
+// StandIn is local http receiver standing in for webhook targets in test mode
+type StandIn struct {
+	server     *http.Server
+	listener   net.Listener
+	mutex      sync.Mutex
+	deliveries []Delivery
+	failures   int
+}
+
+// NewStandIn returns stand-in listening on random local port
+func NewStandIn() (*StandIn, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	result := &StandIn{
+		listener:   listener,
+		deliveries: make([]Delivery, 0),
+	}
+	result.server = &http.Server{
+		Handler: http.HandlerFunc(result.receive),
+	}
+	go result.server.Serve(listener)
+	return result, nil
+}
+
+// URL returns address of stand-in
+func (standIn *StandIn) URL() string {
+	if standIn == nil {
+		return ""
+	}
+	return "http://" + standIn.listener.Addr().String()
+}
+
+// FailNext makes stand-in respond with error to next n deliveries
+func (standIn *StandIn) FailNext(n int) {
+	if standIn == nil {
+		return
+	}
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+	standIn.failures = n
+}
+
+// Deliveries returns deliveries received so far
+func (standIn *StandIn) Deliveries() []Delivery {
+	if standIn == nil {
+		return nil
+	}
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+	result := make([]Delivery, len(standIn.deliveries))
+	copy(result, standIn.deliveries)
+	return result
+}
+
+// Close stops stand-in
+func (standIn *StandIn) Close() {
+	if standIn == nil {
+		return
+	}
+	standIn.server.Close()
+}
+
+func (standIn *StandIn) receive(w http.ResponseWriter, r *http.Request) {
+	payload, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+
+	if standIn.failures > 0 {
+		standIn.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	delivery := Delivery{
+		Tenant:    r.Header.Get(TenantHeader),
+		Webhook:   r.Header.Get(WebhookHeader),
+		Event:     r.Header.Get(EventHeader),
+		Payload:   payload,
+		Signature: r.Header.Get(SignatureHeader),
+	}
+	standIn.deliveries = append(standIn.deliveries, delivery)
+
+	log.Info().Msgf("Stand-in received %s event for webhook %s/%s", delivery.Event, delivery.Tenant, delivery.Webhook)
+
+	w.WriteHeader(http.StatusOK)
+}
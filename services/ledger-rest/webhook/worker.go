@@ -0,0 +1,356 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+const (
+	// TenantHeader is name of header carrying tenant of delivery
+	TenantHeader = "X-Ledger-Tenant"
+	// WebhookHeader is name of header carrying webhook id of delivery
+	WebhookHeader = "X-Ledger-Webhook"
+	// EventHeader is name of header carrying event name of delivery
+	EventHeader = "X-Ledger-Event"
+	// DeliveryHeader is name of header carrying event sequence of delivery
+	DeliveryHeader = "X-Ledger-Delivery"
+)
+
+const deliveryTimeout = 5 * time.Second
+const maxBackoff = 10 * time.Minute
+const maxDeliveriesPerWork = 100
+const maxConcurrentWebhooks = 16
+
+type failedDelivery struct {
+	sequence    uint64
+	attempts    int
+	nextAttempt time.Time
+}
+
+// DeliveryWorker delivers transaction events to webhooks of all tenants
+type DeliveryWorker struct {
+	storage     localfs.Storage
+	client      *http.Client
+	testMode    bool
+	standIn     *StandIn
+	maxAttempts int
+	backoff     time.Duration
+	mutex       sync.Mutex
+	failures    map[string]*failedDelivery
+	inFlight    map[string]bool
+	slots       chan struct{}
+	running     sync.WaitGroup
+}
+
+type payload struct {
+	Sequence    uint64             `json:"sequence"`
+	Tenant      string             `json:"tenant"`
+	Event       string             `json:"event"`
+	Timestamp   time.Time          `json:"timestamp"`
+	Transaction *model.Transaction `json:"transaction"`
+}
+
+// NewDeliveryWorker returns webhook delivery worker fascade
+func NewDeliveryWorker(rootStorage string, maxAttempts int, backoff time.Duration, testMode bool) *DeliveryWorker {
+	storage, err := localfs.NewPlaintextStorage(rootStorage)
+	if err != nil {
+		log.Error().Msgf("Failed to ensure storage %+v", err)
+		return nil
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &DeliveryWorker{
+		storage: storage,
+		client: &http.Client{
+			Timeout: deliveryTimeout,
+		},
+		testMode:    testMode,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		failures:    make(map[string]*failedDelivery),
+		inFlight:    make(map[string]bool),
+		slots:       make(chan struct{}, maxConcurrentWebhooks),
+	}
+}
+
+// StandIn returns local receiver used in test mode
+func (worker *DeliveryWorker) StandIn() *StandIn {
+	if worker == nil {
+		return nil
+	}
+	return worker.standIn
+}
+
+func (worker *DeliveryWorker) backoffFor(attempts int) time.Duration {
+	result := worker.backoff
+	for i := 1; i < attempts && result < maxBackoff; i++ {
+		result *= 2
+	}
+	if result > maxBackoff {
+		return maxBackoff
+	}
+	return result
+}
+
+func (worker *DeliveryWorker) getTenants() []string {
+	items, err := worker.storage.ListDirectory("", true)
+	if err != nil {
+		return nil
+	}
+	result := make([]string, 0)
+	for _, item := range items {
+		if strings.HasPrefix(item, "t_") {
+			result = append(result, item[2:])
+		}
+	}
+	return result
+}
+
+func (worker *DeliveryWorker) deliver(tenant string, webhook *model.Webhook, event *model.Event) ([]byte, error) {
+	transaction, err := persistence.LoadTransaction(worker.storage, tenant, event.IDTransaction)
+	if err != nil {
+		return nil, err
+	}
+	if transaction != nil {
+		transaction.Status = event.Status
+	}
+
+	data, err := json.Marshal(payload{
+		Sequence:    event.Sequence,
+		Tenant:      tenant,
+		Event:       event.Name(),
+		Timestamp:   event.Timestamp,
+		Transaction: transaction,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	target := webhook.URL
+	if worker.testMode {
+		target = worker.standIn.URL()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return data, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TenantHeader, tenant)
+	req.Header.Set(WebhookHeader, webhook.ID)
+	req.Header.Set(EventHeader, event.Name())
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(event.Sequence, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, data))
+
+	resp, err := worker.client.Do(req)
+	if err != nil {
+		return data, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return data, fmt.Errorf("target responded with status %d", resp.StatusCode)
+	}
+	return data, nil
+}
+
+func (worker *DeliveryWorker) failureOf(key string) (*failedDelivery, bool) {
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+	failure, ok := worker.failures[key]
+	return failure, ok
+}
+
+func (worker *DeliveryWorker) setFailure(key string, failure *failedDelivery) {
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+	if failure == nil {
+		delete(worker.failures, key)
+	} else {
+		worker.failures[key] = failure
+	}
+}
+
+func (worker *DeliveryWorker) processWebhook(tenant string, id string) {
+	key := tenant + "/" + id
+
+	failure, retrying := worker.failureOf(key)
+	if retrying && time.Now().Before(failure.nextAttempt) {
+		return
+	}
+
+	webhook, err := persistence.LoadWebhook(worker.storage, tenant, id)
+	if err != nil || webhook == nil {
+		worker.setFailure(key, nil)
+		return
+	}
+
+	cursor, err := persistence.LoadWebhookCursor(worker.storage, tenant, id)
+	if err != nil {
+		log.Warn().Msgf("Unable to load cursor of webhook %s %+v", key, err)
+		return
+	}
+
+	for i := 0; i < maxDeliveriesPerWork; i++ {
+		event, err := persistence.LoadEvent(worker.storage, tenant, cursor+1)
+		if err == persistence.ErrMalformedEvent {
+			log.Warn().Msgf("Event %d of tenant %s is malformed, dead-lettering for webhook %s", cursor+1, tenant, key)
+			if err := persistence.CreateWebhookDeadLetter(worker.storage, tenant, id, cursor+1, nil, err.Error()); err != nil {
+				log.Error().Msgf("Unable to dead-letter delivery %d of webhook %s %+v", cursor+1, key, err)
+				return
+			}
+			cursor++
+			if err := persistence.UpdateWebhookCursor(worker.storage, tenant, id, cursor); err != nil {
+				log.Error().Msgf("Unable to update cursor of webhook %s %+v", key, err)
+				return
+			}
+			continue
+		}
+		if err != nil {
+			log.Warn().Msgf("Unable to load event %d of tenant %s %+v", cursor+1, tenant, err)
+			return
+		}
+		if event == nil {
+			return
+		}
+
+		if webhook.IsSubscribedTo(event.Name()) {
+			data, err := worker.deliver(tenant, webhook, event)
+			if err != nil {
+				if !retrying || failure.sequence != event.Sequence {
+					failure = &failedDelivery{
+						sequence: event.Sequence,
+					}
+					worker.setFailure(key, failure)
+					retrying = true
+				}
+				failure.attempts++
+				if failure.attempts < worker.maxAttempts {
+					failure.nextAttempt = time.Now().Add(worker.backoffFor(failure.attempts))
+					log.Warn().Msgf("Delivery %d of webhook %s failed %d times, retrying in %v because %+v", event.Sequence, key, failure.attempts, worker.backoffFor(failure.attempts), err)
+					return
+				}
+				log.Warn().Msgf("Delivery %d of webhook %s failed %d times, dead-lettering because %+v", event.Sequence, key, failure.attempts, err)
+				if err := persistence.CreateWebhookDeadLetter(worker.storage, tenant, id, event.Sequence, data, err.Error()); err != nil {
+					log.Error().Msgf("Unable to dead-letter delivery %d of webhook %s %+v", event.Sequence, key, err)
+					return
+				}
+			}
+			worker.setFailure(key, nil)
+			retrying = false
+		}
+
+		cursor = event.Sequence
+		if err := persistence.UpdateWebhookCursor(worker.storage, tenant, id, cursor); err != nil {
+			log.Error().Msgf("Unable to update cursor of webhook %s %+v", key, err)
+			return
+		}
+	}
+}
+
+// startWebhook delivers pending events to webhook in background, webhook
+// which deliveries are still in flight is skipped and false is returned when
+// all delivery slots are taken
+func (worker *DeliveryWorker) startWebhook(tenant string, id string) bool {
+	key := tenant + "/" + id
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+	if worker.inFlight[key] {
+		return true
+	}
+	select {
+	case worker.slots <- struct{}{}:
+	default:
+		return false
+	}
+	worker.inFlight[key] = true
+	worker.running.Add(1)
+	go func() {
+		defer func() {
+			worker.mutex.Lock()
+			delete(worker.inFlight, key)
+			worker.mutex.Unlock()
+			<-worker.slots
+			worker.running.Done()
+		}()
+		worker.processWebhook(tenant, id)
+	}()
+	return true
+}
+
+// Setup starts local stand-in in test mode
+func (worker *DeliveryWorker) Setup() error {
+	if worker == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	if !worker.testMode {
+		return nil
+	}
+	standIn, err := NewStandIn()
+	if err != nil {
+		return err
+	}
+	worker.standIn = standIn
+	log.Warn().Msgf("Webhook test mode, delivering to stand-in %s", standIn.URL())
+	return nil
+}
+
+// Done always returns done
+func (worker *DeliveryWorker) Done() <-chan interface{} {
+	done := make(chan interface{})
+	close(done)
+	return done
+}
+
+// Cancel waits for deliveries in flight and stops local stand-in
+func (worker *DeliveryWorker) Cancel() {
+	if worker == nil {
+		return
+	}
+	worker.running.Wait()
+	worker.standIn.Close()
+}
+
+// Work starts delivery of pending events to webhooks of all tenants, each
+// webhook is delivered to concurrently with others in bounded pool
+func (worker *DeliveryWorker) Work() {
+	if worker == nil {
+		return
+	}
+	for _, tenant := range worker.getTenants() {
+		webhooks, err := persistence.LoadWebhooksIDs(worker.storage, tenant)
+		if err != nil {
+			continue
+		}
+		for _, id := range webhooks {
+			if !worker.startWebhook(tenant, id) {
+				return
+			}
+		}
+	}
+}
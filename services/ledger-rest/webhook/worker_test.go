@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-rest/model"
+	"github.com/jancajthaml-openbank/ledger-rest/persistence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignature(t *testing.T) {
+	signature := Sign("secret", []byte("payload"))
+
+	assert.Equal(t, "sha256=b82fcb791acec57859b989b430a826488ce2e479fdf92326bd0a2e8375a42ba4", signature)
+	assert.True(t, Verify("secret", []byte("payload"), signature))
+	assert.False(t, Verify("other", []byte("payload"), signature))
+	assert.False(t, Verify("secret", []byte("tampered"), signature))
+}
+
+func TestDeliveryWorker(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_webhook")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	worker := NewDeliveryWorker(tmpdir, 2, time.Nanosecond, true)
+	require.NotNil(t, worker)
+	require.Nil(t, worker.Setup())
+	defer worker.Cancel()
+
+	storage := worker.storage
+
+	storage.WriteFile("t_x/transaction/a", []byte("committed\nt1 x A x B 2020-01-01T00:00:00Z 1 EUR\n"))
+	storage.WriteFile("t_x/event/00000000000000000001", []byte("a new 2020-01-01T00:00:00Z"))
+
+	webhook := &model.Webhook{
+		ID:     "w",
+		URL:    "https://example.com/hook",
+		Secret: "secret",
+		Events: []string{"committed", "rollbacked"},
+	}
+	require.Nil(t, persistence.CreateWebhook(storage, "x", webhook))
+
+	t.Log("skips events before subscription")
+	{
+		storage.WriteFile("t_x/event/00000000000000000002", []byte("a accepted 2020-01-01T00:00:01Z"))
+		storage.WriteFile("t_x/event/00000000000000000003", []byte("a committed 2020-01-01T00:00:02Z"))
+
+		worker.Work()
+		worker.running.Wait()
+
+		deliveries := worker.StandIn().Deliveries()
+		require.Equal(t, 1, len(deliveries))
+		assert.Equal(t, "x", deliveries[0].Tenant)
+		assert.Equal(t, "w", deliveries[0].Webhook)
+		assert.Equal(t, "committed", deliveries[0].Event)
+		assert.True(t, Verify("secret", deliveries[0].Payload, deliveries[0].Signature))
+
+		cursor, err := persistence.LoadWebhookCursor(storage, "x", "w")
+		require.Nil(t, err)
+		assert.Equal(t, uint64(3), cursor)
+	}
+
+	t.Log("retries and dead-letters failed delivery")
+	{
+		storage.WriteFile("t_x/event/00000000000000000004", []byte("a rollbacked 2020-01-01T00:00:03Z"))
+		worker.StandIn().FailNext(2)
+
+		worker.Work()
+		worker.running.Wait()
+
+		cursor, err := persistence.LoadWebhookCursor(storage, "x", "w")
+		require.Nil(t, err)
+		assert.Equal(t, uint64(3), cursor)
+
+		time.Sleep(time.Millisecond)
+		worker.Work()
+		worker.running.Wait()
+
+		cursor, err = persistence.LoadWebhookCursor(storage, "x", "w")
+		require.Nil(t, err)
+		assert.Equal(t, uint64(4), cursor)
+
+		deadLetters, err := persistence.LoadWebhookDeadLettersIDs(storage, "x", "w")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"00000000000000000004"}, deadLetters)
+		assert.Equal(t, 1, len(worker.StandIn().Deliveries()))
+	}
+}
+
+func TestDeliveryWorkerSkipsMalformedEvent(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_webhook")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	worker := NewDeliveryWorker(tmpdir, 1, time.Nanosecond, true)
+	require.NotNil(t, worker)
+	require.Nil(t, worker.Setup())
+	defer worker.Cancel()
+
+	storage := worker.storage
+
+	storage.WriteFile("t_x/transaction/a", []byte("committed\nt1 x A x B 2020-01-01T00:00:00Z 1 EUR\n"))
+	require.Nil(t, persistence.CreateWebhook(storage, "x", &model.Webhook{
+		ID:     "w",
+		URL:    "https://example.com/hook",
+		Secret: "secret",
+		Events: []string{"committed"},
+	}))
+
+	storage.WriteFile("t_x/event/00000000000000000001", []byte("garbage"))
+	storage.WriteFile("t_x/event/00000000000000000002", []byte("a committed 2020-01-01T00:00:02Z"))
+
+	worker.Work()
+	worker.running.Wait()
+
+	cursor, err := persistence.LoadWebhookCursor(storage, "x", "w")
+	require.Nil(t, err)
+	assert.Equal(t, uint64(2), cursor)
+
+	deadLetters, err := persistence.LoadWebhookDeadLettersIDs(storage, "x", "w")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"00000000000000000001"}, deadLetters)
+
+	deliveries := worker.StandIn().Deliveries()
+	require.Equal(t, 1, len(deliveries))
+	assert.Equal(t, "committed", deliveries[0].Event)
+}
+
+func TestDeliveryWorkerDeliversWebhooksConcurrently(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "test_webhook")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	worker := NewDeliveryWorker(tmpdir, 1, time.Nanosecond, true)
+	require.NotNil(t, worker)
+	require.Nil(t, worker.Setup())
+	defer worker.Cancel()
+
+	storage := worker.storage
+
+	storage.WriteFile("t_x/transaction/a", []byte("committed\nt1 x A x B 2020-01-01T00:00:00Z 1 EUR\n"))
+	for _, id := range []string{"u", "v", "w"} {
+		require.Nil(t, persistence.CreateWebhook(storage, "x", &model.Webhook{
+			ID:     id,
+			URL:    "https://example.com/" + id,
+			Secret: "secret",
+			Events: []string{"committed"},
+		}))
+	}
+
+	storage.WriteFile("t_x/event/00000000000000000001", []byte("a committed 2020-01-01T00:00:02Z"))
+
+	t.Log("webhook in flight is not delivered to twice")
+	{
+		worker.mutex.Lock()
+		worker.inFlight["x/u"] = true
+		worker.mutex.Unlock()
+
+		worker.Work()
+		worker.running.Wait()
+
+		assert.Equal(t, 2, len(worker.StandIn().Deliveries()))
+		cursor, err := persistence.LoadWebhookCursor(storage, "x", "u")
+		require.Nil(t, err)
+		assert.Equal(t, uint64(0), cursor)
+	}
+
+	t.Log("webhook is delivered to once it is no longer in flight")
+	{
+		worker.mutex.Lock()
+		delete(worker.inFlight, "x/u")
+		worker.mutex.Unlock()
+
+		worker.Work()
+		worker.running.Wait()
+
+		assert.Equal(t, 3, len(worker.StandIn().Deliveries()))
+		cursor, err := persistence.LoadWebhookCursor(storage, "x", "u")
+		require.Nil(t, err)
+		assert.Equal(t, uint64(1), cursor)
+	}
+
+	t.Log("no webhook is started while all delivery slots are taken")
+	{
+		for i := 0; i < maxConcurrentWebhooks; i++ {
+			worker.slots <- struct{}{}
+		}
+		storage.WriteFile("t_x/event/00000000000000000002", []byte("a committed 2020-01-01T00:00:03Z"))
+
+		worker.Work()
+		worker.running.Wait()
+
+		assert.Equal(t, 3, len(worker.StandIn().Deliveries()))
+
+		for i := 0; i < maxConcurrentWebhooks; i++ {
+			<-worker.slots
+		}
+	}
+}
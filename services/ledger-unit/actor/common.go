@@ -15,32 +15,26 @@
 package actor
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/tracing"
 
 	system "github.com/jancajthaml-openbank/actor-system"
 	money "gopkg.in/inf.v0"
 )
 
 func parseTransfer(chunk string) (*model.Transfer, error) {
-	start := 0
-	end := len(chunk)
-	parts := make([]string, 8)
-	idx := 0
-	i := 0
-	for i < end && idx < 8 {
-		if chunk[i] == 59 {
-			if !(start == i && chunk[start] == 59) {
-				parts[idx] = chunk[start:i]
-				idx++
-			}
-			start = i + 1
-		}
-		i++
+	parts := strings.Split(chunk, ";")
+	if len(parts) != 8 && len(parts) != 9 {
+		return nil, fmt.Errorf("invalid transfer %s", chunk)
 	}
-	if idx < 8 && chunk[start] != 59 && len(chunk[start:]) > 0 {
-		parts[idx] = chunk[start:]
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid transfer %s", chunk)
+		}
 	}
 
 	amount, ok := new(money.Dec).SetString(parts[5])
@@ -48,6 +42,13 @@ func parseTransfer(chunk string) (*model.Transfer, error) {
 		return nil, fmt.Errorf("invalid amount %s", parts[5])
 	}
 
+	var metadata model.Metadata
+	if len(parts) == 9 {
+		if err := metadata.Deserialize(parts[8]); err != nil {
+			return nil, fmt.Errorf("invalid metadata %s", parts[8])
+		}
+	}
+
 	return &model.Transfer{
 		IDTransfer: parts[0],
 		Credit: model.Account{
@@ -61,16 +62,28 @@ func parseTransfer(chunk string) (*model.Transfer, error) {
 		ValueDate: parts[7],
 		Amount:    amount,
 		Currency:  parts[6],
+		Metadata:  metadata,
+	}, nil
+}
+
+// vaultOf returns account of vault that sent message
+func vaultOf(from system.Coordinates) (model.Account, error) {
+	if !strings.HasPrefix(from.Region, "VaultUnit/") || len(from.Region) == 10 || from.Name == "" {
+		return model.Account{}, fmt.Errorf("invalid vault %v", from)
+	}
+	return model.Account{
+		Tenant: from.Region[10:],
+		Name:   from.Name,
 	}, nil
 }
 
 func parseMessage(msg string, from system.Coordinates) (interface{}, error) {
 	start := 0
 	end := len(msg)
-	parts := make([]string, 40)
+	parts := make([]string, 42)
 	idx := 0
 	i := 0
-	for i < end && idx < 40 {
+	for i < end && idx < 42 {
 		if msg[i] == 32 {
 			if !(start == i && msg[start] == 32) {
 				parts[idx] = msg[start:i]
@@ -80,7 +93,7 @@ func parseMessage(msg string, from system.Coordinates) (interface{}, error) {
 		}
 		i++
 	}
-	if idx < 40 && msg[start] != 32 && len(msg[start:]) > 0 {
+	if idx < 42 && start < end && msg[start] != 32 {
 		parts[idx] = msg[start:]
 		idx++
 	}
@@ -89,88 +102,127 @@ func parseMessage(msg string, from system.Coordinates) (interface{}, error) {
 		return nil, fmt.Errorf("message too large")
 	}
 
+	if idx == 0 {
+		return nil, fmt.Errorf("empty message")
+	}
+
 	switch parts[0] {
 
 	case ReqCreateTransaction:
+		trace := tracing.SpanContext{}
+		if idx > 3 && strings.IndexByte(parts[idx-1], 59) == -1 {
+			var err error
+			if trace, err = tracing.ParseTraceparent(parts[idx-1]); err != nil {
+				return nil, fmt.Errorf("invalid traceparent in message %s", msg)
+			}
+			idx--
+		}
 		if idx > 2 {
 			transaction := model.Transaction{
 				IDTransaction: parts[1],
 			}
-			for _, part := range parts[2:idx] {
+			transfers := parts[2:idx]
+			if strings.IndexByte(transfers[0], 59) == -1 {
+				if err := transaction.Metadata.Deserialize(transfers[0]); err != nil {
+					return nil, fmt.Errorf("invalid metadata in message %s", msg)
+				}
+				transfers = transfers[1:]
+			}
+			if len(transfers) == 0 {
+				return nil, fmt.Errorf("invalid message %s", msg)
+			}
+			for _, part := range transfers {
 				transfer, err := parseTransfer(part)
 				if err != nil {
 					return nil, fmt.Errorf("invalid transfer in message %s", msg)
 				}
 				transaction.Transfers = append(transaction.Transfers, *transfer)
 			}
-			return transaction, nil
+			return CreateTransaction{
+				Transaction: transaction,
+				Trace:       trace,
+			}, nil
+		}
+		return nil, fmt.Errorf("invalid message %s", msg)
+
+	case ReqListSagas:
+		if idx == 1 {
+			return ListSagas{}, nil
 		}
 		return nil, fmt.Errorf("invalid message %s", msg)
 
+	case ReqAbortSaga:
+		if idx == 2 {
+			return AbortSaga{
+				IDTransaction: parts[1],
+			}, nil
+		}
+		return nil, fmt.Errorf("invalid message %s", msg)
+
+	default:
+		return parseVaultReply(parts[:idx], msg, from)
+
+	}
+
+}
+
+// parseVaultReply parses reply of vault to negotiation order
+func parseVaultReply(parts []string, msg string, from system.Coordinates) (interface{}, error) {
+	account, err := vaultOf(from)
+	if err != nil {
+		return nil, fmt.Errorf("%s of message %s", err, msg)
+	}
+
+	switch parts[0] {
+
 	case FatalError:
 		return FatalErrored{
-			Account: model.Account{
-				Tenant: from.Region[10:],
-				Name:   from.Name,
-			},
+			Account: account,
 		}, nil
 
 	case PromiseAccepted:
 		return PromiseWasAccepted{
-			Account: model.Account{
-				Tenant: from.Region[10:],
-				Name:   from.Name,
-			},
+			Account: account,
+		}, nil
+
+	case PromiseBounced:
+		return PromiseWasBounced{
+			Account: account,
 		}, nil
 
 	case PromiseRejected:
-		if idx == 2 {
+		if len(parts) == 2 {
 			return PromiseWasRejected{
-				Account: model.Account{
-					Tenant: from.Region[10:],
-					Name:   from.Name,
-				},
-				Reason: parts[1],
+				Account: account,
+				Reason:  parts[1],
 			}, nil
 		}
 		return nil, fmt.Errorf("invalid message %s", msg)
 
 	case CommitAccepted:
 		return CommitWasAccepted{
-			Account: model.Account{
-				Tenant: from.Region[10:],
-				Name:   from.Name,
-			},
+			Account: account,
 		}, nil
 
 	case CommitRejected:
-		if idx == 2 {
+		if len(parts) == 2 {
 			return CommitWasRejected{
-				Account: model.Account{
-					Tenant: from.Region[10:],
-					Name:   from.Name,
-				},
-				Reason: parts[1],
+				Account: account,
+				Reason:  parts[1],
 			}, nil
 		}
 		return nil, fmt.Errorf("invalid message %s", msg)
 
 	case RollbackAccepted:
 		return RollbackWasAccepted{
-			Account: model.Account{
-				Tenant: from.Region[10:],
-				Name:   from.Name,
-			},
+			Account: account,
 		}, nil
 
 	case RollbackRejected:
-		if idx == 2 {
+		if len(parts) == 2 {
 			return RollbackWasRejected{
-				Account: model.Account{
-					Tenant: from.Region[10:],
-					Name:   from.Name,
-				},
-				Reason: parts[1],
+				Account: account,
+				Reason:  parts[1],
 			}, nil
 		}
 		return nil, fmt.Errorf("invalid message %s", msg)
@@ -179,7 +231,6 @@ func parseMessage(msg string, from system.Coordinates) (interface{}, error) {
 		return nil, fmt.Errorf("invalid message %s", msg)
 
 	}
-
 }
 
 // ProcessMessage processing of remote message to this wall
@@ -192,8 +243,27 @@ func ProcessMessage(s *System) system.ProcessMessage {
 			return
 		}
 		var ref *system.Actor
-		switch message.(type) {
-		case model.Transaction:
+		switch request := message.(type) {
+		case ListSagas:
+			chunk, err := json.Marshal(s.Sagas.List())
+			if err != nil {
+				log.Warn().Msgf("Failed to list sagas %+v", err)
+				s.SendMessage(FatalError, from, to)
+				return
+			}
+			s.SendMessage(RespListSagas+" "+string(chunk), from, to)
+			return
+		case AbortSaga:
+			name, ok := s.Sagas.Find(request.IDTransaction)
+			if !ok {
+				s.SendMessage(RespSagaMissing+" "+request.IDTransaction, from, to)
+				return
+			}
+			if ref, err = s.ActorOf(name); err != nil {
+				s.SendMessage(RespSagaMissing+" "+request.IDTransaction, from, to)
+				return
+			}
+		case CreateTransaction:
 			if ref, err = NewTransactionActor(s, to.Name); err != nil {
 				log.Warn().Msgf("%s [remote %v -> local %v]", err, from, to)
 				s.SendMessage(FatalError, from, to)
@@ -221,3 +291,32 @@ func NewTransactionActor(s *System, name string) (*system.Actor, error) {
 	log.Debug().Msgf("Actor %s registered", name)
 	return envelope, nil
 }
+
+// ResumeTransaction spawns actor recovering unfinished transaction, nothing is
+// spawned when saga of transaction is still in flight
+func ResumeTransaction(s *System, transaction model.Transaction) {
+	if _, ok := s.Sagas.Find(transaction.IDTransaction); ok {
+		return
+	}
+	name := "recovery/" + transaction.IDTransaction
+	if _, err := s.ActorOf(name); err == nil {
+		return
+	}
+	ref, err := NewTransactionActor(s, name)
+	if err != nil {
+		return
+	}
+	ref.Tell(
+		RecoverTransaction{
+			Transaction: transaction,
+		},
+		system.Coordinates{
+			Region: s.Name,
+			Name:   name,
+		},
+		system.Coordinates{
+			Region: s.Name,
+			Name:   "transaction_finalizer_cron",
+		},
+	)
+}
@@ -0,0 +1,238 @@
+package actor
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+
+	system "github.com/jancajthaml-openbank/actor-system"
+	money "gopkg.in/inf.v0"
+)
+
+func TestParseCreateTransaction(t *testing.T) {
+	from := system.Coordinates{Region: "LedgerRest", Name: "transaction/1"}
+	transfer := "1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z"
+
+	t.Log("without traceparent")
+	{
+		message, err := parseMessage("NT xyz "+transfer, from)
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		request, ok := message.(CreateTransaction)
+		if !ok {
+			t.Fatalf("expected CreateTransaction got %T", message)
+		}
+		if request.Transaction.IDTransaction != "xyz" || len(request.Transaction.Transfers) != 1 {
+			t.Errorf("unexpected transaction %+v", request.Transaction)
+		}
+		if request.Trace.IsValid() {
+			t.Errorf("expected no trace got %s", request.Trace.String())
+		}
+	}
+
+	t.Log("with traceparent")
+	{
+		traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		message, err := parseMessage("NT xyz "+transfer+" "+transfer+" "+traceparent, from)
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		request := message.(CreateTransaction)
+		if len(request.Transaction.Transfers) != 2 {
+			t.Errorf("expected 2 transfers got %d", len(request.Transaction.Transfers))
+		}
+		if request.Trace.String() != traceparent {
+			t.Errorf("expected trace %s got %s", traceparent, request.Trace.String())
+		}
+	}
+
+	t.Log("with metadata")
+	{
+		traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		message, err := parseMessage("NT xyz d=payroll%20march&t.batch=7 "+transfer+";e=E2E%3B1 "+traceparent, from)
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		request := message.(CreateTransaction)
+		if request.Transaction.Metadata.Description != "payroll march" || request.Transaction.Metadata.Tags["batch"] != "7" {
+			t.Errorf("unexpected transaction metadata %+v", request.Transaction.Metadata)
+		}
+		if len(request.Transaction.Transfers) != 1 || request.Transaction.Transfers[0].Metadata.EndToEndID != "E2E;1" {
+			t.Errorf("unexpected transfers %+v", request.Transaction.Transfers)
+		}
+		if request.Trace.String() != traceparent {
+			t.Errorf("expected trace %s got %s", traceparent, request.Trace.String())
+		}
+	}
+
+	t.Log("with invalid metadata")
+	{
+		for _, msg := range []string{
+			"NT xyz d=a",
+			"NT xyz x=a " + transfer,
+			"NT xyz " + transfer + ";x=a",
+			"NT xyz " + transfer + ";d=a;d=b",
+		} {
+			if _, err := parseMessage(msg, from); err == nil {
+				t.Errorf("expected error parsing %s", msg)
+			}
+		}
+	}
+
+	t.Log("with invalid traceparent")
+	{
+		if _, err := parseMessage("NT xyz "+transfer+" 00-xyz", from); err == nil {
+			t.Errorf("expected error")
+		}
+	}
+}
+
+// vaultOrderFormat is format of vault order accepted by vault unit, trailing
+// traceparent is present only when client of ledger-rest sent one
+var vaultOrderFormat = regexp.MustCompile(`^(NP|NC|NR) [^\s]{1,100} (-?\d{1,100}\.\d{1,100}|-?\d{1,100}) ([A-Z]{3})( 00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2})?$`)
+
+// restMessages returns create transaction messages recorded by ledger-rest
+func restMessages(t *testing.T) []string {
+	data, err := ioutil.ReadFile("../../ledger-rest/api/testdata/create_transaction_messages")
+	if os.IsNotExist(err) {
+		t.Skip("ledger-rest messages are not available")
+	}
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestVaultOrdersOfRestMessages(t *testing.T) {
+	from := system.Coordinates{Region: "LedgerRest", Name: "transaction/1"}
+
+	for _, data := range restMessages(t) {
+		message, err := parseMessage(data, from)
+		if err != nil {
+			t.Fatalf("unable to parse %q %+v", data, err)
+		}
+		request := message.(CreateTransaction)
+
+		state := NewTransactionState()
+		state.PrepareNewForTransaction(request.Transaction, request.Trace, from)
+
+		for _, order := range []string{PromiseOrder, CommitOrder, RollbackOrder} {
+			for _, task := range state.Negotiation {
+				actual := vaultOrder(order, task, state)
+				fields := vaultOrderFormat.FindStringSubmatch(actual)
+				if fields == nil {
+					t.Errorf("order %q of %q is not accepted by vault", actual, data)
+					continue
+				}
+				traced := fields[4] != ""
+				if traced != request.Trace.IsValid() {
+					t.Errorf("order %q of %q expected to carry trace only when requested with one", actual, data)
+				}
+				if traced && !strings.Contains(actual, " 00-"+request.Trace.TraceIDString()+"-") {
+					t.Errorf("order %q of %q does not continue trace of request", actual, data)
+				}
+			}
+		}
+	}
+}
+
+func TestParsePromiseBounced(t *testing.T) {
+	from := system.Coordinates{Region: "VaultUnit/two", Name: "B"}
+
+	message, err := parseMessage("P3", from)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	bounced, ok := message.(PromiseWasBounced)
+	if !ok {
+		t.Fatalf("expected PromiseWasBounced got %T", message)
+	}
+	if bounced.Account.Tenant != "two" || bounced.Account.Name != "B" {
+		t.Errorf("unexpected account %+v", bounced.Account)
+	}
+}
+
+func TestParseMalformedMessage(t *testing.T) {
+	vault := system.Coordinates{Region: "VaultUnit/two", Name: "B"}
+	rest := system.Coordinates{Region: "LedgerRest", Name: "transaction/1"}
+
+	for _, msg := range []string{
+		"",
+		" ",
+		"   ",
+		"XX",
+		"NT",
+		"NT xyz",
+		"NT xyz ;",
+		"NT xyz ;;;;;;;",
+		"NT xyz 1;one;A;one;B;1.0;EUR",
+		"NT xyz 1;one;A;one;B;1.0;EUR;",
+		"NT xyz 1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z;x",
+		"NT xyz 1;one;A;;B;1.0;EUR;2020-01-01T00:00:00Z",
+		"NT xyz 1;one;A;one;B;abc;EUR;2020-01-01T00:00:00Z",
+		"SL x",
+		"SA",
+		"P2",
+		"C2 a b",
+		"R2",
+	} {
+		if _, err := parseMessage(msg, vault); err == nil {
+			t.Errorf("expected error parsing %q", msg)
+		}
+	}
+
+	for _, from := range []system.Coordinates{
+		rest,
+		{},
+		{Region: "Vault", Name: "B"},
+		{Region: "VaultUnit/", Name: "B"},
+		{Region: "VaultUnit/two"},
+	} {
+		for _, msg := range []string{"P1", "P2 reason", "P3", "C1", "C2 reason", "R1", "R2 reason", "EE"} {
+			if _, err := parseMessage(msg, from); err == nil {
+				t.Errorf("expected error parsing %q from %v", msg, from)
+			}
+		}
+	}
+}
+
+func TestParseTransferRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	token := func() string {
+		alphabet := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-:."
+		result := make([]byte, 1+random.Intn(16))
+		for i := range result {
+			result[i] = alphabet[random.Intn(len(alphabet))]
+		}
+		return string(result)
+	}
+
+	for i := 0; i < 1000; i++ {
+		expected := model.Transfer{
+			IDTransfer: token(),
+			Credit:     model.Account{Tenant: token(), Name: token()},
+			Debit:      model.Account{Tenant: token(), Name: token()},
+			ValueDate:  token(),
+			Amount:     money.NewDec(random.Int63()-random.Int63(), money.Scale(random.Intn(10))),
+			Currency:   token(),
+		}
+		actual, err := parseTransfer(encodeTransfer(expected))
+		if err != nil {
+			t.Fatalf("unexpected error %+v parsing %s", err, encodeTransfer(expected))
+		}
+		if actual.IDTransfer != expected.IDTransfer ||
+			actual.Credit != expected.Credit ||
+			actual.Debit != expected.Debit ||
+			actual.ValueDate != expected.ValueDate ||
+			actual.Amount.Cmp(expected.Amount) != 0 ||
+			actual.Currency != expected.Currency {
+			t.Fatalf("expected %+v got %+v", expected, actual)
+		}
+	}
+}
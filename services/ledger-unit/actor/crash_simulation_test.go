@@ -0,0 +1,262 @@
+package actor
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+	"github.com/jancajthaml-openbank/ledger-unit/support/faults"
+
+	money "gopkg.in/inf.v0"
+)
+
+var simulationAccounts = []string{"a", "b", "c", "d", "e"}
+
+var simulationFaults = []string{
+	"fail-write@transaction/",
+	"partial-write@transaction/",
+	"fail-write@pending/",
+	"partial-write@pending/",
+	"drop-message@NP",
+	"drop-message@NC",
+	"drop-message@NR",
+	"abort@" + string(faults.AfterPromisePersisted),
+	"abort@" + string(faults.AfterAcceptedPersisted),
+	"abort@" + string(faults.AfterCommitSent),
+}
+
+// randomFaults returns spec of few random faults each firing with random
+// probability
+func randomFaults(random *rand.Rand) string {
+	rules := make([]string, 0)
+	for _, fault := range simulationFaults {
+		if random.Intn(3) == 0 {
+			rules = append(rules, fmt.Sprintf("%s:%.2f", fault, 0.05+random.Float64()*0.2))
+		}
+	}
+	return strings.Join(rules, ",")
+}
+
+// randomVault returns behaviour of vault accepting most orders
+func randomVault(random *rand.Rand, mutex *sync.Mutex) func(string) reaction {
+	return func(order string) reaction {
+		mutex.Lock()
+		dice := random.Intn(10)
+		delay := time.Duration(random.Intn(3)) * time.Millisecond
+		mutex.Unlock()
+		if order == PromiseOrder {
+			switch dice {
+			case 0:
+				return delayed(delay, reject("INSUFFICIENT_FUNDS"))
+			case 1:
+				return delayed(delay, bounce())
+			}
+		}
+		return delayed(delay, accept())
+	}
+}
+
+func randomTransaction(random *rand.Rand, id string) model.Transaction {
+	transaction := model.Transaction{
+		IDTransaction: id,
+		Transfers:     make([]model.Transfer, 0),
+	}
+	for i := 0; i < 1+random.Intn(3); i++ {
+		credit := random.Intn(len(simulationAccounts))
+		debit := (credit + 1 + random.Intn(len(simulationAccounts)-1)) % len(simulationAccounts)
+		transaction.Transfers = append(transaction.Transfers, transfer(
+			fmt.Sprintf("%s_%d", id, i),
+			"one/"+simulationAccounts[credit],
+			"one/"+simulationAccounts[debit],
+			int64(1+random.Intn(100)),
+		))
+	}
+	return transaction
+}
+
+// settle waits until no saga is in flight or timeout elapses
+func (lake *fakeLake) settle(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		sys := lake.current()
+		if len(sys.Sagas.List()) == 0 && len(lake.delivery) == 0 {
+			time.Sleep(5 * time.Millisecond)
+			if len(sys.Sagas.List()) == 0 && len(lake.delivery) == 0 {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// recover resumes every unfinished transaction in journal as finalizer of
+// restarted ledger-unit would
+func (lake *fakeLake) recover() {
+	sys := lake.current()
+	finalizer := NewTransactionFinalizer(lake.tmpdir+"/t_"+lake.tenant, 0, 0, func(transaction model.Transaction) {
+		ResumeTransaction(sys, transaction)
+	})
+	finalizer.finalizeStaleTransactions()
+}
+
+// replyOutcome returns journal state promised to requester by reply
+func replyOutcome(reply string) (string, string) {
+	parts := strings.Split(reply, " ")
+	if len(parts) < 2 {
+		return "", ""
+	}
+	switch parts[0] {
+	case RespCreateTransaction:
+		return parts[1], persistence.StatusCommitted
+	case RespTransactionRejected:
+		return parts[1], persistence.StatusRollbacked
+	default:
+		return "", ""
+	}
+}
+
+// checkConvergence asserts that journal and ledgers of vaults agree
+func (lake *fakeLake) checkConvergence(t *testing.T, acknowledged map[string]string) {
+	storage := lake.current().Storage
+
+	ids, err := storage.ListDirectory("transaction", true)
+	if err != nil {
+		t.Fatalf("unable to list journal %+v", err)
+	}
+
+	lake.mutex.Lock()
+	defer lake.mutex.Unlock()
+
+	journal := make(map[string]bool)
+	balance := new(money.Dec)
+
+	for _, id := range ids {
+		journal[id] = true
+		transaction, err := persistence.LoadTransaction(storage, id)
+		if err != nil {
+			// torn before any order was sent
+			for account, vault := range lake.vaults {
+				if _, ok := vault.promised[id]; ok {
+					t.Errorf("unreadable transaction %s is promised by %v", id, account)
+				}
+				if _, ok := vault.committed[id]; ok {
+					t.Errorf("unreadable transaction %s is committed by %v", id, account)
+				}
+			}
+			continue
+		}
+		if expected, ok := acknowledged[id]; ok && expected != transaction.State {
+			t.Errorf("transaction %s acknowledged as %s ended %s", id, expected, transaction.State)
+		}
+		negotiation := transaction.PrepareRemoteNegotiation()
+		switch transaction.State {
+		case persistence.StatusCommitted:
+			for account, task := range negotiation {
+				vault := lake.vaults[account]
+				if vault == nil || vault.committed[id] != task {
+					t.Errorf("committed transaction %s is not committed by %v", id, account)
+				}
+			}
+		case persistence.StatusRollbacked:
+			for account := range negotiation {
+				vault := lake.vaults[account]
+				if vault == nil {
+					continue
+				}
+				if _, ok := vault.committed[id]; ok {
+					t.Errorf("rollbacked transaction %s is committed by %v", id, account)
+				}
+			}
+		default:
+			t.Errorf("transaction %s did not finish, ended %s", id, transaction.State)
+		}
+	}
+
+	for account, vault := range lake.vaults {
+		for id := range vault.promised {
+			t.Errorf("promise of transaction %s held by %v", id, account)
+		}
+		for id, task := range vault.committed {
+			if !journal[id] {
+				t.Errorf("transaction %s committed by %v is not in journal", id, account)
+			}
+			amount, _ := new(money.Dec).SetString(strings.Split(task, " ")[1])
+			balance.Add(balance, amount)
+		}
+	}
+
+	if balance.Sign() != 0 {
+		t.Errorf("ledgers of vaults do not balance, sum is %s", balance.String())
+	}
+}
+
+func simulate(t *testing.T, seed int64) {
+	random := rand.New(rand.NewSource(seed))
+	mutex := new(sync.Mutex)
+
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	for _, name := range simulationAccounts {
+		lake.vault("one", name).choose = randomVault(random, mutex)
+	}
+
+	acknowledged := make(map[string]string)
+	collect := func() {
+		for {
+			select {
+			case reply := <-lake.replies:
+				if id, state := replyOutcome(reply); id != "" {
+					acknowledged[id] = state
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	sequence := 0
+	for round := 0; round < 4; round++ {
+		mutex.Lock()
+		spec := randomFaults(random)
+		injectorSeed := random.Int63()
+		mutex.Unlock()
+
+		lake.restart(lake.injector(spec, injectorSeed))
+		lake.recover()
+		lake.settle(200 * time.Millisecond)
+
+		for i := 0; i < 10; i++ {
+			sequence++
+			mutex.Lock()
+			transaction := randomTransaction(random, fmt.Sprintf("tx%03d", sequence))
+			mutex.Unlock()
+			lake.submitTransaction(transaction)
+		}
+		lake.settle(200 * time.Millisecond)
+		collect()
+	}
+
+	for round := 0; round < 2; round++ {
+		lake.restart(nil)
+		lake.recover()
+		lake.settle(time.Second)
+		collect()
+	}
+
+	lake.checkConvergence(t, acknowledged)
+}
+
+func TestCrashConsistencySimulation(t *testing.T) {
+	for seed := int64(1); seed <= 8; seed++ {
+		seed := seed
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			simulate(t, seed)
+		})
+	}
+}
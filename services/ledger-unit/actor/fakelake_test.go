@@ -0,0 +1,426 @@
+package actor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/metrics"
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+	"github.com/jancajthaml-openbank/ledger-unit/support/faults"
+
+	system "github.com/jancajthaml-openbank/actor-system"
+	money "gopkg.in/inf.v0"
+)
+
+// reaction is scripted reply of fake vault to single order
+type reaction struct {
+	reply string
+	delay time.Duration
+	crash bool
+}
+
+func accept() reaction {
+	return reaction{reply: "accept"}
+}
+
+func reject(reason string) reaction {
+	return reaction{reply: "reject " + reason}
+}
+
+func bounce() reaction {
+	return reaction{reply: "bounce"}
+}
+
+func fatal() reaction {
+	return reaction{reply: "fatal"}
+}
+
+func drop() reaction {
+	return reaction{}
+}
+
+// crash makes vault swallow order and every order after it
+func crash() reaction {
+	return reaction{crash: true}
+}
+
+func delayed(delay time.Duration, r reaction) reaction {
+	r.delay = delay
+	return r
+}
+
+// fakeVault replies to orders of single account following script, last
+// reaction of each order kind repeats once script is exhausted, accepted
+// orders are applied to its ledger of promises and commits
+type fakeVault struct {
+	script    map[string][]reaction
+	choose    func(order string) reaction
+	crashed   bool
+	promised  map[string]string
+	committed map[string]string
+}
+
+func (vault *fakeVault) on(order string, reactions ...reaction) *fakeVault {
+	vault.script[order] = append(vault.script[order], reactions...)
+	return vault
+}
+
+func (vault *fakeVault) onPromise(reactions ...reaction) *fakeVault {
+	return vault.on(PromiseOrder, reactions...)
+}
+
+func (vault *fakeVault) onCommit(reactions ...reaction) *fakeVault {
+	return vault.on(CommitOrder, reactions...)
+}
+
+func (vault *fakeVault) onRollback(reactions ...reaction) *fakeVault {
+	return vault.on(RollbackOrder, reactions...)
+}
+
+func (vault *fakeVault) next(order string) reaction {
+	if vault.choose != nil {
+		return vault.choose(order)
+	}
+	reactions := vault.script[order]
+	if len(reactions) == 0 {
+		return accept()
+	}
+	if len(reactions) > 1 {
+		vault.script[order] = reactions[1:]
+	}
+	return reactions[0]
+}
+
+// apply applies accepted order of transaction to ledger of vault, returns
+// reason of rejection when order cannot be applied
+func (vault *fakeVault) apply(order string, id string, task string) string {
+	switch order {
+	case PromiseOrder:
+		if _, ok := vault.committed[id]; !ok {
+			vault.promised[id] = task
+		}
+	case CommitOrder:
+		if _, ok := vault.committed[id]; ok {
+			return ""
+		}
+		if _, ok := vault.promised[id]; !ok {
+			return "MISSING_PROMISE"
+		}
+		delete(vault.promised, id)
+		vault.committed[id] = task
+	case RollbackOrder:
+		if _, ok := vault.committed[id]; ok {
+			return "ALREADY_COMMITTED"
+		}
+		delete(vault.promised, id)
+	}
+	return ""
+}
+
+var replyCodes = map[string]map[string]string{
+	PromiseOrder:  {"accept": PromiseAccepted, "reject": PromiseRejected, "bounce": PromiseBounced},
+	CommitOrder:   {"accept": CommitAccepted, "reject": CommitRejected},
+	RollbackOrder: {"accept": RollbackAccepted, "reject": RollbackRejected},
+}
+
+// fakeLake is in-memory lake connecting actor system of single tenant with
+// scripted vaults, it records every message leaving the actor system
+type fakeLake struct {
+	t        *testing.T
+	tenant   string
+	tmpdir   string
+	system   *System
+	mutex    sync.Mutex
+	vaults   map[model.Account]*fakeVault
+	journal  []string
+	replies  chan string
+	delivery chan func()
+	done     chan interface{}
+}
+
+func newFakeLake(t *testing.T, tenant string) *fakeLake {
+	tmpdir, err := ioutil.TempDir("", "fakelake")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	lake := &fakeLake{
+		t:        t,
+		tenant:   tenant,
+		tmpdir:   tmpdir,
+		vaults:   make(map[model.Account]*fakeVault),
+		journal:  make([]string, 0),
+		replies:  make(chan string, 1024),
+		delivery: make(chan func(), 1024),
+		done:     make(chan interface{}),
+	}
+	lake.restart(nil)
+	go lake.deliver()
+	return lake
+}
+
+// restart replaces actor system by fresh one over the same storage as if
+// ledger-unit process was restarted, messages of previous system are no
+// longer delivered
+func (lake *fakeLake) restart(injector *faults.Injector) *System {
+	sys := NewActorSystem(
+		lake.tenant,
+		"127.0.0.1",
+		lake.tmpdir+"/t_"+lake.tenant,
+		lake.tmpdir,
+		3,
+		time.Millisecond,
+		metrics.NewMetrics(lake.tenant, "127.0.0.1:8125", lake.tmpdir+"/t_"+lake.tenant, nil, nil),
+		nil,
+		nil,
+		injector,
+	)
+	if sys == nil {
+		lake.t.Fatalf("failed to create actor system")
+	}
+	sys.Transport = lake
+	lake.mutex.Lock()
+	lake.system = sys
+	lake.mutex.Unlock()
+	return sys
+}
+
+// current returns actor system currently connected to lake
+// injector returns fault injector of given spec
+func (lake *fakeLake) injector(spec string, seed int64) *faults.Injector {
+	lake.t.Helper()
+	injector, err := faults.NewInjector(spec, seed, nil)
+	if err != nil {
+		lake.t.Fatalf("unexpected error %+v", err)
+	}
+	return injector
+}
+
+func (lake *fakeLake) current() *System {
+	lake.mutex.Lock()
+	defer lake.mutex.Unlock()
+	return lake.system
+}
+
+func (lake *fakeLake) close() {
+	close(lake.done)
+	os.RemoveAll(lake.tmpdir)
+}
+
+// deliver hands replies of vaults to actor system one by one
+func (lake *fakeLake) deliver() {
+	for {
+		select {
+		case <-lake.done:
+			return
+		case fn := <-lake.delivery:
+			fn()
+		}
+	}
+}
+
+func (lake *fakeLake) vault(tenant string, name string) *fakeVault {
+	lake.mutex.Lock()
+	defer lake.mutex.Unlock()
+	account := model.Account{Tenant: tenant, Name: name}
+	if vault, ok := lake.vaults[account]; ok {
+		return vault
+	}
+	vault := &fakeVault{
+		script:    make(map[string][]reaction),
+		promised:  make(map[string]string),
+		committed: make(map[string]string),
+	}
+	lake.vaults[account] = vault
+	return vault
+}
+
+// Send implements Transport
+func (lake *fakeLake) Send(msg string, to system.Coordinates, from system.Coordinates) {
+	lake.mutex.Lock()
+	lake.journal = append(lake.journal, to.Region+" "+to.Name+" "+msg)
+	lake.mutex.Unlock()
+
+	if to.Region == "LedgerRest" {
+		select {
+		case lake.replies <- msg:
+		default:
+		}
+		return
+	}
+	if !strings.HasPrefix(to.Region, "VaultUnit/") {
+		return
+	}
+
+	// vault drops orders it does not understand
+	if !vaultOrderFormat.MatchString(msg) {
+		return
+	}
+
+	vault := lake.vault(to.Region[10:], to.Name)
+	parts := strings.Split(msg, " ")
+	order := parts[0]
+
+	lake.mutex.Lock()
+	if vault.crashed {
+		lake.mutex.Unlock()
+		return
+	}
+	r := vault.next(order)
+	if r.crash {
+		vault.crashed = true
+	}
+	reason := ""
+	if r.reply == "accept" && len(parts) >= 4 {
+		reason = vault.apply(order, parts[1], strings.Join(parts[1:4], " "))
+	}
+	lake.mutex.Unlock()
+
+	var reply string
+	switch {
+	case r.crash, r.reply == "":
+		return
+	case r.reply == "fatal":
+		reply = FatalError
+	case reason != "":
+		reply = replyCodes[order]["reject"] + " " + reason
+	default:
+		parts := strings.SplitN(r.reply, " ", 2)
+		reply = replyCodes[order][parts[0]]
+		if len(parts) == 2 {
+			reply += " " + parts[1]
+		}
+	}
+
+	respond := func() {
+		lake.delivery <- func() {
+			ProcessMessage(lake.current())(reply, from, to)
+		}
+	}
+	if r.delay > 0 {
+		time.AfterFunc(r.delay, respond)
+	} else {
+		respond()
+	}
+}
+
+// encodeTransfer encodes transfer as ledger-rest does in create transaction
+// message
+func encodeTransfer(transfer model.Transfer) string {
+	result := fmt.Sprintf("%s;%s;%s;%s;%s;%s;%s;%s",
+		transfer.IDTransfer,
+		transfer.Credit.Tenant,
+		transfer.Credit.Name,
+		transfer.Debit.Tenant,
+		transfer.Debit.Name,
+		transfer.Amount.String(),
+		transfer.Currency,
+		transfer.ValueDate,
+	)
+	if !transfer.Metadata.IsEmpty() {
+		result += ";" + transfer.Metadata.Serialize()
+	}
+	return result
+}
+
+// submitTransaction requests transaction as ledger-rest would without
+// waiting for reply
+func (lake *fakeLake) submitTransaction(transaction model.Transaction) {
+	var buffer strings.Builder
+	buffer.WriteString(ReqCreateTransaction + " " + transaction.IDTransaction)
+	for _, transfer := range transaction.Transfers {
+		buffer.WriteString(" " + encodeTransfer(transfer))
+	}
+	sys := lake.current()
+	name := "request/" + transaction.IDTransaction
+	ProcessMessage(sys)(
+		buffer.String(),
+		system.Coordinates{Region: sys.Name, Name: name},
+		system.Coordinates{Region: "LedgerRest", Name: name},
+	)
+}
+
+// createTransaction requests transaction as ledger-rest would and waits for
+// reply, empty reply means none arrived within timeout
+func (lake *fakeLake) createTransaction(transaction model.Transaction, timeout time.Duration) string {
+	lake.submitTransaction(transaction)
+	select {
+	case reply := <-lake.replies:
+		return reply
+	case <-time.After(timeout):
+		return ""
+	}
+}
+
+// request sends create transaction message as received from ledger-rest and
+// waits for reply, empty reply means none arrived within timeout
+func (lake *fakeLake) request(message string, timeout time.Duration) string {
+	name := "request/" + strings.SplitN(message, " ", 3)[1]
+	sys := lake.current()
+	ProcessMessage(sys)(
+		message,
+		system.Coordinates{Region: sys.Name, Name: name},
+		system.Coordinates{Region: "LedgerRest", Name: name},
+	)
+	select {
+	case reply := <-lake.replies:
+		return reply
+	case <-time.After(timeout):
+		return ""
+	}
+}
+
+// messagesOf returns orders sent to vault of given account in order they
+// were sent
+func (lake *fakeLake) messagesOf(tenant string, name string) []string {
+	lake.mutex.Lock()
+	defer lake.mutex.Unlock()
+	prefix := "VaultUnit/" + tenant + " " + name + " "
+	result := make([]string, 0)
+	for _, message := range lake.journal {
+		if strings.HasPrefix(message, prefix) {
+			result = append(result, strings.TrimPrefix(message, prefix))
+		}
+	}
+	return result
+}
+
+func (lake *fakeLake) expectMessages(tenant string, name string, expected ...string) {
+	lake.t.Helper()
+	actual := lake.messagesOf(tenant, name)
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		lake.t.Errorf("vault %s/%s expected messages\n%s\ngot\n%s", tenant, name, strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+func (lake *fakeLake) expectState(id string, expected string) {
+	lake.t.Helper()
+	deadline := time.Now().Add(time.Second)
+	var actual string
+	for time.Now().Before(deadline) {
+		actual, _ = persistence.LoadTransactionState(lake.current().Storage, id)
+		if actual == expected {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	lake.t.Errorf("transaction %s expected state %s got %s", id, expected, actual)
+}
+
+func transfer(id string, credit string, debit string, amount int64) model.Transfer {
+	creditParts := strings.SplitN(credit, "/", 2)
+	debitParts := strings.SplitN(debit, "/", 2)
+	return model.Transfer{
+		IDTransfer: id,
+		Credit:     model.Account{Tenant: creditParts[0], Name: creditParts[1]},
+		Debit:      model.Account{Tenant: debitParts[0], Name: debitParts[1]},
+		ValueDate:  "2020-01-01T00:00:00Z",
+		Amount:     new(money.Dec).SetUnscaled(amount),
+		Currency:   "EUR",
+	}
+}
@@ -0,0 +1,67 @@
+//go:build go1.18
+// +build go1.18
+
+package actor
+
+import (
+	"testing"
+
+	system "github.com/jancajthaml-openbank/actor-system"
+)
+
+func FuzzParseMessage(f *testing.F) {
+	f.Add("NT xyz 1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z", "LedgerRest", "transaction/1")
+	f.Add("NT xyz d=payroll&e=E2E 1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z;r=invoice%2042", "LedgerRest", "transaction/1")
+	f.Add("NT xyz 1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "LedgerRest", "transaction/1")
+	f.Add("SL", "LedgerRest", "saga/1")
+	f.Add("SA xyz", "LedgerRest", "saga/1")
+	f.Add("P1", "VaultUnit/one", "A")
+	f.Add("P2 INSUFFICIENT_FUNDS", "VaultUnit/one", "A")
+	f.Add("EE", "Vault", "")
+	f.Add("", "", "")
+	f.Add("P1 ", "VaultUnit/", "A")
+
+	f.Fuzz(func(t *testing.T, msg string, region string, name string) {
+		message, err := parseMessage(msg, system.Coordinates{Region: region, Name: name})
+		if err != nil {
+			return
+		}
+		request, ok := message.(CreateTransaction)
+		if !ok {
+			return
+		}
+		for _, transfer := range request.Transaction.Transfers {
+			if transfer.Amount == nil {
+				t.Fatalf("transfer without amount parsed from %q", msg)
+			}
+		}
+	})
+}
+
+func FuzzParseTransfer(f *testing.F) {
+	f.Add("1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z")
+	f.Add("1;one;A;one;B;1.0;EUR;2020-01-01T00:00:00Z;d=rent%20%3B%20march&t.kind=rent")
+	f.Add(";;;;;;;")
+	f.Add("")
+	f.Add(";")
+
+	f.Fuzz(func(t *testing.T, chunk string) {
+		transfer, err := parseTransfer(chunk)
+		if err != nil {
+			return
+		}
+		again, err := parseTransfer(encodeTransfer(*transfer))
+		if err != nil {
+			t.Fatalf("re-encoded transfer %q does not parse %+v", encodeTransfer(*transfer), err)
+		}
+		if again.IDTransfer != transfer.IDTransfer ||
+			again.Credit != transfer.Credit ||
+			again.Debit != transfer.Debit ||
+			again.ValueDate != transfer.ValueDate ||
+			again.Amount.Cmp(transfer.Amount) != 0 ||
+			again.Currency != transfer.Currency ||
+			!again.Metadata.Equal(transfer.Metadata) {
+			t.Fatalf("transfer %+v does not round trip, got %+v", transfer, again)
+		}
+	})
+}
@@ -30,6 +30,19 @@ const (
 	// RespTransactionMissing ledger message response code for "Transaction Missing"
 	RespTransactionMissing = "T5"
 
+	// ReqListSagas ledger message request code for "List Sagas"
+	ReqListSagas = "SL"
+	// RespListSagas ledger message response code for "Sagas Listed"
+	RespListSagas = "SR"
+	// ReqAbortSaga ledger message request code for "Abort Saga"
+	ReqAbortSaga = "SA"
+	// RespSagaAborted ledger message response code for "Saga Aborted"
+	RespSagaAborted = "S0"
+	// RespSagaMissing ledger message response code for "Saga Missing"
+	RespSagaMissing = "S1"
+	// RespSagaNotAbortable ledger message response code for "Saga Not Abortable"
+	RespSagaNotAbortable = "S2"
+
 	// PromiseOrder vault message request code for "Promise"
 	PromiseOrder = "NP"
 	// PromiseAccepted vault message response code for "Promise" accepted
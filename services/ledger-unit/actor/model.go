@@ -16,8 +16,21 @@ package actor
 
 import (
 	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/tracing"
 )
 
+// CreateTransaction is inbound request to negotiate transaction within trace
+type CreateTransaction struct {
+	Transaction model.Transaction
+	Trace       tracing.SpanContext
+}
+
+// RecoverTransaction is internal request to resume negotiation of transaction
+// found unfinished in journal
+type RecoverTransaction struct {
+	Transaction model.Transaction
+}
+
 // FatalErrored is inbound message that there was a fatal error
 type FatalErrored struct {
 	Account model.Account
@@ -33,6 +46,11 @@ type PromiseWasBounced struct {
 	Account model.Account
 }
 
+// RetryPromise is internal message to promise again to bounced account
+type RetryPromise struct {
+	Account model.Account
+}
+
 // PromiseWasRejected is inbound message that promise was rejected
 type PromiseWasRejected struct {
 	Account model.Account
@@ -60,3 +78,12 @@ type RollbackWasRejected struct {
 	Account model.Account
 	Reason  string
 }
+
+// ListSagas is inbound request for snapshots of in-flight sagas
+type ListSagas struct{}
+
+// AbortSaga is inbound request to force saga of transaction into rollback,
+// only saga that has not yet sent commit orders can be aborted
+type AbortSaga struct {
+	IDTransaction string
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actor
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SagaAccount is vault account taking part in negotiation of saga
+type SagaAccount struct {
+	Tenant string `json:"tenant"`
+	Name   string `json:"name"`
+	Reply  string `json:"reply,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Saga is snapshot of in-flight transaction negotiation
+type Saga struct {
+	Actor          string        `json:"actor"`
+	Transaction    string        `json:"transaction"`
+	Phase          string        `json:"phase"`
+	StartedAt      time.Time     `json:"startedAt"`
+	PhaseStartedAt time.Time     `json:"phaseStartedAt"`
+	PromiseRetries int           `json:"promiseRetries"`
+	Outstanding    []SagaAccount `json:"outstanding"`
+	Responses      []SagaAccount `json:"responses"`
+}
+
+// SagaRegistry holds snapshots of live transaction actors
+type SagaRegistry struct {
+	mutex sync.RWMutex
+	sagas map[string]Saga
+}
+
+// NewSagaRegistry returns empty saga registry
+func NewSagaRegistry() *SagaRegistry {
+	return &SagaRegistry{
+		sagas: make(map[string]Saga),
+	}
+}
+
+func sortAccounts(accounts []SagaAccount) {
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Tenant == accounts[j].Tenant {
+			return accounts[i].Name < accounts[j].Name
+		}
+		return accounts[i].Tenant < accounts[j].Tenant
+	})
+}
+
+// Track publishes snapshot of negotiation state of actor in given phase, it
+// must be called from within actor so its state is not read concurrently
+func (registry *SagaRegistry) Track(name string, phase string, state TransactionState) {
+	if registry == nil {
+		return
+	}
+	saga := Saga{
+		Actor:          name,
+		Transaction:    state.Transaction.IDTransaction,
+		Phase:          phase,
+		StartedAt:      state.StartedAt,
+		PhaseStartedAt: state.PhaseStartedAt,
+		PromiseRetries: state.PromiseRetries,
+		Outstanding:    make([]SagaAccount, 0, len(state.WaitFor)),
+		Responses:      make([]SagaAccount, 0, len(state.Responses)),
+	}
+	for account := range state.WaitFor {
+		saga.Outstanding = append(saga.Outstanding, SagaAccount{
+			Tenant: account.Tenant,
+			Name:   account.Name,
+		})
+	}
+	for account, reply := range state.Responses {
+		item := SagaAccount{
+			Tenant: account.Tenant,
+			Name:   account.Name,
+			Reply:  "rejected",
+			Reason: reply.Reason,
+		}
+		if reply.Accepted {
+			item.Reply = "accepted"
+		}
+		saga.Responses = append(saga.Responses, item)
+	}
+	sortAccounts(saga.Outstanding)
+	sortAccounts(saga.Responses)
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.sagas[name] = saga
+}
+
+// Forget removes snapshot of actor
+func (registry *SagaRegistry) Forget(name string) {
+	if registry == nil {
+		return
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	delete(registry.sagas, name)
+}
+
+// List returns snapshots of all live sagas, oldest first
+func (registry *SagaRegistry) List() []Saga {
+	result := make([]Saga, 0)
+	if registry == nil {
+		return result
+	}
+	registry.mutex.RLock()
+	for _, saga := range registry.sagas {
+		result = append(result, saga)
+	}
+	registry.mutex.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StartedAt.Equal(result[j].StartedAt) {
+			return result[i].Actor < result[j].Actor
+		}
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+	return result
+}
+
+// Find returns name of actor negotiating given transaction
+func (registry *SagaRegistry) Find(transaction string) (string, bool) {
+	if registry == nil {
+		return "", false
+	}
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	for name, saga := range registry.sagas {
+		if saga.Transaction == transaction {
+			return name, true
+		}
+	}
+	return "", false
+}
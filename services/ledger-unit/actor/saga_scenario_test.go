@@ -0,0 +1,262 @@
+package actor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+)
+
+func simpleTransaction(id string) model.Transaction {
+	return model.Transaction{
+		IDTransaction: id,
+		Transfers: []model.Transfer{
+			transfer("a", "one/credit", "one/debit", 1),
+		},
+	}
+}
+
+func expectReply(t *testing.T, expected string, actual string) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("expected reply %q got %q", expected, actual)
+	}
+}
+
+func TestSagaScenarioAllAccept(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	reply := lake.createTransaction(simpleTransaction("xxx"), time.Second)
+
+	expectReply(t, RespCreateTransaction+" xxx", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NC xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR", "NC xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusCommitted)
+
+	for _, path := range []string{"t_one/account/credit/one/xxx", "t_one/account/debit/one/xxx"} {
+		if ok, _ := lake.current().SharedStorage.Exists(path); !ok {
+			t.Errorf("expected account index entry %s", path)
+		}
+	}
+}
+
+func TestSagaScenarioPromiseRejected(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.vault("one", "debit").onPromise(reject("INSUFFICIENT_FUNDS"))
+
+	reply := lake.createTransaction(simpleTransaction("xxx"), time.Second)
+
+	expectReply(t, RespTransactionRejected+" xxx rollbacked", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NR xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR", "NR xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusRollbacked)
+}
+
+func TestSagaScenarioPromiseBounced(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.vault("one", "debit").onPromise(bounce(), bounce(), accept())
+
+	reply := lake.createTransaction(simpleTransaction("xxx"), time.Second)
+
+	expectReply(t, RespCreateTransaction+" xxx", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NC xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR", "NP xxx -1 EUR", "NP xxx -1 EUR", "NC xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusCommitted)
+}
+
+func TestSagaScenarioRetryBudgetExhausted(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.vault("one", "debit").onPromise(bounce())
+
+	reply := lake.createTransaction(simpleTransaction("xxx"), time.Second)
+
+	expectReply(t, RespTransactionRejected+" xxx rollbacked", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NR xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR", "NP xxx -1 EUR", "NP xxx -1 EUR", "NP xxx -1 EUR", "NR xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusRollbacked)
+}
+
+func TestSagaScenarioDelayedPromise(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.vault("one", "credit").onPromise(delayed(50*time.Millisecond, accept()))
+
+	reply := lake.createTransaction(simpleTransaction("xxx"), time.Second)
+
+	expectReply(t, RespCreateTransaction+" xxx", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NC xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR", "NC xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusCommitted)
+}
+
+func TestSagaScenarioDroppedPromise(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.vault("one", "credit").onPromise(drop())
+
+	reply := lake.createTransaction(simpleTransaction("xxx"), 100*time.Millisecond)
+
+	expectReply(t, "", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusNew)
+
+	sagas := lake.current().Sagas.List()
+	if len(sagas) != 1 {
+		t.Fatalf("expected one saga in flight got %d", len(sagas))
+	}
+	if sagas[0].Phase != PhasePromise {
+		t.Errorf("expected saga in phase %s got %s", PhasePromise, sagas[0].Phase)
+	}
+	if len(sagas[0].Outstanding) != 1 || sagas[0].Outstanding[0].Name != "credit" {
+		t.Errorf("expected saga waiting for credit got %+v", sagas[0].Outstanding)
+	}
+}
+
+func TestSagaScenarioCrashMidCommit(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.vault("one", "credit").onCommit(crash())
+
+	reply := lake.createTransaction(simpleTransaction("xxx"), 100*time.Millisecond)
+
+	expectReply(t, "", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NC xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR", "NC xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusAccepted)
+
+	sagas := lake.current().Sagas.List()
+	if len(sagas) != 1 || sagas[0].Phase != PhaseCommit {
+		t.Errorf("expected one saga stuck in commit got %+v", sagas)
+	}
+}
+
+func TestSagaScenarioAbortRefusedMidCommit(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.vault("one", "credit").onCommit(delayed(200*time.Millisecond, accept()))
+
+	lake.submitTransaction(simpleTransaction("xxx"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(lake.messagesOf("one", "credit")) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NC xxx 1 EUR")
+
+	reply := lake.request(ReqAbortSaga+" xxx", time.Second)
+	expectReply(t, RespSagaNotAbortable+" xxx", reply)
+
+	select {
+	case reply = <-lake.replies:
+	case <-time.After(time.Second):
+		reply = ""
+	}
+
+	expectReply(t, RespCreateTransaction+" xxx", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NC xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR", "NC xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusCommitted)
+}
+
+func TestSagaScenarioFatalCommit(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.vault("one", "credit").onCommit(fatal())
+
+	// debit already committed refuses rollback and transaction stays rejected
+	// until finalizer resumes it
+
+	reply := lake.createTransaction(simpleTransaction("xxx"), time.Second)
+
+	expectReply(t, RespTransactionRefused+" xxx", reply)
+	lake.expectMessages("one", "credit", "NP xxx 1 EUR", "NC xxx 1 EUR", "NR xxx 1 EUR")
+	lake.expectMessages("one", "debit", "NP xxx -1 EUR", "NC xxx -1 EUR", "NR xxx -1 EUR")
+	lake.expectState("xxx", persistence.StatusRejected)
+}
+
+func TestSagaScenarioRestMessages(t *testing.T) {
+	for _, message := range restMessages(t) {
+		lake := newFakeLake(t, "one")
+
+		reply := lake.request(message, time.Second)
+
+		expectReply(t, RespCreateTransaction+" xyz", reply)
+		lake.expectState("xyz", persistence.StatusCommitted)
+
+		suffix := ""
+		if fields := strings.Fields(message); strings.HasPrefix(fields[len(fields)-1], "00-") {
+			suffix = " 00-" + fields[len(fields)-1][3:35] + "-"
+		}
+		for _, account := range []string{"one/A", "two/B"} {
+			parts := strings.SplitN(account, "/", 2)
+			orders := lake.messagesOf(parts[0], parts[1])
+			if len(orders) != 2 {
+				t.Errorf("vault %s expected promise and commit of %q got %q", account, message, orders)
+			}
+			for _, order := range orders {
+				if suffix == "" && strings.Count(order, " ") != 3 {
+					t.Errorf("vault %s expected order without trace of %q got %q", account, message, order)
+				}
+				if suffix != "" && !strings.Contains(order, suffix) {
+					t.Errorf("vault %s expected order with trace of %q got %q", account, message, order)
+				}
+			}
+		}
+
+		lake.close()
+	}
+}
+
+func TestSagaScenarioReferencePublicationInterrupted(t *testing.T) {
+	lake := newFakeLake(t, "one")
+	defer lake.close()
+
+	lake.restart(lake.injector("fail-write@t_two/inbound/", 1))
+
+	transaction := model.Transaction{
+		IDTransaction: "xxx",
+		Transfers: []model.Transfer{
+			transfer("a", "one/credit", "two/debit", 1),
+		},
+	}
+
+	reply := lake.createTransaction(transaction, time.Second)
+
+	expectReply(t, RespCreateTransaction+" xxx", reply)
+	lake.expectState("xxx", persistence.StatusCommitted)
+
+	shared := lake.current().SharedStorage
+	if ok, _ := shared.Exists("t_two/inbound/one/xxx"); ok {
+		t.Errorf("expected reference not to be published")
+	}
+	if !persistence.HasOutboundMark(lake.current().Storage, "xxx") {
+		t.Errorf("expected transaction to be marked for publication")
+	}
+
+	lake.restart(nil)
+	lake.recover()
+	lake.settle(time.Second)
+
+	data, err := lake.current().SharedStorage.ReadFileFully("t_two/inbound/one/xxx")
+	if err != nil || string(data) != persistence.StatusCommitted {
+		t.Errorf("expected reference to be published on recovery got %q %+v", string(data), err)
+	}
+	if persistence.HasOutboundMark(lake.current().Storage, "xxx") {
+		t.Errorf("expected mark to be removed once reference is published")
+	}
+}
@@ -0,0 +1,69 @@
+package actor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/tracing"
+
+	money "gopkg.in/inf.v0"
+)
+
+func TestSagaRegistry(t *testing.T) {
+	registry := NewSagaRegistry()
+
+	state := NewTransactionState()
+	state.PrepareNewForTransaction(model.Transaction{
+		IDTransaction: "xyz",
+		Transfers: []model.Transfer{
+			{
+				IDTransfer: "1",
+				Credit:     model.Account{Tenant: "one", Name: "A"},
+				Debit:      model.Account{Tenant: "two", Name: "B"},
+				Amount:     new(money.Dec).SetUnscaled(1),
+				Currency:   "EUR",
+			},
+		},
+	}, tracing.SpanContext{}, state.ReplyTo)
+	state.Mark(PromiseWasRejected{
+		Account: model.Account{Tenant: "two", Name: "B"},
+		Reason:  "INSUFFICIENT_FUNDS",
+	})
+
+	registry.Track("transaction/1", PhasePromise, state)
+
+	older := NewTransactionState()
+	older.Transaction.IDTransaction = "abc"
+	older.StartedAt = state.StartedAt.Add(-time.Minute)
+	registry.Track("transaction/2", PhaseRollback, older)
+
+	sagas := registry.List()
+	if len(sagas) != 2 {
+		t.Fatalf("expected 2 sagas got %d", len(sagas))
+	}
+	if sagas[0].Transaction != "abc" || sagas[1].Transaction != "xyz" {
+		t.Errorf("expected oldest saga first got %+v", sagas)
+	}
+
+	saga := sagas[1]
+	if saga.Phase != PhasePromise {
+		t.Errorf("expected phase %s got %s", PhasePromise, saga.Phase)
+	}
+	if len(saga.Outstanding) != 1 || saga.Outstanding[0].Tenant != "one" || saga.Outstanding[0].Name != "A" {
+		t.Errorf("expected outstanding one/A got %+v", saga.Outstanding)
+	}
+	if len(saga.Responses) != 1 || saga.Responses[0].Reply != "rejected" || saga.Responses[0].Reason != "INSUFFICIENT_FUNDS" {
+		t.Errorf("expected rejected response of two/B got %+v", saga.Responses)
+	}
+
+	if name, ok := registry.Find("xyz"); !ok || name != "transaction/1" {
+		t.Errorf("expected to find transaction/1 got %s", name)
+	}
+
+	registry.Forget("transaction/1")
+
+	if _, ok := registry.Find("xyz"); ok {
+		t.Errorf("expected forgotten saga to be missing")
+	}
+}
@@ -15,21 +15,44 @@
 package actor
 
 import (
+	"time"
+
 	"github.com/jancajthaml-openbank/ledger-unit/model"
 	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+	"github.com/jancajthaml-openbank/ledger-unit/tracing"
 
 	system "github.com/jancajthaml-openbank/actor-system"
 )
 
+// Negotiation phases of transaction actor
+const (
+	PhasePromise  = "promise"
+	PhaseCommit   = "commit"
+	PhaseRollback = "rollback"
+)
+
+// Reply is vault reply received in current negotiation phase
+type Reply struct {
+	Accepted bool
+	Reason   string
+}
+
 // TransactionState represent negotiation state of transaction actor
 type TransactionState struct {
 	Transaction     model.Transaction
 	Negotiation     map[model.Account]string
 	WaitFor         map[model.Account]interface{}
+	Responses       map[model.Account]Reply
 	OkResponses     int
 	FailedResponses int
+	PromiseRetries  int
 	Ready           bool
 	ReplyTo         system.Coordinates
+	StartedAt       time.Time
+	PhaseStartedAt  time.Time
+	Trace           tracing.SpanContext
+	TracePropagated bool
+	PhaseSpan       tracing.SpanContext
 }
 
 // NewTransactionState returns initial negotiation transaction actor state
@@ -52,6 +75,7 @@ func (state *TransactionState) Mark(value interface{}) *model.Account {
 	case PromiseWasAccepted:
 		if _, exists := state.WaitFor[msg.Account]; exists {
 			delete(state.WaitFor, msg.Account)
+			state.Responses[msg.Account] = Reply{Accepted: true}
 			state.OkResponses++
 		}
 		return nil
@@ -59,6 +83,7 @@ func (state *TransactionState) Mark(value interface{}) *model.Account {
 	case PromiseWasRejected:
 		if _, exists := state.WaitFor[msg.Account]; exists {
 			delete(state.WaitFor, msg.Account)
+			state.Responses[msg.Account] = Reply{Reason: msg.Reason}
 			state.FailedResponses++
 		}
 		return nil
@@ -72,6 +97,7 @@ func (state *TransactionState) Mark(value interface{}) *model.Account {
 	case CommitWasAccepted:
 		if _, exists := state.WaitFor[msg.Account]; exists {
 			delete(state.WaitFor, msg.Account)
+			state.Responses[msg.Account] = Reply{Accepted: true}
 			state.OkResponses++
 		}
 		return nil
@@ -79,6 +105,7 @@ func (state *TransactionState) Mark(value interface{}) *model.Account {
 	case CommitWasRejected:
 		if _, exists := state.WaitFor[msg.Account]; exists {
 			delete(state.WaitFor, msg.Account)
+			state.Responses[msg.Account] = Reply{Reason: msg.Reason}
 			state.FailedResponses++
 		}
 		return nil
@@ -86,6 +113,7 @@ func (state *TransactionState) Mark(value interface{}) *model.Account {
 	case RollbackWasAccepted:
 		if _, exists := state.WaitFor[msg.Account]; exists {
 			delete(state.WaitFor, msg.Account)
+			state.Responses[msg.Account] = Reply{Accepted: true}
 			state.OkResponses++
 		}
 		return nil
@@ -93,6 +121,7 @@ func (state *TransactionState) Mark(value interface{}) *model.Account {
 	case RollbackWasRejected:
 		if _, exists := state.WaitFor[msg.Account]; exists {
 			delete(state.WaitFor, msg.Account)
+			state.Responses[msg.Account] = Reply{Reason: msg.Reason}
 			state.FailedResponses++
 		}
 		return nil
@@ -100,6 +129,7 @@ func (state *TransactionState) Mark(value interface{}) *model.Account {
 	case FatalErrored:
 		if _, exists := state.WaitFor[msg.Account]; exists {
 			delete(state.WaitFor, msg.Account)
+			state.Responses[msg.Account] = Reply{Reason: "fatal error"}
 			state.FailedResponses++
 		}
 		return nil
@@ -110,17 +140,20 @@ func (state *TransactionState) Mark(value interface{}) *model.Account {
 	}
 }
 
-// ResetMarks zeroes out negotiation state
+// ResetMarks zeroes out negotiation state and starts timing of next phase
 func (state *TransactionState) ResetMarks() {
 	if state == nil {
 		return
 	}
 	state.WaitFor = make(map[model.Account]interface{})
+	state.Responses = make(map[model.Account]Reply)
 	for account := range state.Negotiation {
 		state.WaitFor[account] = nil
 	}
 	state.OkResponses = 0
 	state.FailedResponses = 0
+	state.PhaseStartedAt = time.Now()
+	state.PhaseSpan = state.Trace.Child()
 }
 
 // IsNegotiationFinished tells whenever negotiation is finished
@@ -128,8 +161,15 @@ func (state TransactionState) IsNegotiationFinished() bool {
 	return len(state.Negotiation) <= (state.OkResponses + state.FailedResponses)
 }
 
-// PrepareNewForTransaction prepares state for new negotiation
-func (state *TransactionState) PrepareNewForTransaction(transaction model.Transaction, requestedBy system.Coordinates) {
+// IsWaitingFor tells whenever reply of given account is still expected
+func (state TransactionState) IsWaitingFor(account model.Account) bool {
+	_, exists := state.WaitFor[account]
+	return exists
+}
+
+// PrepareNewForTransaction prepares state for new negotiation continuing
+// given trace, new local trace is started when given one is not valid
+func (state *TransactionState) PrepareNewForTransaction(transaction model.Transaction, trace tracing.SpanContext, requestedBy system.Coordinates) {
 	if state == nil {
 		return
 	}
@@ -137,7 +177,29 @@ func (state *TransactionState) PrepareNewForTransaction(transaction model.Transa
 	state.Transaction = transaction
 	state.Transaction.State = persistence.StatusNew
 	state.Negotiation = negotiation
+	state.StartedAt = time.Now()
+	state.Trace = trace
+	state.TracePropagated = trace.IsValid()
+	if !state.TracePropagated {
+		state.Trace = tracing.NewTrace()
+	}
 	state.ResetMarks()
 	state.Ready = true
 	state.ReplyTo = requestedBy
 }
+
+// PrepareRecoveryOfTransaction prepares state for resuming negotiation of
+// transaction loaded from journal, nobody awaits outcome of recovery
+func (state *TransactionState) PrepareRecoveryOfTransaction(transaction model.Transaction) {
+	if state == nil {
+		return
+	}
+	state.Transaction = transaction
+	state.Negotiation = transaction.PrepareRemoteNegotiation()
+	state.StartedAt = time.Now()
+	state.Trace = tracing.NewTrace()
+	state.TracePropagated = false
+	state.ResetMarks()
+	state.Ready = true
+	state.ReplyTo = system.Coordinates{}
+}
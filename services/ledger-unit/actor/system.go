@@ -15,7 +15,15 @@
 package actor
 
 import (
+	"sync/atomic"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/audit"
 	"github.com/jancajthaml-openbank/ledger-unit/metrics"
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+	"github.com/jancajthaml-openbank/ledger-unit/support/faults"
+	"github.com/jancajthaml-openbank/ledger-unit/tracing"
 
 	system "github.com/jancajthaml-openbank/actor-system"
 	localfs "github.com/jancajthaml-openbank/local-fs"
@@ -24,18 +32,34 @@ import (
 // System represents actor system subroutine
 type System struct {
 	system.System
+	Tenant               string
 	Storage              localfs.Storage
+	SharedStorage        localfs.Storage
+	Events               *persistence.EventLog
 	Metrics              metrics.Metrics
+	Sagas                *SagaRegistry
+	Tracer               *tracing.Exporter
+	Audit                *audit.Sink
+	Transport            Transport
+	Faults               *faults.Injector
 	EventCounterTreshold int64
+	PromiseRetryBudget   int
+	PromiseRetryBackoff  time.Duration
+	tenantConfig         atomic.Value
 }
 
 // NewActorSystem returns actor system fascade
-func NewActorSystem(tenant string, endpoint string, rootStorage string, metrics metrics.Metrics) *System {
+func NewActorSystem(tenant string, endpoint string, rootStorage string, sharedStorage string, promiseRetryBudget int, promiseRetryBackoff time.Duration, metrics metrics.Metrics, tracer *tracing.Exporter, auditSink *audit.Sink, injector *faults.Injector) *System {
 	storage, err := localfs.NewPlaintextStorage(rootStorage)
 	if err != nil {
 		log.Error().Msgf("Failed to ensure storage %+v", err)
 		return nil
 	}
+	shared, err := localfs.NewPlaintextStorage(sharedStorage)
+	if err != nil {
+		log.Error().Msgf("Failed to ensure shared storage %+v", err)
+		return nil
+	}
 	sys, err := system.New("LedgerUnit/"+tenant, endpoint)
 	if err != nil {
 		log.Error().Msgf("Failed to register actor system %+v", err)
@@ -43,14 +67,67 @@ func NewActorSystem(tenant string, endpoint string, rootStorage string, metrics
 	}
 	result := new(System)
 	result.System = sys
+	result.Tenant = tenant
+	result.PromiseRetryBudget = promiseRetryBudget
+	result.PromiseRetryBackoff = promiseRetryBackoff
 	result.Metrics = metrics
-	result.Storage = storage
+	result.Sagas = NewSagaRegistry()
+	result.Tracer = tracer
+	result.Audit = auditSink
+	result.Faults = injector
+	result.Storage = injector.Storage(storage)
+	result.SharedStorage = injector.Storage(shared)
+	result.Events = persistence.NewEventLog(result.Storage)
 	result.System.RegisterOnMessage(ProcessMessage(result))
 	return result
 }
 
-// Setup does nothing
+// RegisterActor registers actor and accounts it as saga in flight
+func (system *System) RegisterActor(ref *system.Actor, initialReaction func(interface{}, system.Context)) error {
+	if err := system.System.RegisterActor(ref, initialReaction); err != nil {
+		return err
+	}
+	system.Metrics.SagaStarted()
+	return nil
+}
+
+// UnregisterActor unregisters actor and accounts its saga as finished
+func (system *System) UnregisterActor(name string) {
+	if _, err := system.System.ActorOf(name); err != nil {
+		return
+	}
+	system.System.UnregisterActor(name)
+	system.Sagas.Forget(name)
+	system.Metrics.SagaFinished()
+}
+
+// TenantConfig returns current runtime configuration of tenant
+func (system *System) TenantConfig() *model.TenantConfig {
+	if system == nil {
+		return nil
+	}
+	config, _ := system.tenantConfig.Load().(*model.TenantConfig)
+	return config
+}
+
+// SetTenantConfig replaces runtime configuration of tenant
+func (system *System) SetTenantConfig(config model.TenantConfig) {
+	if system == nil {
+		return
+	}
+	system.tenantConfig.Store(&config)
+}
+
+// Setup indexes accounts of transactions journaled before account index
+// existed, failed backfill is retried on next start
 func (system *System) Setup() error {
+	if system == nil {
+		return nil
+	}
+	err := persistence.BackfillAccountIndex(system.Storage, system.SharedStorage, system.Tenant)
+	if err != nil {
+		log.Warn().Msgf("Failed to backfill account index %+v", err)
+	}
 	return nil
 }
 
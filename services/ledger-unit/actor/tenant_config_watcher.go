@@ -0,0 +1,117 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actor
+
+import (
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// TenantConfigWatcher represents tenant configuration reload subroutine
+type TenantConfigWatcher struct {
+	callback     func(config model.TenantConfig)
+	storage      localfs.Storage
+	defaults     model.TenantConfig
+	modification time.Time
+	loaded       bool
+}
+
+// NewTenantConfigWatcher returns tenant configuration watcher fascade with
+// initial configuration already loaded, callback receives configuration with
+// unset values filled from defaults
+func NewTenantConfigWatcher(rootStorage string, defaults model.TenantConfig, callback func(config model.TenantConfig)) *TenantConfigWatcher {
+	storage, err := localfs.NewPlaintextStorage(rootStorage)
+	if err != nil {
+		log.Error().Msgf("Failed to ensure storage %+v", err)
+		return nil
+	}
+	watcher := &TenantConfigWatcher{
+		callback: callback,
+		storage:  storage,
+		defaults: defaults,
+	}
+	watcher.reload()
+	if !watcher.loaded {
+		callback(defaults)
+	}
+	return watcher
+}
+
+func (watcher *TenantConfigWatcher) withDefaults(config model.TenantConfig) model.TenantConfig {
+	if config.FinalizerInterval <= 0 {
+		config.FinalizerInterval = watcher.defaults.FinalizerInterval
+	}
+	if config.LogLevel == "" {
+		config.LogLevel = watcher.defaults.LogLevel
+	}
+	if len(config.Currencies) == 0 {
+		config.Currencies = watcher.defaults.Currencies
+	}
+	if config.MaxTransfers <= 0 {
+		config.MaxTransfers = watcher.defaults.MaxTransfers
+	}
+	if config.MaxAmount == nil {
+		config.MaxAmount = watcher.defaults.MaxAmount
+	}
+	return config
+}
+
+func (watcher *TenantConfigWatcher) reload() {
+	if watcher == nil {
+		return
+	}
+	modification, err := persistence.LoadTenantConfigModification(watcher.storage)
+	if err != nil {
+		log.Warn().Msgf("Unable to check tenant configuration %+v", err)
+		return
+	}
+	if watcher.loaded && modification.Equal(watcher.modification) {
+		return
+	}
+	config, err := persistence.LoadTenantConfig(watcher.storage)
+	if err != nil {
+		log.Warn().Msgf("Invalid tenant configuration, keeping previous one %+v", err)
+		return
+	}
+	watcher.modification = modification
+	watcher.loaded = true
+	log.Info().Msg("Tenant configuration loaded")
+	watcher.callback(watcher.withDefaults(*config))
+}
+
+// Setup does nothing
+func (watcher *TenantConfigWatcher) Setup() error {
+	return nil
+}
+
+// Work reloads tenant configuration if it has changed
+func (watcher *TenantConfigWatcher) Work() {
+	watcher.reload()
+}
+
+// Cancel does nothing
+func (watcher *TenantConfigWatcher) Cancel() {
+}
+
+// Done always returns done
+func (watcher *TenantConfigWatcher) Done() <-chan interface{} {
+	done := make(chan interface{})
+	close(done)
+	return done
+}
@@ -0,0 +1,90 @@
+package actor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+
+	money "gopkg.in/inf.v0"
+)
+
+func TestTenantConfigWatcher(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "tenant_config_watcher")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	modification := time.Now().Add(-time.Hour)
+	writeConfig := func(data string) {
+		filename := filepath.Join(tmpdir, "config")
+		if err := ioutil.WriteFile(filename, []byte(data), 0600); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		modification = modification.Add(time.Second)
+		if err := os.Chtimes(filename, modification, modification); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+	}
+
+	defaults := model.TenantConfig{
+		FinalizerInterval: time.Minute,
+		LogLevel:          "INFO",
+		MaxTransfers:      5,
+	}
+
+	var current *model.TenantConfig
+	calls := 0
+	watcher := NewTenantConfigWatcher(tmpdir, defaults, func(config model.TenantConfig) {
+		current = &config
+		calls++
+	})
+	if watcher == nil {
+		t.Fatalf("expected watcher")
+	}
+
+	if calls != 1 || current.FinalizerInterval != time.Minute || current.MaxTransfers != 5 || current.MaxAmount != nil {
+		t.Fatalf("expected defaults without configuration, got %+v", current)
+	}
+
+	writeConfig(`{"maxTransfers":2,"maxAmount":"100","currencies":["EUR"]}`)
+	watcher.Work()
+	if calls != 2 {
+		t.Fatalf("expected configuration to be reloaded")
+	}
+	if current.MaxTransfers != 2 || current.MaxAmount == nil || current.MaxAmount.Cmp(money.NewDec(100, 0)) != 0 {
+		t.Errorf("unexpected configuration %+v", current)
+	}
+	if current.FinalizerInterval != time.Minute || current.LogLevel != "INFO" {
+		t.Errorf("expected unset values from defaults, got %+v", current)
+	}
+
+	watcher.Work()
+	if calls != 2 {
+		t.Errorf("expected unchanged configuration not to be reloaded")
+	}
+
+	for _, value := range []string{"0", "-1", "1e10", "Inf", "NaN", "1_000"} {
+		writeConfig(`{"maxAmount":"` + value + `"}`)
+		watcher.Work()
+		if calls != 2 {
+			t.Fatalf("expected maxAmount %q to be rejected", value)
+		}
+		if current.MaxAmount == nil || current.MaxAmount.Cmp(money.NewDec(100, 0)) != 0 {
+			t.Errorf("expected previous configuration to be kept, got %+v", current)
+		}
+	}
+
+	writeConfig(`{"maxAmount":"0.5"}`)
+	watcher.Work()
+	if calls != 3 {
+		t.Fatalf("expected configuration to be reloaded after invalid one")
+	}
+	if current.MaxAmount == nil || current.MaxAmount.Cmp(money.NewDec(5, 1)) != 0 || current.MaxTransfers != 5 {
+		t.Errorf("unexpected configuration %+v", current)
+	}
+}
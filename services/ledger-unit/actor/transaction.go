@@ -15,12 +15,263 @@
 package actor
 
 import (
+	"strconv"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/audit"
 	"github.com/jancajthaml-openbank/ledger-unit/model"
 	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+	"github.com/jancajthaml-openbank/ledger-unit/support/faults"
+	"github.com/jancajthaml-openbank/ledger-unit/tracing"
 
 	system "github.com/jancajthaml-openbank/actor-system"
 )
 
+// become transitions actor into reaction of given phase and publishes snapshot
+// of its negotiation
+func become(s *System, context system.Context, state TransactionState, phase string, reaction func(interface{}, system.Context)) {
+	context.Self.Become(state, reaction)
+	s.Sagas.Track(context.Self.Name, phase, state)
+}
+
+// vaultOrder returns order of negotiation task carrying trace of current phase
+// when transaction was requested within trace
+func vaultOrder(order string, task string, state TransactionState) string {
+	if !state.TracePropagated {
+		return order + " " + task
+	}
+	return order + " " + task + " " + state.PhaseSpan.String()
+}
+
+// sendOrders sends order to every account negotiated by transaction
+func sendOrders(s *System, state TransactionState, order string, from system.Coordinates) {
+	for account, task := range state.Negotiation {
+		s.SendMessage(
+			vaultOrder(order, task, state),
+			system.Coordinates{
+				Region: "VaultUnit/" + account.Tenant,
+				Name:   account.Name,
+			},
+			from,
+		)
+	}
+}
+
+// endPhase records duration of negotiation phase and exports its span
+func endPhase(s *System, state TransactionState, phase string, aborted bool) {
+	now := time.Now()
+	s.Metrics.PhaseDuration(phase, now.Sub(state.PhaseStartedAt))
+
+	outcome := "accepted"
+	if aborted {
+		outcome = "aborted"
+	} else if state.FailedResponses > 0 {
+		outcome = "rejected"
+	}
+
+	s.Tracer.Export(tracing.Span{
+		Name:    "ledger." + phase,
+		Kind:    tracing.KindInternal,
+		Context: state.PhaseSpan,
+		Parent:  state.Trace,
+		Start:   state.PhaseStartedAt,
+		End:     now,
+		Attributes: map[string]string{
+			"ledger.tenant":      s.Tenant,
+			"ledger.transaction": state.Transaction.IDTransaction,
+			"ledger.outcome":     outcome,
+			"ledger.accounts":    strconv.Itoa(len(state.Negotiation)),
+			"ledger.accepted":    strconv.Itoa(state.OkResponses),
+			"ledger.rejected":    strconv.Itoa(state.FailedResponses),
+		},
+		Failed: outcome != "accepted",
+	})
+}
+
+// auditOutcome records terminal state of transaction into audit trail
+func auditOutcome(s *System, state TransactionState) {
+	amounts := make([]audit.Amount, 0, len(state.Transaction.Transfers))
+	for _, transfer := range state.Transaction.Transfers {
+		amounts = append(amounts, audit.Amount{
+			Transfer: transfer.IDTransfer,
+			Value:    transfer.Amount.String(),
+			Currency: transfer.Currency,
+		})
+	}
+	s.Audit.Record(audit.Entry{
+		Action:      "transaction.finalize",
+		Outcome:     state.Transaction.State,
+		Tenant:      s.Tenant,
+		Trace:       state.Trace.TraceIDString(),
+		Transaction: state.Transaction.IDTransaction,
+		Amounts:     amounts,
+	})
+}
+
+// abortSaga rejects transaction and rollbacks all its negotiated accounts
+func abortSaga(s *System, context system.Context, state TransactionState, phase string) {
+	log.Info().Msgf("%s/%s Aborted", state.Transaction.IDTransaction, phase)
+
+	state.Transaction.State = persistence.StatusRejected
+	err := persistence.UpdateTransaction(s.Storage, s.Events, &state.Transaction)
+	if err != nil {
+		log.Warn().Msgf("%s/%s failed to update aborted transaction %+v", state.Transaction.IDTransaction, phase, err)
+	}
+
+	s.SendMessage(
+		RespSagaAborted+" "+state.Transaction.IDTransaction,
+		context.Sender,
+		context.Receiver,
+	)
+
+	endPhase(s, state, phase, true)
+	state.ResetMarks()
+	become(s, context, state, PhaseRollback, RollbackingTransaction(s))
+
+	self := system.Coordinates{
+		Region: s.Name,
+		Name:   context.Self.Name,
+	}
+
+	sendOrders(s, state, RollbackOrder, self)
+
+	log.Debug().Msgf("%s/%s -> %s/Rollback", state.Transaction.IDTransaction, phase, state.Transaction.IDTransaction)
+}
+
+// maxPromiseRetryBackoff caps backoff between promise retries
+const maxPromiseRetryBackoff = 10 * time.Second
+
+// promiseRetryBackoff returns exponential backoff of given retry attempt
+func promiseRetryBackoff(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxPromiseRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxPromiseRetryBackoff {
+		return maxPromiseRetryBackoff
+	}
+	return delay
+}
+
+// schedulePromiseRetry tells actor to promise again to bounced account once
+// backoff elapses, nothing is told when actor is gone by then
+func schedulePromiseRetry(s *System, ref *system.Actor, account model.Account, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		current, err := s.ActorOf(ref.Name)
+		if err != nil || current != ref {
+			return
+		}
+		self := system.Coordinates{
+			Region: s.Name,
+			Name:   ref.Name,
+		}
+		ref.Tell(RetryPromise{Account: account}, self, self)
+	})
+}
+
+// observeReply records vault reply of given negotiation phase, only replies
+// still awaited are counted
+func observeReply(s *System, state TransactionState, phase string, data interface{}) {
+	switch msg := data.(type) {
+
+	case PromiseWasRejected:
+		if state.IsWaitingFor(msg.Account) {
+			s.Metrics.TransactionRejected(phase, msg.Reason, msg.Account.Tenant)
+		}
+
+	case CommitWasRejected:
+		if state.IsWaitingFor(msg.Account) {
+			s.Metrics.TransactionRejected(phase, msg.Reason, msg.Account.Tenant)
+		}
+
+	case RollbackWasRejected:
+		if state.IsWaitingFor(msg.Account) {
+			s.Metrics.TransactionRejected(phase, msg.Reason, msg.Account.Tenant)
+		}
+
+	case PromiseWasBounced:
+		if state.IsWaitingFor(msg.Account) {
+			s.Metrics.PromiseBounced(msg.Account.Tenant)
+		}
+
+	case FatalErrored:
+		if state.IsWaitingFor(msg.Account) {
+			s.Metrics.FatalError(phase, msg.Account.Tenant)
+		}
+
+	}
+}
+
+// recoverTransaction resumes negotiation of transaction left unfinished by
+// previous run, accepted transaction is committed and any other unfinished
+// transaction is rolled back, transaction without transfers was torn when
+// created and never negotiated so it is rolled back right away, finished
+// transaction has its interrupted update completed and committed transaction
+// has its unpublished references published again
+func recoverTransaction(s *System, context system.Context, state TransactionState) {
+	self := system.Coordinates{
+		Region: s.Name,
+		Name:   context.Self.Name,
+	}
+
+	switch state.Transaction.State {
+
+	case persistence.StatusAccepted:
+		log.Info().Msgf("%s/Recovery -> %s/Commit", state.Transaction.IDTransaction, state.Transaction.IDTransaction)
+		state.ResetMarks()
+		become(s, context, state, PhaseCommit, CommitingTransaction(s))
+		sendOrders(s, state, CommitOrder, self)
+		s.Faults.Checkpoint(faults.AfterCommitSent)
+
+	case persistence.StatusNew, persistence.StatusRejected:
+		if len(state.Negotiation) == 0 {
+			log.Warn().Msgf("%s/Recovery has no transfers -> Rollbacked", state.Transaction.IDTransaction)
+			state.Transaction.State = persistence.StatusRollbacked
+			err := persistence.UpdateTransaction(s.Storage, s.Events, &state.Transaction)
+			if err != nil {
+				log.Warn().Msgf("%s/Recovery failed to update transaction %+v", state.Transaction.IDTransaction, err)
+			}
+			s.UnregisterActor(context.Receiver.Name)
+			return
+		}
+		if state.Transaction.State == persistence.StatusNew {
+			state.Transaction.State = persistence.StatusRejected
+			err := persistence.UpdateTransaction(s.Storage, s.Events, &state.Transaction)
+			if err != nil {
+				log.Warn().Msgf("%s/Recovery failed to update transaction %+v", state.Transaction.IDTransaction, err)
+				s.UnregisterActor(context.Receiver.Name)
+				return
+			}
+		}
+		log.Info().Msgf("%s/Recovery -> %s/Rollback", state.Transaction.IDTransaction, state.Transaction.IDTransaction)
+		state.ResetMarks()
+		become(s, context, state, PhaseRollback, RollbackingTransaction(s))
+		sendOrders(s, state, RollbackOrder, self)
+
+	case persistence.StatusCommitted, persistence.StatusRollbacked:
+		if persistence.HasPendingUpdate(s.Storage, state.Transaction.IDTransaction) {
+			log.Info().Msgf("%s/Recovery -> Complete update", state.Transaction.IDTransaction)
+			err := persistence.CompletePendingUpdate(s.Storage, s.Events, state.Transaction.IDTransaction)
+			if err != nil {
+				log.Warn().Msgf("%s/Recovery failed to complete update %+v", state.Transaction.IDTransaction, err)
+			}
+		}
+		if state.Transaction.State == persistence.StatusCommitted {
+			log.Info().Msgf("%s/Recovery -> Publish references", state.Transaction.IDTransaction)
+			err := persistence.PublishInboundReferences(s.Storage, s.SharedStorage, s.Tenant, &state.Transaction)
+			if err != nil {
+				log.Warn().Msgf("%s/Recovery %+v", state.Transaction.IDTransaction, err)
+			}
+		}
+		s.UnregisterActor(context.Receiver.Name)
+
+	default:
+		log.Debug().Msgf("%s/Recovery nothing to recover in state %s", state.Transaction.IDTransaction, state.Transaction.State)
+		s.UnregisterActor(context.Receiver.Name)
+
+	}
+}
+
 // InitialTransaction represents initial transaction state
 func InitialTransaction(s *System) func(interface{}, system.Context) {
 	return func(t_state interface{}, context system.Context) {
@@ -28,24 +279,44 @@ func InitialTransaction(s *System) func(interface{}, system.Context) {
 
 		switch msg := context.Data.(type) {
 
-		case model.Transaction:
+		case CreateTransaction:
+			transaction := msg.Transaction
 			if state.Ready {
 				s.SendMessage(
-					RespTransactionRace+" "+msg.IDTransaction,
+					RespTransactionRace+" "+transaction.IDTransaction,
 					state.ReplyTo,
 					context.Receiver,
 				)
 				log.Warn().Msgf("%s/Initial already in progress", state.Transaction.IDTransaction)
 				return
 			}
-			state.PrepareNewForTransaction(msg, context.Sender)
+			if err := s.TenantConfig().Check(&transaction); err != nil {
+				s.SendMessage(
+					RespTransactionRefused+" "+transaction.IDTransaction,
+					context.Sender,
+					context.Receiver,
+				)
+				log.Info().Msgf("%s/Initial refused %s", transaction.IDTransaction, err.Error())
+				s.Metrics.TransactionRefused(len(transaction.Transfers))
+				s.UnregisterActor(context.Receiver.Name)
+				return
+			}
+			state.PrepareNewForTransaction(transaction, msg.Trace, context.Sender)
+
+		case RecoverTransaction:
+			if state.Ready {
+				return
+			}
+			state.PrepareRecoveryOfTransaction(msg.Transaction)
+			recoverTransaction(s, context, state)
+			return
 
 		default:
 			s.SendMessage(FatalError, state.ReplyTo, context.Receiver)
 			return
 		}
 
-		err := persistence.CreateTransaction(s.Storage, &state.Transaction)
+		err := persistence.CreateTransaction(s.Storage, s.Events, &state.Transaction)
 		if err != nil {
 			current, err := persistence.LoadTransaction(s.Storage, state.Transaction.IDTransaction)
 			if err != nil {
@@ -93,19 +364,14 @@ func InitialTransaction(s *System) func(interface{}, system.Context) {
 
 		s.Metrics.TransactionPromised(len(state.Transaction.Transfers))
 
-		for account, task := range state.Negotiation {
-			s.SendMessage(
-				PromiseOrder+" "+task,
-				system.Coordinates{
-					Region: "VaultUnit/" + account.Tenant,
-					Name:   account.Name,
-				},
-				context.Receiver,
-			)
+		if s.Faults.Checkpoint(faults.AfterPromisePersisted) {
+			return
 		}
 
 		state.ResetMarks()
-		context.Self.Become(state, PromisingTransaction(s))
+		become(s, context, state, PhasePromise, PromisingTransaction(s))
+
+		sendOrders(s, state, PromiseOrder, context.Receiver)
 
 		log.Debug().Msgf("%s/Initial -> %s/Promise", state.Transaction.IDTransaction, state.Transaction.IDTransaction)
 	}
@@ -116,35 +382,64 @@ func PromisingTransaction(s *System) func(interface{}, system.Context) {
 	return func(t_state interface{}, context system.Context) {
 		state := t_state.(TransactionState)
 
+		if _, ok := context.Data.(AbortSaga); ok {
+			abortSaga(s, context, state, "Promise")
+			return
+		}
+
+		observeReply(s, state, PhasePromise, context.Data)
+
+		if retry, ok := context.Data.(RetryPromise); ok {
+			task, negotiated := state.Negotiation[retry.Account]
+			if !negotiated || !state.IsWaitingFor(retry.Account) {
+				return
+			}
+			log.Debug().Msgf("%s/Promise Retry for %v", state.Transaction.IDTransaction, retry.Account)
+			s.SendMessage(
+				vaultOrder(PromiseOrder, task, state),
+				system.Coordinates{
+					Region: "VaultUnit/" + retry.Account.Tenant,
+					Name:   retry.Account.Name,
+				},
+				system.Coordinates{
+					Region: s.Name,
+					Name:   context.Self.Name,
+				},
+			)
+			return
+		}
+
 		accountRetry := state.Mark(context.Data)
 
 		if accountRetry != nil {
-			log.Debug().Msgf("%s/Promise Bounced for %v", state.Transaction.IDTransaction, accountRetry)
+			if state.PromiseRetries < s.PromiseRetryBudget {
+				state.PromiseRetries++
+				delay := promiseRetryBackoff(s.PromiseRetryBackoff, state.PromiseRetries)
+				log.Debug().Msgf("%s/Promise Bounced for %v, retry %d/%d in %v", state.Transaction.IDTransaction, accountRetry, state.PromiseRetries, s.PromiseRetryBudget, delay)
+				schedulePromiseRetry(s, context.Self, *accountRetry, delay)
+				become(s, context, state, PhasePromise, PromisingTransaction(s))
+				return
+			}
 
-			for account, task := range state.Negotiation {
-				if !(accountRetry.Tenant == account.Tenant && accountRetry.Name != account.Name) {
-					continue
-				}
-				s.SendMessage(
-					CommitOrder+" "+task,
-					system.Coordinates{
-						Region: "VaultUnit/" + account.Tenant,
-						Name:   account.Name,
-					},
-					context.Receiver,
-				)
+			log.Info().Msgf("%s/Promise Bounced for %v, retry budget exhausted", state.Transaction.IDTransaction, accountRetry)
+			exhausted := PromiseWasRejected{
+				Account: *accountRetry,
+				Reason:  "RETRY_BUDGET_EXHAUSTED",
 			}
-			return
+			observeReply(s, state, PhasePromise, exhausted)
+			state.Mark(exhausted)
 		}
 
 		if !state.IsNegotiationFinished() {
-			context.Self.Become(state, PromisingTransaction(s))
+			become(s, context, state, PhasePromise, PromisingTransaction(s))
 			return
 		}
 
+		endPhase(s, state, PhasePromise, false)
+
 		if state.FailedResponses > 0 {
 			state.Transaction.State = persistence.StatusRejected
-			err := persistence.UpdateTransaction(s.Storage, &state.Transaction)
+			err := persistence.UpdateTransaction(s.Storage, s.Events, &state.Transaction)
 			if err != nil {
 				log.Error().Msgf("%s/Promise failed to update transaction %+v", state.Transaction.IDTransaction, err)
 				s.SendMessage(
@@ -152,7 +447,7 @@ func PromisingTransaction(s *System) func(interface{}, system.Context) {
 					state.ReplyTo,
 					context.Receiver,
 				)
-				s.UnregisterActor(context.Sender.Name)
+				s.UnregisterActor(context.Receiver.Name)
 				return
 			}
 		}
@@ -164,6 +459,7 @@ func PromisingTransaction(s *System) func(interface{}, system.Context) {
 				context.Receiver,
 			)
 			log.Debug().Msgf("%s/Promise Rejected All", state.Transaction.IDTransaction)
+			s.UnregisterActor(context.Receiver.Name)
 			return
 		}
 
@@ -172,18 +468,9 @@ func PromisingTransaction(s *System) func(interface{}, system.Context) {
 			log.Debug().Msgf("%s/Promise -> %s/Rollback", state.Transaction.IDTransaction, state.Transaction.IDTransaction)
 
 			state.ResetMarks()
-			context.Self.Become(state, RollbackingTransaction(s))
+			become(s, context, state, PhaseRollback, RollbackingTransaction(s))
 
-			for account, task := range state.Negotiation {
-				s.SendMessage(
-					RollbackOrder+" "+task,
-					system.Coordinates{
-						Region: "VaultUnit/" + account.Tenant,
-						Name:   account.Name,
-					},
-					context.Receiver,
-				)
-			}
+			sendOrders(s, state, RollbackOrder, context.Receiver)
 
 			return
 		}
@@ -192,7 +479,7 @@ func PromisingTransaction(s *System) func(interface{}, system.Context) {
 
 		state.Transaction.State = persistence.StatusAccepted
 
-		err := persistence.UpdateTransaction(s.Storage, &state.Transaction)
+		err := persistence.UpdateTransaction(s.Storage, s.Events, &state.Transaction)
 		if err != nil {
 			s.SendMessage(
 				RespTransactionRefused+" "+state.Transaction.IDTransaction,
@@ -202,24 +489,21 @@ func PromisingTransaction(s *System) func(interface{}, system.Context) {
 
 			log.Warn().Msgf("%s/Promise failed to accept transaction", state.Transaction.IDTransaction)
 
-			s.UnregisterActor(context.Sender.Name)
+			s.UnregisterActor(context.Receiver.Name)
 			return
 		}
 
-		for account, task := range state.Negotiation {
-			s.SendMessage(
-				CommitOrder+" "+task,
-				system.Coordinates{
-					Region: "VaultUnit/" + account.Tenant,
-					Name:   account.Name,
-				},
-				context.Receiver,
-			)
+		if s.Faults.Checkpoint(faults.AfterAcceptedPersisted) {
+			return
 		}
 
 		state.ResetMarks()
-		context.Self.Become(state, CommitingTransaction(s))
+		become(s, context, state, PhaseCommit, CommitingTransaction(s))
+
+		sendOrders(s, state, CommitOrder, context.Receiver)
 		log.Debug().Msgf("%s/Promise -> %s/Commit", state.Transaction.IDTransaction, state.Transaction.IDTransaction)
+
+		s.Faults.Checkpoint(faults.AfterCommitSent)
 		return
 	}
 }
@@ -228,18 +512,32 @@ func PromisingTransaction(s *System) func(interface{}, system.Context) {
 func CommitingTransaction(s *System) func(interface{}, system.Context) {
 	return func(t_state interface{}, context system.Context) {
 		state := t_state.(TransactionState)
+
+		// vaults may have already committed, commit has to finish
+		if _, ok := context.Data.(AbortSaga); ok {
+			s.SendMessage(
+				RespSagaNotAbortable+" "+state.Transaction.IDTransaction,
+				context.Sender,
+				context.Receiver,
+			)
+			return
+		}
+
+		observeReply(s, state, PhaseCommit, context.Data)
 		state.Mark(context.Data)
 		if !state.IsNegotiationFinished() {
-			context.Self.Become(state, CommitingTransaction(s))
+			become(s, context, state, PhaseCommit, CommitingTransaction(s))
 			return
 		}
 
+		endPhase(s, state, PhaseCommit, false)
+
 		if state.FailedResponses > 0 {
 			log.Debug().Msgf("%s/Commit Rejected Some [total: %d, accepted: %d, rejected: %d]", state.Transaction.IDTransaction, len(state.Negotiation), state.FailedResponses, state.OkResponses)
 
 			state.Transaction.State = persistence.StatusRejected
 
-			err := persistence.UpdateTransaction(s.Storage, &state.Transaction)
+			err := persistence.UpdateTransaction(s.Storage, s.Events, &state.Transaction)
 			if err != nil {
 				log.Error().Msgf("%s/Commit failed to update transaction %+v", state.Transaction.IDTransaction, err)
 				s.SendMessage(
@@ -247,23 +545,14 @@ func CommitingTransaction(s *System) func(interface{}, system.Context) {
 					state.ReplyTo,
 					context.Receiver,
 				)
-				s.UnregisterActor(context.Sender.Name)
+				s.UnregisterActor(context.Receiver.Name)
 				return
 			}
 
-			for account, task := range state.Negotiation {
-				s.SendMessage(
-					RollbackOrder+" "+task,
-					system.Coordinates{
-						Region: "VaultUnit/" + account.Tenant,
-						Name:   account.Name,
-					},
-					context.Receiver,
-				)
-			}
-
 			state.ResetMarks()
-			context.Self.Become(state, RollbackingTransaction(s))
+			become(s, context, state, PhaseRollback, RollbackingTransaction(s))
+
+			sendOrders(s, state, RollbackOrder, context.Receiver)
 
 			log.Debug().Msgf("%s/Commit -> %s/Rollback", state.Transaction.IDTransaction, state.Transaction.IDTransaction)
 
@@ -272,9 +561,13 @@ func CommitingTransaction(s *System) func(interface{}, system.Context) {
 
 		log.Debug().Msgf("%s/Commit Accepted All", state.Transaction.IDTransaction)
 
+		if err := persistence.CreateOutboundMark(s.Storage, state.Transaction.IDTransaction); err != nil {
+			log.Warn().Msgf("%s/Commit failed to mark references for publication %+v", state.Transaction.IDTransaction, err)
+		}
+
 		state.Transaction.State = persistence.StatusCommitted
 
-		err := persistence.UpdateTransaction(s.Storage, &state.Transaction)
+		err := persistence.UpdateTransaction(s.Storage, s.Events, &state.Transaction)
 		// FIXME log error
 		if err != nil {
 			s.SendMessage(
@@ -285,26 +578,27 @@ func CommitingTransaction(s *System) func(interface{}, system.Context) {
 
 			log.Warn().Msgf("%s/Commit failed to commit transaction", state.Transaction.IDTransaction)
 
-			s.UnregisterActor(context.Sender.Name)
+			s.UnregisterActor(context.Receiver.Name)
 			return
 		}
 
-		var transfers []string
-		for _, transfer := range state.Transaction.Transfers {
-			transfers = append(transfers, transfer.IDTransfer)
+		err = persistence.PublishInboundReferences(s.Storage, s.SharedStorage, s.Tenant, &state.Transaction)
+		if err != nil {
+			log.Warn().Msgf("%s/Commit %+v", state.Transaction.IDTransaction, err)
 		}
 
 		s.Metrics.TransactionCommitted(len(state.Transaction.Transfers))
+		auditOutcome(s, state)
 		s.SendMessage(
 			RespCreateTransaction+" "+state.Transaction.IDTransaction,
 			state.ReplyTo,
 			context.Receiver,
 		)
 
-		log.Info().Msgf("New Transaction %s Committed", state.Transaction.IDTransaction)
+		log.Info().Msgf("New Transaction %s Committed [trace %s]", state.Transaction.IDTransaction, state.Trace.TraceIDString())
 		log.Debug().Msgf("%s/Commit -> Unregister", state.Transaction.IDTransaction)
 
-		s.UnregisterActor(context.Sender.Name)
+		s.UnregisterActor(context.Receiver.Name)
 		return
 	}
 }
@@ -313,12 +607,34 @@ func CommitingTransaction(s *System) func(interface{}, system.Context) {
 func RollbackingTransaction(s *System) func(interface{}, system.Context) {
 	return func(t_state interface{}, context system.Context) {
 		state := t_state.(TransactionState)
+
+		switch context.Data.(type) {
+
+		case AbortSaga:
+			s.SendMessage(
+				RespSagaNotAbortable+" "+state.Transaction.IDTransaction,
+				context.Sender,
+				context.Receiver,
+			)
+			return
+
+		case RollbackWasAccepted, RollbackWasRejected, FatalErrored:
+
+		default:
+			// late replies of aborted promise or commit
+			return
+
+		}
+
+		observeReply(s, state, PhaseRollback, context.Data)
 		state.Mark(context.Data)
 		if !state.IsNegotiationFinished() {
-			context.Self.Become(state, RollbackingTransaction(s))
+			become(s, context, state, PhaseRollback, RollbackingTransaction(s))
 			return
 		}
 
+		endPhase(s, state, PhaseRollback, false)
+
 		if state.FailedResponses > 0 {
 			s.SendMessage(
 				RespTransactionRefused+" "+state.Transaction.IDTransaction,
@@ -328,7 +644,7 @@ func RollbackingTransaction(s *System) func(interface{}, system.Context) {
 
 			log.Debug().Msgf("%s/Rollback Rejected Some [total: %d, accepted: %d, rejected: %d]", state.Transaction.IDTransaction, len(state.Negotiation), state.FailedResponses, state.OkResponses)
 
-			s.UnregisterActor(context.Sender.Name)
+			s.UnregisterActor(context.Receiver.Name)
 			return
 		}
 
@@ -339,7 +655,7 @@ func RollbackingTransaction(s *System) func(interface{}, system.Context) {
 
 		state.Transaction.State = persistence.StatusRollbacked
 
-		err := persistence.UpdateTransaction(s.Storage, &state.Transaction)
+		err := persistence.UpdateTransaction(s.Storage, s.Events, &state.Transaction)
 		if err != nil {
 			log.Error().Msgf("%s/Rollback failed to update transaction %+v", state.Transaction.IDTransaction, err)
 			s.SendMessage(
@@ -350,11 +666,12 @@ func RollbackingTransaction(s *System) func(interface{}, system.Context) {
 
 			log.Warn().Msgf("%s/Rollback failed to rollback transaction", state.Transaction.IDTransaction)
 
-			s.UnregisterActor(context.Sender.Name)
+			s.UnregisterActor(context.Receiver.Name)
 			return
 		}
 
 		s.Metrics.TransactionRollbacked(len(state.Transaction.Transfers))
+		auditOutcome(s, state)
 
 		s.SendMessage(
 			RespTransactionRejected+" "+state.Transaction.IDTransaction+" "+state.Transaction.State,
@@ -362,10 +679,10 @@ func RollbackingTransaction(s *System) func(interface{}, system.Context) {
 			context.Receiver,
 		)
 
-		log.Info().Msgf("New Transaction %s Rollbacked", state.Transaction.IDTransaction)
+		log.Info().Msgf("New Transaction %s Rollbacked [trace %s]", state.Transaction.IDTransaction, state.Trace.TraceIDString())
 		log.Debug().Msgf("%s/Rollback -> Unregister", state.Transaction.IDTransaction)
 
-		s.UnregisterActor(context.Sender.Name)
+		s.UnregisterActor(context.Receiver.Name)
 		return
 	}
 }
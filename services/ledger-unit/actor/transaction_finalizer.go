@@ -15,6 +15,7 @@
 package actor
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/jancajthaml-openbank/ledger-unit/model"
@@ -25,32 +26,47 @@ import (
 
 // TransactionFinalizer represents journal saturation update subroutine
 type TransactionFinalizer struct {
-	callback func(transaction model.Transaction)
-	storage  localfs.Storage
+	callback   func(transaction model.Transaction)
+	storage    localfs.Storage
+	interval   int64
+	staleAfter time.Duration
+	lastScan   time.Time
 }
 
-// NewTransactionFinalizer returns snapshot updater fascade
-func NewTransactionFinalizer(rootStorage string, callback func(transaction model.Transaction)) *TransactionFinalizer {
+// NewTransactionFinalizer returns snapshot updater fascade, unfinished
+// transaction not updated for staleAfter is considered abandoned by its saga
+func NewTransactionFinalizer(rootStorage string, interval time.Duration, staleAfter time.Duration, callback func(transaction model.Transaction)) *TransactionFinalizer {
 	storage, err := localfs.NewPlaintextStorage(rootStorage)
 	if err != nil {
 		log.Error().Msgf("Failed to ensure storage %+v", err)
 		return nil
 	}
 	return &TransactionFinalizer{
-		callback: callback,
-		storage:  storage,
+		callback:   callback,
+		storage:    storage,
+		interval:   int64(interval),
+		staleAfter: staleAfter,
+		lastScan:   time.Now(),
 	}
 }
 
-func (scan *TransactionFinalizer) getTransactions() []string {
+// SetInterval changes backoff between scans
+func (scan *TransactionFinalizer) SetInterval(interval time.Duration) {
 	if scan == nil {
-		return nil
+		return
 	}
-	result, err := scan.storage.ListDirectory("transaction", true)
-	if err != nil {
-		return nil
+	atomic.StoreInt64(&scan.interval, int64(interval))
+}
+
+func (scan *TransactionFinalizer) getTransactions() ([]string, error) {
+	if scan == nil {
+		return nil, nil
+	}
+	exists, err := scan.storage.Exists("transaction")
+	if err != nil || !exists {
+		return nil, err
 	}
-	return result
+	return scan.storage.ListDirectory("transaction", true)
 }
 
 func (scan *TransactionFinalizer) finalizeStaleTransactions() {
@@ -58,7 +74,11 @@ func (scan *TransactionFinalizer) finalizeStaleTransactions() {
 		return
 	}
 	log.Info().Msg("Performing stale transactions scan")
-	transactions := scan.getTransactions()
+	transactions, err := scan.getTransactions()
+	if err != nil {
+		log.Warn().Msgf("Failed to list transactions %+v", err)
+		return
+	}
 	for _, transaction := range transactions {
 		instance := scan.getTransaction(transaction)
 		if instance == nil {
@@ -67,6 +87,9 @@ func (scan *TransactionFinalizer) finalizeStaleTransactions() {
 		log.Info().Msgf("Transaction %s in state %s needs completion", transaction, instance.State)
 		scan.callback(*instance)
 	}
+	if err = persistence.UpdateLastFinalizerScan(scan.storage, time.Now()); err != nil {
+		log.Warn().Msgf("Failed to record finalizer scan %+v", err)
+	}
 }
 
 func (scan *TransactionFinalizer) getTransaction(id string) *model.Transaction {
@@ -77,7 +100,7 @@ func (scan *TransactionFinalizer) getTransaction(id string) *model.Transaction {
 	if err != nil {
 		return nil
 	}
-	if time.Now().Sub(modTime).Seconds() < 120 { // FIXME configurable
+	if time.Now().Sub(modTime) < scan.staleAfter {
 		return nil
 	}
 	state, err := persistence.LoadTransactionState(scan.storage, id)
@@ -85,7 +108,9 @@ func (scan *TransactionFinalizer) getTransaction(id string) *model.Transaction {
 		return nil
 	}
 	if state == persistence.StatusCommitted || state == persistence.StatusRollbacked {
-		return nil
+		if !persistence.HasPendingUpdate(scan.storage, id) && !persistence.HasOutboundMark(scan.storage, id) {
+			return nil
+		}
 	}
 	transaction, err := persistence.LoadTransaction(scan.storage, id)
 	if err != nil {
@@ -99,11 +124,15 @@ func (scan *TransactionFinalizer) Setup() error {
 	return nil
 }
 
-// Work finalizes stale transactions
+// Work finalizes stale transactions once interval since last scan elapsed
 func (scan *TransactionFinalizer) Work() {
 	if scan == nil {
 		return
 	}
+	if time.Now().Sub(scan.lastScan) < time.Duration(atomic.LoadInt64(&scan.interval)) {
+		return
+	}
+	scan.lastScan = time.Now()
 	scan.finalizeStaleTransactions()
 }
 
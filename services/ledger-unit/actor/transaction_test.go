@@ -0,0 +1,26 @@
+package actor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromiseRetryBackoff(t *testing.T) {
+	expectations := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{7, 6400 * time.Millisecond},
+		{8, maxPromiseRetryBackoff},
+		{100, maxPromiseRetryBackoff},
+	}
+	for _, expectation := range expectations {
+		actual := promiseRetryBackoff(100*time.Millisecond, expectation.attempt)
+		if actual != expectation.expected {
+			t.Errorf("attempt %d expected %v got %v", expectation.attempt, expectation.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actor
+
+import (
+	system "github.com/jancajthaml-openbank/actor-system"
+)
+
+// Transport delivers messages of actor system to other regions, it replaces
+// lake when set on System
+type Transport interface {
+	Send(msg string, to system.Coordinates, from system.Coordinates)
+}
+
+// SendMessage sends message through transport if set or through lake
+// otherwise, messages within own region are always delivered locally and
+// messages without recipient or dropped by fault injection are discarded
+func (system *System) SendMessage(msg string, to system.Coordinates, from system.Coordinates) {
+	if to.Region == "" || system.Faults.DropMessage(msg) {
+		return
+	}
+	if system.Transport != nil && to.Region != system.Name {
+		system.Transport.Send(msg, to, from)
+		return
+	}
+	system.System.SendMessage(msg, to, from)
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const rotatedLayout = "20060102T150405.000000000Z"
+
+// Amount is amount of single transfer of transaction
+type Amount struct {
+	Transfer string `json:"transfer"`
+	Value    string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Entry is single record of audit trail
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Service     string    `json:"service"`
+	Action      string    `json:"action"`
+	Outcome     string    `json:"outcome"`
+	Tenant      string    `json:"tenant,omitempty"`
+	Principal   string    `json:"principal,omitempty"`
+	Client      string    `json:"client,omitempty"`
+	RequestID   string    `json:"requestId,omitempty"`
+	Trace       string    `json:"trace,omitempty"`
+	Status      int       `json:"status,omitempty"`
+	Transaction string    `json:"transaction,omitempty"`
+	Amounts     []Amount  `json:"amounts,omitempty"`
+}
+
+// Sink appends audit entries as JSON lines into file of given name, file is
+// rotated once it exceeds maximum size and rotated files are removed after
+// retention elapses
+type Sink struct {
+	mutex     sync.Mutex
+	directory string
+	name      string
+	maxSize   int64
+	retention time.Duration
+	file      *os.File
+	size      int64
+}
+
+// NewSink returns audit sink writing into directory, empty directory disables
+// audit trail, zero maximum size disables rotation and zero retention keeps
+// rotated files indefinitely
+func NewSink(directory string, name string, maxSize int64, retention time.Duration) *Sink {
+	if directory != "" {
+		if err := os.MkdirAll(directory, 0750); err != nil {
+			log.Error().Msgf("Failed to ensure audit directory %+v", err)
+			return nil
+		}
+	}
+	return &Sink{
+		directory: directory,
+		name:      name,
+		maxSize:   maxSize,
+		retention: retention,
+	}
+}
+
+func (sink *Sink) currentPath() string {
+	return filepath.Join(sink.directory, sink.name+".jsonl")
+}
+
+func (sink *Sink) open() error {
+	file, err := os.OpenFile(sink.currentPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	sink.file = file
+	sink.size = info.Size()
+	return nil
+}
+
+func (sink *Sink) rotate(now time.Time) error {
+	if sink.file != nil {
+		sink.file.Close()
+		sink.file = nil
+	}
+	target := filepath.Join(sink.directory, sink.name+"."+now.UTC().Format(rotatedLayout)+".jsonl")
+	for {
+		// never overwrite already rotated file
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			break
+		}
+		now = now.Add(time.Nanosecond)
+		target = filepath.Join(sink.directory, sink.name+"."+now.UTC().Format(rotatedLayout)+".jsonl")
+	}
+	if err := os.Rename(sink.currentPath(), target); err != nil {
+		return err
+	}
+	if err := os.Chmod(target, 0440); err != nil {
+		log.Warn().Msgf("Failed to seal rotated audit file %s %+v", target, err)
+	}
+	return sink.open()
+}
+
+// Write appends entry to audit trail
+func (sink *Sink) Write(entry Entry) error {
+	if sink == nil || sink.directory == "" {
+		return nil
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+	entry.Service = sink.name
+	chunk, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	chunk = append(chunk, '\n')
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if sink.file == nil {
+		if err = sink.open(); err != nil {
+			return err
+		}
+	}
+	if sink.maxSize > 0 && sink.size > 0 && sink.size+int64(len(chunk)) > sink.maxSize {
+		if err = sink.rotate(entry.Time); err != nil {
+			return err
+		}
+	}
+	written, err := sink.file.Write(chunk)
+	sink.size += int64(written)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Record appends entry to audit trail logging failure
+func (sink *Sink) Record(entry Entry) {
+	if err := sink.Write(entry); err != nil {
+		log.Error().Msgf("Failed to write audit entry %s of %s %+v", entry.Action, entry.Tenant, err)
+	}
+}
+
+// Rotated returns rotated files of this sink with time of their rotation
+func (sink *Sink) Rotated() (map[string]time.Time, error) {
+	result := make(map[string]time.Time)
+	if sink == nil || sink.directory == "" {
+		return result, nil
+	}
+	files, err := ioutil.ReadDir(sink.directory)
+	if err != nil {
+		return nil, err
+	}
+	prefix := sink.name + "."
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		rotatedAt, err := time.Parse(rotatedLayout, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".jsonl"))
+		if err != nil {
+			continue
+		}
+		result[filepath.Join(sink.directory, name)] = rotatedAt
+	}
+	return result, nil
+}
+
+// Setup does nothing
+func (sink *Sink) Setup() error {
+	if sink == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	return nil
+}
+
+// Work removes rotated files older than retention
+func (sink *Sink) Work() {
+	if sink == nil || sink.retention <= 0 {
+		return
+	}
+	rotated, err := sink.Rotated()
+	if err != nil {
+		log.Warn().Msgf("Failed to list rotated audit files %+v", err)
+		return
+	}
+	deadline := time.Now().Add(-sink.retention)
+	for path, rotatedAt := range rotated {
+		if rotatedAt.After(deadline) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Warn().Msgf("Failed to remove expired audit file %s %+v", path, err)
+			continue
+		}
+		log.Info().Msgf("Removed expired audit file %s", path)
+	}
+}
+
+// Cancel closes audit file
+func (sink *Sink) Cancel() {
+	if sink == nil {
+		return
+	}
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if sink.file != nil {
+		sink.file.Close()
+		sink.file = nil
+	}
+}
+
+// Done always returns done
+func (sink *Sink) Done() <-chan interface{} {
+	done := make(chan interface{})
+	close(done)
+	return done
+}
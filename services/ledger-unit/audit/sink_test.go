@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSink(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	t.Log("appends entries as json lines")
+	{
+		sink := NewSink(tmpdir, "lines", 0, 0)
+		defer sink.Cancel()
+
+		for _, id := range []string{"1", "2"} {
+			err := sink.Write(Entry{
+				Action:      "transaction.create",
+				Outcome:     "committed",
+				Tenant:      "one",
+				Transaction: id,
+				Amounts:     []Amount{{Transfer: "1", Value: "1.0", Currency: "EUR"}},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error %+v", err)
+			}
+		}
+
+		file, err := os.Open(filepath.Join(tmpdir, "lines.jsonl"))
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		defer file.Close()
+
+		entries := make([]Entry, 0)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var entry Entry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatalf("unexpected error %+v", err)
+			}
+			entries = append(entries, entry)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries got %d", len(entries))
+		}
+		if entries[1].Service != "lines" || entries[1].Transaction != "2" || entries[1].Time.IsZero() {
+			t.Errorf("unexpected entry %+v", entries[1])
+		}
+		if entries[0].Amounts[0].Value != "1.0" {
+			t.Errorf("unexpected amounts %+v", entries[0].Amounts)
+		}
+	}
+
+	t.Log("rotates and expires files")
+	{
+		sink := NewSink(tmpdir, "rotating", 10, time.Hour)
+		defer sink.Cancel()
+
+		past := time.Now().Add(-2 * time.Hour)
+		if err := sink.Write(Entry{Time: past, Action: "a"}); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if err := sink.Write(Entry{Time: past, Action: "b"}); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if err := sink.Write(Entry{Action: "c"}); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+
+		rotated, err := sink.Rotated()
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if len(rotated) != 2 {
+			t.Fatalf("expected 2 rotated files got %d", len(rotated))
+		}
+
+		sink.Work()
+
+		rotated, err = sink.Rotated()
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if len(rotated) != 1 {
+			t.Errorf("expected 1 rotated file after retention got %d", len(rotated))
+		}
+	}
+
+	t.Log("disabled without directory")
+	{
+		sink := NewSink("", "disabled", 0, 0)
+		if err := sink.Write(Entry{Action: "a"}); err != nil {
+			t.Errorf("unexpected error %+v", err)
+		}
+	}
+}
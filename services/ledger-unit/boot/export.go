@@ -0,0 +1,125 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/config"
+	"github.com/jancajthaml-openbank/ledger-unit/export"
+	"github.com/jancajthaml-openbank/ledger-unit/support/naming"
+)
+
+// ExitCodeExportFailed is exit code of export that could not be finished
+const ExitCodeExportFailed = 1
+
+// ExitCodeExportUsage is exit code of invalid invocation of export
+const ExitCodeExportUsage = 2
+
+// Export writes transfers of tenant given by arguments of export subcommand
+// to output and returns exit code, cursor of next chunk is written to standard
+// error when limit cuts export short
+func Export(args []string, output io.Writer) int {
+	cfg := config.LoadConfig()
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ledger-unit export [-format csv|jsonl|parquet] [-from time] [-to time] [-value-from time] [-value-to time] [-after cursor] [-limit n] [tenant]")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", export.FormatCSV, "output format, one of csv, jsonl and parquet")
+	from := flags.String("from", "", "export transactions recorded at or after time, RFC3339 time or date")
+	to := flags.String("to", "", "export transactions recorded before time, RFC3339 time or date")
+	valueFrom := flags.String("value-from", "", "export transfers with value date at or after time, RFC3339 time or date")
+	valueTo := flags.String("value-to", "", "export transfers with value date before time, RFC3339 time or date")
+	after := flags.String("after", "", "continue export after cursor of previous chunk")
+	limit := flags.Int("limit", 0, "number of transactions in chunk, zero exports all")
+	if err := flags.Parse(args); err != nil {
+		return ExitCodeExportUsage
+	}
+
+	tenant := cfg.Tenant
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		tenant = flags.Arg(0)
+	default:
+		flags.Usage()
+		return ExitCodeExportUsage
+	}
+	if err := naming.ValidateTenant(tenant); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid tenant %q, %s\n", tenant, err.Error())
+		return ExitCodeExportUsage
+	}
+
+	var filter export.Filter
+	for _, bound := range []struct {
+		target *time.Time
+		value  string
+	}{
+		{&filter.From, *from},
+		{&filter.To, *to},
+		{&filter.ValueFrom, *valueFrom},
+		{&filter.ValueTo, *valueTo},
+	} {
+		at, err := export.ParseBound(bound.value)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return ExitCodeExportUsage
+		}
+		*bound.target = at
+	}
+
+	buffered := bufio.NewWriter(output)
+	writer, err := export.NewWriter(*format, buffered)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return ExitCodeExportUsage
+	}
+
+	rootStorage := cfg.SharedStorage + "/t_" + tenant
+	if _, err = os.Stat(rootStorage); err != nil {
+		fmt.Fprintf(os.Stderr, "no journal of tenant %s %+v\n", tenant, err)
+		return ExitCodeExportFailed
+	}
+
+	exporter := export.NewExporter(rootStorage)
+	ids, err := exporter.Transactions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to list transactions of tenant %s %+v\n", tenant, err)
+		return ExitCodeExportFailed
+	}
+	chunk, next := export.Chunk(ids, *after, *limit)
+
+	if err = exporter.Export(chunk, filter, writer); err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to export transactions of tenant %s %+v\n", tenant, err)
+		return ExitCodeExportFailed
+	}
+	if next != "" {
+		fmt.Fprintf(os.Stderr, "next cursor %s\n", next)
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jancajthaml-openbank/ledger-unit/config"
+	"github.com/jancajthaml-openbank/ledger-unit/fsck"
+	"github.com/jancajthaml-openbank/ledger-unit/support/naming"
+)
+
+// Exit codes of fsck subcommand, they correspond to exit codes of fsck(8)
+const (
+	// ExitCodeFsckClean is exit code of check that found no problems
+	ExitCodeFsckClean = 0
+	// ExitCodeFsckRepaired is exit code of check that repaired all problems
+	ExitCodeFsckRepaired = 1
+	// ExitCodeFsckUnresolved is exit code of check that left problems
+	// unresolved
+	ExitCodeFsckUnresolved = 4
+	// ExitCodeFsckFailed is exit code of check that could not be performed
+	ExitCodeFsckFailed = 8
+	// ExitCodeFsckUsage is exit code of invalid invocation of fsck
+	ExitCodeFsckUsage = 16
+)
+
+// Fsck checks journal of tenant given by arguments of fsck subcommand, writes
+// report as JSON to output and returns exit code
+func Fsck(args []string, output io.Writer) int {
+	cfg := config.LoadConfig()
+
+	flags := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ledger-unit fsck [-repair] [-stale-after duration] [tenant]")
+		flags.PrintDefaults()
+	}
+	repair := flags.Bool("repair", false, "quarantine corrupt files and complete interrupted updates")
+	staleAfter := flags.Duration("stale-after", cfg.TransactionStaleAfter, "report non terminal transactions not updated for longer, zero disables")
+	if err := flags.Parse(args); err != nil {
+		return ExitCodeFsckUsage
+	}
+
+	tenant := cfg.Tenant
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		tenant = flags.Arg(0)
+	default:
+		flags.Usage()
+		return ExitCodeFsckUsage
+	}
+	if err := naming.ValidateTenant(tenant); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid tenant %q, %s\n", tenant, err.Error())
+		return ExitCodeFsckUsage
+	}
+
+	rootStorage := cfg.SharedStorage + "/t_" + tenant
+	if _, err := os.Stat(rootStorage); err != nil {
+		fmt.Fprintf(os.Stderr, "no journal of tenant %s %+v\n", tenant, err)
+		return ExitCodeFsckFailed
+	}
+
+	report, err := fsck.NewChecker(tenant, rootStorage, cfg.SharedStorage, *staleAfter, *repair).Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to check journal of tenant %s %+v\n", tenant, err)
+		return ExitCodeFsckFailed
+	}
+	if err = json.NewEncoder(output).Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report %+v\n", err)
+		return ExitCodeFsckFailed
+	}
+
+	switch {
+	case report.Unresolved() > 0:
+		return ExitCodeFsckUnresolved
+	case len(report.Findings) > 0:
+		return ExitCodeFsckRepaired
+	default:
+		return ExitCodeFsckClean
+	}
+}
@@ -15,18 +15,21 @@
 package boot
 
 import (
-	"github.com/rs/xid"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/jancajthaml-openbank/ledger-unit/actor"
+	"github.com/jancajthaml-openbank/ledger-unit/audit"
 	"github.com/jancajthaml-openbank/ledger-unit/config"
 	"github.com/jancajthaml-openbank/ledger-unit/metrics"
 	"github.com/jancajthaml-openbank/ledger-unit/model"
 	"github.com/jancajthaml-openbank/ledger-unit/support/concurrent"
+	"github.com/jancajthaml-openbank/ledger-unit/support/faults"
+	"github.com/jancajthaml-openbank/ledger-unit/support/host"
 	"github.com/jancajthaml-openbank/ledger-unit/support/logging"
-
-	system "github.com/jancajthaml-openbank/actor-system"
+	"github.com/jancajthaml-openbank/ledger-unit/support/naming"
+	"github.com/jancajthaml-openbank/ledger-unit/tracing"
 )
 
 // Program encapsulate program
@@ -45,62 +48,149 @@ func NewProgram() Program {
 	}
 }
 
-// Setup setups program
-func (prog *Program) Setup() {
+// ExitCodeInvalidConfiguration is exit code of program refusing to start
+// because of invalid configuration, it corresponds to EX_CONFIG of sysexits
+const ExitCodeInvalidConfiguration = 78
+
+// ExitCodeInjectedAbort is exit code of program aborted at checkpoint by fault
+// injection, it corresponds to SIGABRT termination
+const ExitCodeInjectedAbort = 134
+
+// Setup setups program, returns error if configuration is invalid
+func (prog *Program) Setup() error {
 	if prog == nil {
-		return
+		return fmt.Errorf("nil pointer")
 	}
 
 	logging.SetupLogger(prog.cfg.LogLevel)
 
+	if err := naming.ValidateTenant(prog.cfg.Tenant); err != nil {
+		log.Error().Msgf("Invalid LEDGER_TENANT %q, %s", prog.cfg.Tenant, err.Error())
+		return err
+	}
+
+	diskMonitorWorker := host.NewDiskMonitor(
+		prog.cfg.MinFreeDiskSpace,
+		prog.cfg.RootStorage,
+	)
+
+	memoryMonitorWorker := host.NewMemoryMonitor(
+		prog.cfg.MinFreeMemory,
+	)
+
 	metricsWorker := metrics.NewMetrics(
 		prog.cfg.Tenant,
 		prog.cfg.MetricsStastdEndpoint,
+		prog.cfg.RootStorage,
+		diskMonitorWorker,
+		memoryMonitorWorker,
+	)
+
+	tracingExporter := tracing.NewExporter(
+		prog.cfg.TracingEndpoint,
+		"ledger-unit",
+	)
+
+	auditSink := audit.NewSink(
+		prog.cfg.AuditDirectory,
+		"ledger-unit@"+prog.cfg.Tenant,
+		prog.cfg.AuditMaxSize,
+		prog.cfg.AuditRetention,
+	)
+
+	faultInjector, err := faults.NewInjector(
+		prog.cfg.FaultInjection,
+		time.Now().UnixNano(),
+		func(checkpoint faults.Checkpoint) {
+			os.Exit(ExitCodeInjectedAbort)
+		},
 	)
+	if err != nil {
+		log.Error().Msgf("Invalid LEDGER_FAULT_INJECTION %s", err.Error())
+		return err
+	}
 
 	actorSystem := actor.NewActorSystem(
 		prog.cfg.Tenant,
 		prog.cfg.LakeHostname,
 		prog.cfg.RootStorage,
+		prog.cfg.SharedStorage,
+		prog.cfg.PromiseRetryBudget,
+		prog.cfg.PromiseRetryBackoff,
 		metricsWorker,
+		tracingExporter,
+		auditSink,
+		faultInjector,
 	)
 
 	transactionFinalizerWorker := actor.NewTransactionFinalizer(
 		prog.cfg.RootStorage,
+		prog.cfg.TransactionIntegrityScanInterval,
+		prog.cfg.TransactionStaleAfter,
 		func(transaction model.Transaction) {
-			name := "transaction/" + xid.New().String()
-			ref, err := actor.NewTransactionActor(actorSystem, name)
-			if err != nil {
-				return
-			}
-			ref.Tell(
-				transaction,
-				system.Coordinates{
-					Region: actorSystem.Name,
-					Name:   name,
-				},
-				system.Coordinates{
-					Region: actorSystem.Name,
-					Name:   "transaction_finalizer_cron",
-				},
-			)
+			actor.ResumeTransaction(actorSystem, transaction)
 		},
 	)
 
+	tenantConfigWatcher := actor.NewTenantConfigWatcher(
+		prog.cfg.RootStorage,
+		model.TenantConfig{
+			FinalizerInterval: prog.cfg.TransactionIntegrityScanInterval,
+			LogLevel:          prog.cfg.LogLevel,
+		},
+		func(config model.TenantConfig) {
+			logging.SetupLogger(config.LogLevel)
+			actorSystem.SetTenantConfig(config)
+			transactionFinalizerWorker.SetInterval(config.FinalizerInterval)
+		},
+	)
+
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"tenant-config",
+		tenantConfigWatcher,
+		time.Second,
+	))
+
 	prog.pool.Register(concurrent.NewOneShotDaemon(
 		"actor-system",
 		actorSystem,
 	))
 
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"disk-monitor",
+		diskMonitorWorker,
+		time.Second,
+	))
+
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"memory-monitor",
+		memoryMonitorWorker,
+		time.Second,
+	))
+
 	prog.pool.Register(concurrent.NewScheduledDaemon(
 		"metrics",
 		metricsWorker,
 		time.Second,
 	))
 
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"audit",
+		auditSink,
+		time.Minute,
+	))
+
+	prog.pool.Register(concurrent.NewScheduledDaemon(
+		"tracing",
+		tracingExporter,
+		time.Second,
+	))
+
 	prog.pool.Register(concurrent.NewScheduledDaemon(
 		"transaction-finalizer",
 		transactionFinalizerWorker,
-		prog.cfg.TransactionIntegrityScanInterval,
+		time.Second,
 	))
+
+	return nil
 }
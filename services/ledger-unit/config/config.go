@@ -27,13 +27,44 @@ type Configuration struct {
 	LakeHostname string
 	// RootStorage gives where to store journals
 	RootStorage string
+	// SharedStorage gives where journals of all tenants are stored
+	SharedStorage string
 	// LogLevel ignorecase log level
 	LogLevel string
 	// MetricsStastdEndpoint represents statsd daemon hostname
 	MetricsStastdEndpoint string
+	// AuditDirectory represents directory of audit trail, empty disables it
+	AuditDirectory string
+	// AuditMaxSize represents size in bytes after which audit file is rotated,
+	// zero disables rotation
+	AuditMaxSize int64
+	// AuditRetention represents how long rotated audit files are kept, zero
+	// keeps them indefinitely
+	AuditRetention time.Duration
+	// TracingEndpoint represents OTLP/HTTP traces endpoint of collector, empty
+	// disables export of spans
+	TracingEndpoint string
+	// PromiseRetryBudget represents how many times bounced promises of single
+	// transaction are retried before transaction is rolled back
+	PromiseRetryBudget int
+	// PromiseRetryBackoff represents initial backoff before bounced promise is
+	// retried, it doubles with every retry
+	PromiseRetryBackoff time.Duration
 	// TransactionIntegrityScanInterval represents backoff between scan for
 	// non terminal transactions
 	TransactionIntegrityScanInterval time.Duration
+	// TransactionStaleAfter represents age of last journal update after which
+	// non terminal transaction is considered abandoned and is finalized
+	TransactionStaleAfter time.Duration
+	// MinFreeDiskSpace represents threshold of free disk space under which
+	// storage is reported unhealthy
+	MinFreeDiskSpace uint64
+	// MinFreeMemory represents threshold of available memory under which
+	// memory is reported unhealthy
+	MinFreeMemory uint64
+	// FaultInjection represents rules of faults injected in test mode, empty
+	// disables fault injection
+	FaultInjection string
 }
 
 // LoadConfig loads application configuration
@@ -42,8 +73,19 @@ func LoadConfig() Configuration {
 		Tenant:                           envString("LEDGER_TENANT", ""),
 		LakeHostname:                     envString("LEDGER_LAKE_HOSTNAME", "127.0.0.1"),
 		RootStorage:                      envString("LEDGER_STORAGE", "/data") + "/" + "t_" + envString("LEDGER_TENANT", ""),
+		SharedStorage:                    envString("LEDGER_STORAGE", "/data"),
 		LogLevel:                         strings.ToUpper(envString("LEDGER_LOG_LEVEL", "INFO")),
+		PromiseRetryBudget:               envInteger("LEDGER_PROMISE_RETRY_BUDGET", 5),
+		PromiseRetryBackoff:              envDuration("LEDGER_PROMISE_RETRY_BACKOFF", 100*time.Millisecond),
 		TransactionIntegrityScanInterval: envDuration("LEDGER_TRANSACTION_INTEGRITY_SCANINTERVAL", 5*time.Minute),
+		TransactionStaleAfter:            envDuration("LEDGER_TRANSACTION_STALE_AFTER", 2*time.Minute),
 		MetricsStastdEndpoint:            envString("LEDGER_STATSD_ENDPOINT", "127.0.0.1:8125"),
+		TracingEndpoint:                  envString("LEDGER_TRACING_ENDPOINT", ""),
+		AuditDirectory:                   envString("LEDGER_AUDIT_DIRECTORY", "/var/log/ledger/audit"),
+		AuditMaxSize:                     int64(envInteger("LEDGER_AUDIT_MAX_SIZE", 100*1024*1024)),
+		AuditRetention:                   envDuration("LEDGER_AUDIT_RETENTION", 0),
+		MinFreeDiskSpace:                 uint64(envInteger("LEDGER_STORAGE_THRESHOLD", 0)),
+		MinFreeMemory:                    uint64(envInteger("LEDGER_MEMORY_THRESHOLD", 0)),
+		FaultInjection:                   envString("LEDGER_FAULT_INJECTION", ""),
 	}
 }
@@ -28,14 +28,47 @@ func TestGetConfig(t *testing.T) {
 		if config.RootStorage != "/data/t_" {
 			t.Errorf("RootStorage default value is not /data/t_")
 		}
+		if config.SharedStorage != "/data" {
+			t.Errorf("SharedStorage default value is not /data")
+		}
 		if config.LogLevel != "INFO" {
 			t.Errorf("LogLevel default value is not INFO")
 		}
 		if config.TransactionIntegrityScanInterval != 5*time.Minute {
 			t.Errorf("TransactionIntegrityScanInterval default value is not 5m")
 		}
+		if config.TransactionStaleAfter != 2*time.Minute {
+			t.Errorf("TransactionStaleAfter default value is not 2m")
+		}
+		if config.PromiseRetryBudget != 5 {
+			t.Errorf("PromiseRetryBudget default value is not 5")
+		}
+		if config.PromiseRetryBackoff != 100*time.Millisecond {
+			t.Errorf("PromiseRetryBackoff default value is not 100ms")
+		}
+		if config.FaultInjection != "" {
+			t.Errorf("FaultInjection default value is not empty")
+		}
 		if config.MetricsStastdEndpoint != "127.0.0.1:8125" {
 			t.Errorf("MetricsStastdEndpoint default value is not 127.0.0.1:8125")
 		}
+		if config.TracingEndpoint != "" {
+			t.Errorf("TracingEndpoint default value is not empty")
+		}
+		if config.AuditDirectory != "/var/log/ledger/audit" {
+			t.Errorf("AuditDirectory default value is not /var/log/ledger/audit")
+		}
+		if config.AuditMaxSize != 100*1024*1024 {
+			t.Errorf("AuditMaxSize default value is not 100MiB")
+		}
+		if config.AuditRetention != 0 {
+			t.Errorf("AuditRetention default value is not 0")
+		}
+		if config.MinFreeDiskSpace != uint64(0) {
+			t.Errorf("MinFreeDiskSpace default value is not 0")
+		}
+		if config.MinFreeMemory != uint64(0) {
+			t.Errorf("MinFreeMemory default value is not 0")
+		}
 	}
 }
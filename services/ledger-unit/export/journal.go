@@ -0,0 +1,117 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// Exporter represents export of journal of single tenant
+type Exporter struct {
+	storage localfs.Storage
+}
+
+// NewExporter returns exporter of journal in given storage
+func NewExporter(rootStorage string) *Exporter {
+	storage, err := localfs.NewPlaintextStorage(rootStorage)
+	if err != nil {
+		return nil
+	}
+	return &Exporter{
+		storage: storage,
+	}
+}
+
+// Transactions returns ids of all transactions in journal
+func (exporter *Exporter) Transactions() ([]string, error) {
+	if exporter == nil {
+		return nil, nil
+	}
+	exists, err := exporter.storage.Exists("transaction")
+	if err != nil || !exists {
+		return nil, err
+	}
+	return exporter.storage.ListDirectory("transaction", true)
+}
+
+// Export writes transfers of given transactions accepted by filter
+func (exporter *Exporter) Export(ids []string, filter Filter, writer Writer) error {
+	if exporter == nil {
+		return nil
+	}
+	for _, id := range ids {
+		recordedAt, err := exporter.recordedAt(id)
+		if err != nil {
+			return err
+		}
+		if !filter.AcceptsRecorded(recordedAt) {
+			continue
+		}
+		transaction, err := persistence.LoadTransaction(exporter.storage, id)
+		if err != nil {
+			return err
+		}
+		for _, row := range rowsOf(transaction, recordedAt, filter) {
+			if err = writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordedAt returns time of first event of transaction, journal entry
+// missing in creation index falls back to time of its last update
+func (exporter *Exporter) recordedAt(id string) (time.Time, error) {
+	if at, ok := persistence.LoadCreation(exporter.storage, id); ok {
+		return at, nil
+	}
+	return exporter.storage.LastModification("transaction/" + id)
+}
+
+// rowsOf flattens transfers of transaction accepted by filter
+func rowsOf(transaction *model.Transaction, recordedAt time.Time, filter Filter) []Row {
+	result := make([]Row, 0, len(transaction.Transfers))
+	for _, transfer := range transaction.Transfers {
+		valueDate := transfer.ValueDate
+		if at, err := time.Parse(time.RFC3339, transfer.ValueDate); err == nil {
+			if !filter.AcceptsValueDate(at) {
+				continue
+			}
+			valueDate = at.UTC().Format(time.RFC3339)
+		} else if filter.HasValueRange() {
+			continue
+		}
+		result = append(result, Row{
+			TransactionID:     transaction.IDTransaction,
+			TransactionStatus: transaction.State,
+			RecordedAt:        recordedAt.UTC().Format(time.RFC3339),
+			TransferID:        transfer.IDTransfer,
+			ValueDate:         valueDate,
+			CreditTenant:      transfer.Credit.Tenant,
+			CreditAccount:     transfer.Credit.Name,
+			DebitTenant:       transfer.Debit.Tenant,
+			DebitAccount:      transfer.Debit.Name,
+			Amount:            transfer.Amount.String(),
+			Currency:          transfer.Currency,
+		})
+	}
+	return result
+}
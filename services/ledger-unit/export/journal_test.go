@@ -0,0 +1,88 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+func TestExport(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	storage.WriteFile("transaction/x1", []byte("committed\na one A two B 2020-01-01T00:00:00Z 1.5 EUR\nb one A two B 2020-02-01T00:00:00+01:00 2 EUR\n"))
+	storage.WriteFile("transaction/x2", []byte("rollbacked\nc one A two B 2020-03-01T00:00:00Z 3 EUR\n"))
+	storage.WriteFile("transaction/x3", []byte("committed\nd one A two B 2020-04-01T00:00:00Z 4 EUR\n"))
+	storage.WriteFile("creation/x1", []byte("2019-06-01T00:00:00Z"))
+	old := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err = os.Chtimes(tmpdir+"/transaction/x3", old, old); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	exporter := NewExporter(tmpdir)
+	ids, err := exporter.Transactions()
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	export := func(filter Filter) [][]string {
+		var buffer bytes.Buffer
+		writer, _ := NewWriter(FormatCSV, &buffer)
+		if err := exporter.Export(ids, filter, writer); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		writer.Close()
+		records, _ := csv.NewReader(&buffer).ReadAll()
+		return records[1:]
+	}
+
+	t.Log("all transfers")
+	{
+		records := export(Filter{})
+		if len(records) != 4 {
+			t.Fatalf("expected 4 transfers got %v", records)
+		}
+		if records[1][4] != "2020-01-31T23:00:00Z" || records[1][9] != "2" {
+			t.Errorf("expected value date in UTC and exact amount got %v", records[1])
+		}
+		if records[0][2] != "2019-06-01T00:00:00Z" {
+			t.Errorf("expected recorded at of first event got %v", records[0])
+		}
+		if records[3][2] != "2019-01-01T00:00:00Z" {
+			t.Errorf("expected recorded at of journal entry missing in creation index got %v", records[3])
+		}
+	}
+
+	t.Log("value date range")
+	{
+		records := export(Filter{
+			ValueFrom: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+			ValueTo:   time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if len(records) != 1 || records[0][3] != "c" {
+			t.Errorf("expected only transfer c got %v", records)
+		}
+	}
+
+	t.Log("recorded range")
+	{
+		records := export(Filter{
+			To: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if len(records) != 3 || records[0][0] != "x1" || records[2][0] != "x3" {
+			t.Errorf("expected only transactions x1 and x3 got %v", records)
+		}
+	}
+}
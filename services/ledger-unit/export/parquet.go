@@ -0,0 +1,302 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// parquetRowGroupSize is number of rows buffered before they are written as
+// row group
+const parquetRowGroupSize = 10000
+
+const parquetMagic = "PAR1"
+
+// thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// parquet enumerations
+const (
+	parquetTypeByteArray     = 6
+	parquetRepetitionReq     = 0
+	parquetConvertedUTF8     = 0
+	parquetEncodingPlain     = 0
+	parquetEncodingRLE       = 3
+	parquetCodecUncompressed = 0
+	parquetPageData          = 0
+)
+
+// thriftWriter encodes structures in thrift compact protocol
+type thriftWriter struct {
+	buffer bytes.Buffer
+	fields []int16
+}
+
+func (writer *thriftWriter) varint(value uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	writer.buffer.Write(scratch[:binary.PutUvarint(scratch[:], value)])
+}
+
+func (writer *thriftWriter) zigzag(value int64) {
+	writer.varint(uint64((value << 1) ^ (value >> 63)))
+}
+
+func (writer *thriftWriter) field(id int16, kind byte) {
+	last := writer.fields[len(writer.fields)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		writer.buffer.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		writer.buffer.WriteByte(kind)
+		writer.zigzag(int64(id))
+	}
+	writer.fields[len(writer.fields)-1] = id
+}
+
+func (writer *thriftWriter) begin() {
+	writer.fields = append(writer.fields, 0)
+}
+
+func (writer *thriftWriter) end() {
+	writer.buffer.WriteByte(0)
+	writer.fields = writer.fields[:len(writer.fields)-1]
+}
+
+func (writer *thriftWriter) i32(id int16, value int32) {
+	writer.field(id, thriftI32)
+	writer.zigzag(int64(value))
+}
+
+func (writer *thriftWriter) i64(id int16, value int64) {
+	writer.field(id, thriftI64)
+	writer.zigzag(value)
+}
+
+func (writer *thriftWriter) binary(value string) {
+	writer.varint(uint64(len(value)))
+	writer.buffer.WriteString(value)
+}
+
+func (writer *thriftWriter) string(id int16, value string) {
+	writer.field(id, thriftBinary)
+	writer.binary(value)
+}
+
+func (writer *thriftWriter) list(id int16, kind byte, size int) {
+	writer.field(id, thriftList)
+	if size < 15 {
+		writer.buffer.WriteByte(byte(size)<<4 | kind)
+	} else {
+		writer.buffer.WriteByte(0xf0 | kind)
+		writer.varint(uint64(size))
+	}
+}
+
+func (writer *thriftWriter) structure(id int16) {
+	writer.field(id, thriftStruct)
+	writer.begin()
+}
+
+type parquetColumnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type parquetRowGroup struct {
+	columns []parquetColumnChunk
+	numRows int64
+	size    int64
+}
+
+// parquetWriter writes rows as uncompressed parquet file with required UTF8
+// column per each of Columns
+type parquetWriter struct {
+	output io.Writer
+	offset int64
+	rows   []Row
+	groups []parquetRowGroup
+}
+
+func newParquetWriter(output io.Writer) *parquetWriter {
+	return &parquetWriter{
+		output: output,
+		rows:   make([]Row, 0, parquetRowGroupSize),
+		groups: make([]parquetRowGroup, 0),
+	}
+}
+
+// start writes leading magic of file unless it was already written
+func (writer *parquetWriter) start() error {
+	if writer.offset != 0 {
+		return nil
+	}
+	n, err := io.WriteString(writer.output, parquetMagic)
+	writer.offset += int64(n)
+	return err
+}
+
+func (writer *parquetWriter) write(data []byte) error {
+	if err := writer.start(); err != nil {
+		return err
+	}
+	n, err := writer.output.Write(data)
+	writer.offset += int64(n)
+	return err
+}
+
+func pageHeader(size int, numValues int) []byte {
+	header := new(thriftWriter)
+	header.begin()
+	header.i32(1, parquetPageData)
+	header.i32(2, int32(size))
+	header.i32(3, int32(size))
+	header.structure(5)
+	header.i32(1, int32(numValues))
+	header.i32(2, parquetEncodingPlain)
+	header.i32(3, parquetEncodingRLE)
+	header.i32(4, parquetEncodingRLE)
+	header.end()
+	header.end()
+	return header.buffer.Bytes()
+}
+
+func (writer *parquetWriter) flush() error {
+	if len(writer.rows) == 0 {
+		return nil
+	}
+	group := parquetRowGroup{
+		columns: make([]parquetColumnChunk, len(Columns)),
+		numRows: int64(len(writer.rows)),
+	}
+	values := make([][]string, len(writer.rows))
+	for i, row := range writer.rows {
+		values[i] = row.values()
+	}
+	var page bytes.Buffer
+	var length [4]byte
+	for column := range Columns {
+		page.Reset()
+		for _, row := range values {
+			binary.LittleEndian.PutUint32(length[:], uint32(len(row[column])))
+			page.Write(length[:])
+			page.WriteString(row[column])
+		}
+		header := pageHeader(page.Len(), len(values))
+		if err := writer.start(); err != nil {
+			return err
+		}
+		chunk := parquetColumnChunk{
+			offset:    writer.offset,
+			size:      int64(len(header) + page.Len()),
+			numValues: int64(len(values)),
+		}
+		if err := writer.write(header); err != nil {
+			return err
+		}
+		if err := writer.write(page.Bytes()); err != nil {
+			return err
+		}
+		group.columns[column] = chunk
+		group.size += chunk.size
+	}
+	writer.groups = append(writer.groups, group)
+	writer.rows = writer.rows[:0]
+	return nil
+}
+
+func (writer *parquetWriter) footer() []byte {
+	var numRows int64
+	for _, group := range writer.groups {
+		numRows += group.numRows
+	}
+
+	meta := new(thriftWriter)
+	meta.begin()
+	meta.i32(1, 1)
+	meta.list(2, thriftStruct, len(Columns)+1)
+	meta.begin()
+	meta.string(4, "schema")
+	meta.i32(5, int32(len(Columns)))
+	meta.end()
+	for _, column := range Columns {
+		meta.begin()
+		meta.i32(1, parquetTypeByteArray)
+		meta.i32(3, parquetRepetitionReq)
+		meta.string(4, column)
+		meta.i32(6, parquetConvertedUTF8)
+		meta.end()
+	}
+	meta.i64(3, numRows)
+	meta.list(4, thriftStruct, len(writer.groups))
+	for _, group := range writer.groups {
+		meta.begin()
+		meta.list(1, thriftStruct, len(group.columns))
+		for column, chunk := range group.columns {
+			meta.begin()
+			meta.i64(2, chunk.offset)
+			meta.structure(3)
+			meta.i32(1, parquetTypeByteArray)
+			meta.list(2, thriftI32, 1)
+			meta.zigzag(parquetEncodingPlain)
+			meta.list(3, thriftBinary, 1)
+			meta.binary(Columns[column])
+			meta.i32(4, parquetCodecUncompressed)
+			meta.i64(5, chunk.numValues)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.end()
+			meta.end()
+		}
+		meta.i64(2, group.size)
+		meta.i64(3, group.numRows)
+		meta.end()
+	}
+	meta.string(6, "openbank ledger")
+	meta.end()
+	return meta.buffer.Bytes()
+}
+
+func (writer *parquetWriter) Write(row Row) error {
+	writer.rows = append(writer.rows, row)
+	if len(writer.rows) < parquetRowGroupSize {
+		return nil
+	}
+	return writer.flush()
+}
+
+func (writer *parquetWriter) Close() error {
+	if err := writer.flush(); err != nil {
+		return err
+	}
+	footer := writer.footer()
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if err := writer.write(footer); err != nil {
+		return err
+	}
+	if err := writer.write(length[:]); err != nil {
+		return err
+	}
+	return writer.write([]byte(parquetMagic))
+}
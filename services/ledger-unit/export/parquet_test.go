@@ -0,0 +1,83 @@
+package export
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// readParquet reads back all columns of parquet file
+func readParquet(t *testing.T, data []byte) [][]string {
+	file, err := buffer.NewBufferFile(data)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	parquet, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		t.Fatalf("unable to open parquet file %+v", err)
+	}
+	defer parquet.ReadStop()
+
+	numRows := parquet.GetNumRows()
+	result := make([][]string, numRows)
+	for i := range result {
+		result[i] = make([]string, len(Columns))
+	}
+	for column := range Columns {
+		values, _, _, err := parquet.ReadColumnByIndex(int64(column), numRows)
+		if err != nil {
+			t.Fatalf("unable to read column %s %+v", Columns[column], err)
+		}
+		if int64(len(values)) != numRows {
+			t.Fatalf("expected %d values of column %s got %d", numRows, Columns[column], len(values))
+		}
+		for i, value := range values {
+			result[i][column] = fmt.Sprint(value)
+		}
+	}
+	return result
+}
+
+func TestParquetReadBack(t *testing.T) {
+	t.Log("rows are read back by parquet reader")
+	{
+		rows := sampleRows()
+		records := readParquet(t, writeRows(t, FormatParquet, rows))
+		if len(records) != len(rows) {
+			t.Fatalf("expected %d rows got %d", len(rows), len(records))
+		}
+		for i, row := range rows {
+			if fmt.Sprint(records[i]) != fmt.Sprint(row.values()) {
+				t.Errorf("expected row %v got %v", row.values(), records[i])
+			}
+		}
+	}
+
+	t.Log("rows spanning multiple row groups are read back by parquet reader")
+	{
+		rows := make([]Row, parquetRowGroupSize+3)
+		for i := range rows {
+			rows[i] = sampleRows()[i%2]
+			rows[i].TransferID = fmt.Sprintf("%d", i)
+		}
+		records := readParquet(t, writeRows(t, FormatParquet, rows))
+		if len(records) != len(rows) {
+			t.Fatalf("expected %d rows got %d", len(rows), len(records))
+		}
+		for i, row := range rows {
+			if fmt.Sprint(records[i]) != fmt.Sprint(row.values()) {
+				t.Errorf("expected row %v got %v", row.values(), records[i])
+				break
+			}
+		}
+	}
+
+	t.Log("empty export is read back by parquet reader")
+	{
+		if records := readParquet(t, writeRows(t, FormatParquet, nil)); len(records) != 0 {
+			t.Errorf("expected no rows got %v", records)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Columns are names of exported columns in order, they are same for every
+// format and must not change between releases
+var Columns = []string{
+	"transaction_id",
+	"transaction_status",
+	"recorded_at",
+	"transfer_id",
+	"value_date",
+	"credit_tenant",
+	"credit_account",
+	"debit_tenant",
+	"debit_account",
+	"amount",
+	"currency",
+}
+
+// Row represents single transfer of transaction flattened, amount is kept as
+// decimal string so that no precision is lost
+type Row struct {
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	RecordedAt        string `json:"recorded_at"`
+	TransferID        string `json:"transfer_id"`
+	ValueDate         string `json:"value_date"`
+	CreditTenant      string `json:"credit_tenant"`
+	CreditAccount     string `json:"credit_account"`
+	DebitTenant       string `json:"debit_tenant"`
+	DebitAccount      string `json:"debit_account"`
+	Amount            string `json:"amount"`
+	Currency          string `json:"currency"`
+}
+
+// values returns row values in order of Columns
+func (row Row) values() []string {
+	return []string{
+		row.TransactionID,
+		row.TransactionStatus,
+		row.RecordedAt,
+		row.TransferID,
+		row.ValueDate,
+		row.CreditTenant,
+		row.CreditAccount,
+		row.DebitTenant,
+		row.DebitAccount,
+		row.Amount,
+		row.Currency,
+	}
+}
+
+// Filter represents range of exported transactions, lower bounds are
+// inclusive, upper bounds are exclusive and zero bound is open
+type Filter struct {
+	From      time.Time
+	To        time.Time
+	ValueFrom time.Time
+	ValueTo   time.Time
+}
+
+func within(at time.Time, from time.Time, to time.Time) bool {
+	return (from.IsZero() || !at.Before(from)) && (to.IsZero() || at.Before(to))
+}
+
+// AcceptsRecorded returns whether transaction recorded at given time is
+// within range
+func (filter Filter) AcceptsRecorded(at time.Time) bool {
+	return within(at, filter.From, filter.To)
+}
+
+// AcceptsValueDate returns whether transfer with given value date is within
+// range
+func (filter Filter) AcceptsValueDate(at time.Time) bool {
+	return within(at, filter.ValueFrom, filter.ValueTo)
+}
+
+// HasValueRange returns whether filter restricts value date
+func (filter Filter) HasValueRange() bool {
+	return !filter.ValueFrom.IsZero() || !filter.ValueTo.IsZero()
+}
+
+// ParseBound parses range bound given either as RFC3339 time or as date,
+// empty value is open bound
+func ParseBound(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	at, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid bound %q, expected RFC3339 time or date", value)
+	}
+	return at, nil
+}
+
+// Chunk returns sorted ids that follow cursor, at most limit of them if limit
+// is positive, and cursor of next chunk which is empty if chunk is last
+func Chunk(ids []string, after string, limit int) ([]string, string) {
+	sort.Strings(ids)
+	start := sort.SearchStrings(ids, after)
+	if after != "" && start < len(ids) && ids[start] == after {
+		start++
+	}
+	if limit <= 0 || start+limit >= len(ids) {
+		return ids[start:], ""
+	}
+	return ids[start : start+limit], ids[start+limit-1]
+}
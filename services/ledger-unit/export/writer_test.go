@@ -0,0 +1,139 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func sampleRows() []Row {
+	return []Row{
+		{
+			TransactionID:     "xxx",
+			TransactionStatus: "committed",
+			RecordedAt:        "2020-01-02T00:00:00Z",
+			TransferID:        "a",
+			ValueDate:         "2020-01-01T00:00:00Z",
+			CreditTenant:      "one",
+			CreditAccount:     "credit",
+			DebitTenant:       "two",
+			DebitAccount:      "debit, \"quoted\"",
+			Amount:            "100000000000000000000.000000000001",
+			Currency:          "EUR",
+		},
+		{
+			TransactionID:     "xxx",
+			TransactionStatus: "committed",
+			RecordedAt:        "2020-01-02T00:00:00Z",
+			TransferID:        "b",
+			ValueDate:         "2020-01-01T00:00:00Z",
+			CreditTenant:      "one",
+			CreditAccount:     "credit",
+			DebitTenant:       "two",
+			DebitAccount:      "debit",
+			Amount:            "0.1",
+			Currency:          "CZK",
+		},
+	}
+}
+
+func writeRows(t *testing.T, format string, rows []Row) []byte {
+	var buffer bytes.Buffer
+	writer, err := NewWriter(format, &buffer)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	for _, row := range rows {
+		if err = writer.Write(row); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestNewWriter(t *testing.T) {
+	if _, err := NewWriter("xml", new(bytes.Buffer)); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeRows(t, FormatCSV, sampleRows()))).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header and 2 rows got %d records", len(records))
+	}
+	if strings.Join(records[0], ",") != strings.Join(Columns, ",") {
+		t.Errorf("unexpected header %v", records[0])
+	}
+	for i, row := range sampleRows() {
+		if strings.Join(records[i+1], "|") != strings.Join(row.values(), "|") {
+			t.Errorf("expected row %v got %v", row.values(), records[i+1])
+		}
+	}
+
+	records, err = csv.NewReader(bytes.NewReader(writeRows(t, FormatCSV, nil))).ReadAll()
+	if err != nil || len(records) != 1 {
+		t.Errorf("expected header of empty export got %v %+v", records, err)
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSuffix(string(writeRows(t, FormatJSONL, sampleRows())), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines got %d", len(lines))
+	}
+	for i, row := range sampleRows() {
+		fields := make(map[string]string)
+		if err := json.Unmarshal([]byte(lines[i]), &fields); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if len(fields) != len(Columns) {
+			t.Errorf("expected %d fields got %v", len(Columns), fields)
+		}
+		for j, column := range Columns {
+			if fields[column] != row.values()[j] {
+				t.Errorf("expected %s to be %q got %q", column, row.values()[j], fields[column])
+			}
+		}
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	data := writeRows(t, FormatParquet, sampleRows())
+
+	if string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		t.Fatalf("expected file to be enclosed in magic")
+	}
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	if footerLength <= 0 || footerLength > len(data)-12 {
+		t.Fatalf("invalid footer length %d", footerLength)
+	}
+	footer := data[len(data)-8-footerLength : len(data)-8]
+	for _, column := range Columns {
+		if !bytes.Contains(footer, []byte(column)) {
+			t.Errorf("expected footer to describe column %s", column)
+		}
+	}
+	body := data[4 : len(data)-8-footerLength]
+	for _, row := range sampleRows() {
+		for _, value := range row.values() {
+			if !bytes.Contains(body, append([]byte{byte(len(value)), 0, 0, 0}, value...)) {
+				t.Errorf("expected value %q to be plain encoded in body", value)
+			}
+		}
+	}
+
+	empty := writeRows(t, FormatParquet, nil)
+	if string(empty[:4]) != parquetMagic || string(empty[len(empty)-4:]) != parquetMagic {
+		t.Errorf("expected empty export to be valid file")
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// Checker represents offline integrity check of journal of single tenant
+type Checker struct {
+	tenant     string
+	storage    localfs.Storage
+	shared     localfs.Storage
+	staleAfter time.Duration
+	repair     bool
+}
+
+// NewChecker returns journal checker of tenant, zero staleAfter disables check
+// of stuck transactions, repair quarantines corrupt files except events
+func NewChecker(tenant string, rootStorage string, sharedStorage string, staleAfter time.Duration, repair bool) *Checker {
+	storage, err := localfs.NewPlaintextStorage(rootStorage)
+	if err != nil {
+		return nil
+	}
+	shared, err := localfs.NewPlaintextStorage(sharedStorage)
+	if err != nil {
+		return nil
+	}
+	return &Checker{
+		tenant:     tenant,
+		storage:    storage,
+		shared:     shared,
+		staleAfter: staleAfter,
+		repair:     repair,
+	}
+}
+
+func isKnownState(state string) bool {
+	switch state {
+	case persistence.StatusNew,
+		persistence.StatusAccepted,
+		persistence.StatusRejected,
+		persistence.StatusCommitted,
+		persistence.StatusRollbacked:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTerminalState(state string) bool {
+	return state == persistence.StatusCommitted || state == persistence.StatusRollbacked
+}
+
+func (checker *Checker) list(path string) ([]string, error) {
+	exists, err := checker.storage.Exists(path)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return checker.storage.ListDirectory(path, true)
+}
+
+// quarantine moves corrupt file aside when checker repairs and returns action
+// taken
+func (checker *Checker) quarantine(finding Finding) Finding {
+	if !checker.repair {
+		return finding
+	}
+	data, err := checker.storage.ReadFileFully(finding.Path)
+	if err == nil {
+		err = checker.storage.WriteFile("quarantine/"+finding.Path, data)
+	}
+	if err == nil {
+		err = checker.storage.DeleteFile(finding.Path)
+	}
+	if err != nil {
+		finding.Detail = strings.TrimSpace(finding.Detail + " quarantine failed " + err.Error())
+		return finding
+	}
+	finding.Action = ActionQuarantined
+	return finding
+}
+
+func (checker *Checker) corrupt(report *Report, path string, kind string, detail string) {
+	report.add(checker.quarantine(Finding{
+		Path:    path,
+		Kind:    kind,
+		Detail:  detail,
+		Corrupt: true,
+	}))
+}
+
+// checkPending reports interrupted update of transaction and returns data of
+// its intact pending copy
+func (checker *Checker) checkPending(report *Report, id string) ([]byte, bool) {
+	pendingPath := "pending/" + id
+	exists, err := checker.storage.Exists(pendingPath)
+	if err != nil || !exists {
+		return nil, false
+	}
+	data, ok := persistence.LoadPendingData(checker.storage, id)
+	if !ok {
+		checker.corrupt(report, pendingPath, KindTornPending, "")
+		return nil, false
+	}
+	finding := Finding{
+		Path: pendingPath,
+		Kind: KindInterruptedUpdate,
+	}
+	if checker.repair {
+		if err = persistence.CompletePendingUpdate(checker.storage, persistence.NewEventLog(checker.storage), id); err != nil {
+			finding.Detail = "completion failed " + err.Error()
+		} else {
+			finding.Action = ActionCompleted
+		}
+	}
+	report.add(finding)
+	return data, true
+}
+
+// checkTransaction verifies journal entry of transaction as unit would load it
+// and returns its state if it is intact
+func (checker *Checker) checkTransaction(report *Report, id string) (string, bool) {
+	path := "transaction/" + id
+	modTime, modErr := checker.storage.LastModification(path)
+	data, ok := checker.checkPending(report, id)
+	if !ok {
+		var err error
+		data, err = checker.storage.ReadFileFully(path)
+		if err != nil {
+			checker.corrupt(report, path, KindUnreadable, err.Error())
+			return "", false
+		}
+	}
+
+	entity := new(model.Transaction)
+	entity.IDTransaction = id
+	if err := entity.DeserializeState(data); err != nil {
+		checker.corrupt(report, path, KindMalformed, err.Error())
+		return "", false
+	}
+	if !isKnownState(entity.State) {
+		checker.corrupt(report, path, KindUnknownState, fmt.Sprintf("state %q", entity.State))
+		return "", false
+	}
+	if err := entity.Deserialize(data); err == model.ErrMalformedAmount {
+		checker.corrupt(report, path, KindInvalidAmount, err.Error())
+		return "", false
+	} else if err != nil {
+		checker.corrupt(report, path, KindMalformed, err.Error())
+		return "", false
+	}
+
+	if checker.staleAfter > 0 && !isTerminalState(entity.State) {
+		if modErr == nil && report.CheckedAt.Sub(modTime) > checker.staleAfter {
+			report.add(Finding{
+				Path:   path,
+				Kind:   KindStale,
+				Detail: fmt.Sprintf("state %s since %s", entity.State, modTime.UTC().Format(time.RFC3339)),
+			})
+		}
+	}
+
+	return entity.State, true
+}
+
+// corruptEvent reports corrupt event, events are never quarantined because
+// event log readers follow contiguous sequence of events and dead-letter
+// malformed ones themselves
+func (checker *Checker) corruptEvent(report *Report, path string, kind string, detail string) {
+	report.add(Finding{
+		Path:    path,
+		Kind:    kind,
+		Detail:  detail,
+		Corrupt: true,
+	})
+}
+
+// checkEvents verifies event log and returns state of last event of every
+// transaction and path of that event
+func (checker *Checker) checkEvents(report *Report) (map[string]string, map[string]string, error) {
+	states := make(map[string]string)
+	paths := make(map[string]string)
+	events, err := checker.list("event")
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Events = len(events)
+	for _, event := range events {
+		path := "event/" + event
+		data, err := checker.storage.ReadFileFully(path)
+		if err != nil {
+			checker.corruptEvent(report, path, KindUnreadable, err.Error())
+			continue
+		}
+		parts := strings.Split(string(data), " ")
+		if len(parts) != 3 || parts[0] == "" || !isKnownState(parts[1]) {
+			checker.corruptEvent(report, path, KindMalformedEvent, fmt.Sprintf("%q", string(data)))
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339Nano, parts[2]); err != nil {
+			checker.corruptEvent(report, path, KindMalformedEvent, err.Error())
+			continue
+		}
+		states[parts[0]] = parts[1]
+		paths[parts[0]] = path
+	}
+	return states, paths, nil
+}
+
+// checkReferences verifies that inbound references of other tenants point to
+// transactions in their journals
+func (checker *Checker) checkReferences(report *Report) error {
+	origins, err := checker.list("inbound")
+	if err != nil {
+		return err
+	}
+	for _, origin := range origins {
+		references, err := checker.list("inbound/" + origin)
+		if err != nil {
+			return err
+		}
+		report.References += len(references)
+		for _, id := range references {
+			exists, err := checker.shared.Exists("t_" + origin + "/transaction/" + id)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				report.add(Finding{
+					Path:   "inbound/" + origin + "/" + id,
+					Kind:   KindOrphanReference,
+					Detail: "tenant " + origin + " has no transaction " + id,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// Run checks journal of tenant and returns report of findings
+func (checker *Checker) Run() (*Report, error) {
+	if checker == nil {
+		return nil, fmt.Errorf("nil pointer")
+	}
+
+	report := &Report{
+		Tenant:    checker.tenant,
+		CheckedAt: time.Now().UTC(),
+		Repair:    checker.repair,
+		States:    make(map[string]int),
+		Findings:  make([]Finding, 0),
+	}
+
+	transactions, err := checker.list("transaction")
+	if err != nil {
+		return nil, err
+	}
+	report.Transactions = len(transactions)
+
+	listed := make(map[string]bool)
+	intact := make(map[string]string)
+	for _, id := range transactions {
+		listed[id] = true
+		if state, ok := checker.checkTransaction(report, id); ok {
+			intact[id] = state
+			report.States[state]++
+		}
+	}
+
+	pending, err := checker.list("pending")
+	if err != nil {
+		return nil, err
+	}
+	report.Pending = len(pending)
+	for _, id := range pending {
+		if !listed[id] {
+			checker.corrupt(report, "pending/"+id, KindOrphanPending, "")
+		}
+	}
+
+	eventStates, eventPaths, err := checker.checkEvents(report)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range transactions {
+		state, ok := intact[id]
+		if !ok {
+			continue
+		}
+		eventState, ok := eventStates[id]
+		if !ok {
+			report.add(Finding{
+				Path: "transaction/" + id,
+				Kind: KindMissingEvent,
+			})
+			continue
+		}
+		if eventState != state {
+			report.add(Finding{
+				Path:   "transaction/" + id,
+				Kind:   KindStateMismatch,
+				Detail: fmt.Sprintf("journal %s last event %s", state, eventState),
+			})
+		}
+	}
+	orphans := make([]string, 0)
+	for id := range eventPaths {
+		if !listed[id] {
+			orphans = append(orphans, id)
+		}
+	}
+	sort.Strings(orphans)
+	for _, id := range orphans {
+		report.add(Finding{
+			Path:   eventPaths[id],
+			Kind:   KindOrphanEvent,
+			Detail: "no transaction " + id,
+		})
+	}
+
+	if err = checker.checkReferences(report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
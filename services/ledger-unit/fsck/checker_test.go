@@ -0,0 +1,221 @@
+package fsck
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/persistence"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	money "gopkg.in/inf.v0"
+)
+
+func newTransaction(id string, state string) *model.Transaction {
+	return &model.Transaction{
+		IDTransaction: id,
+		State:         state,
+		Transfers: []model.Transfer{
+			{
+				IDTransfer: "a",
+				Credit:     model.Account{Tenant: "one", Name: "credit"},
+				Debit:      model.Account{Tenant: "two", Name: "debit"},
+				ValueDate:  "2020-01-01T00:00:00Z",
+				Amount:     new(money.Dec).SetUnscaled(1),
+				Currency:   "EUR",
+			},
+		},
+	}
+}
+
+func setupJournal(t *testing.T) (string, localfs.Storage, *persistence.EventLog) {
+	tmpdir, err := ioutil.TempDir("", "fsck")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	storage, err := localfs.NewPlaintextStorage(filepath.Join(tmpdir, "t_one"))
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	events := persistence.NewEventLog(storage)
+	if err := persistence.CreateTransaction(storage, events, newTransaction("committed", persistence.StatusNew)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if err := persistence.UpdateTransaction(storage, events, newTransaction("committed", persistence.StatusCommitted)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if err := persistence.CreateTransaction(storage, events, newTransaction("accepted", persistence.StatusAccepted)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	return tmpdir, storage, events
+}
+
+func kindsOf(report *Report) map[string]string {
+	result := make(map[string]string)
+	for _, finding := range report.Findings {
+		result[finding.Path] = finding.Kind
+	}
+	return result
+}
+
+func TestCheckCleanJournal(t *testing.T) {
+	tmpdir, _, _ := setupJournal(t)
+	defer os.RemoveAll(tmpdir)
+
+	report, err := NewChecker("one", filepath.Join(tmpdir, "t_one"), tmpdir, time.Hour, false).Run()
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if len(report.Findings) != 0 {
+		t.Errorf("expected no findings got %+v", report.Findings)
+	}
+	if report.Transactions != 2 || report.Events != 3 {
+		t.Errorf("expected 2 transactions and 3 events got %d and %d", report.Transactions, report.Events)
+	}
+	if report.States[persistence.StatusCommitted] != 1 || report.States[persistence.StatusAccepted] != 1 {
+		t.Errorf("unexpected states %+v", report.States)
+	}
+	if report.Unresolved() != 0 {
+		t.Errorf("expected no unresolved findings")
+	}
+}
+
+func TestCheckCorruptJournal(t *testing.T) {
+	tmpdir, storage, _ := setupJournal(t)
+	defer os.RemoveAll(tmpdir)
+
+	files := map[string]string{
+		"transaction/unknown":        "lost\n",
+		"transaction/amount":         "new\na one credit two debit 2020-01-01T00:00:00Z 1.x EUR\n",
+		"transaction/torn":           "committed\na one credit two",
+		"transaction/truncated":      "committed",
+		"pending/committed":          "00000000\ncommitted\n",
+		"pending/orphan":             "00000000\nnew\n",
+		"event/99999999999999999999": "ghost committed 2020-01-01T00:00:00Z",
+		"event/99999999999999999998": "garbage",
+		"inbound/two/missing":        "committed",
+	}
+	for path, data := range files {
+		if err := storage.WriteFile(path, []byte(data)); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+	}
+	stale := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(tmpdir, "t_one", "transaction", "accepted"), stale, stale); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	report, err := NewChecker("one", filepath.Join(tmpdir, "t_one"), tmpdir, time.Hour, false).Run()
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	expected := map[string]string{
+		"transaction/accepted":       KindStale,
+		"transaction/unknown":        KindUnknownState,
+		"transaction/amount":         KindInvalidAmount,
+		"transaction/torn":           KindMalformed,
+		"transaction/truncated":      KindMalformed,
+		"pending/committed":          KindTornPending,
+		"pending/orphan":             KindOrphanPending,
+		"event/99999999999999999999": KindOrphanEvent,
+		"event/99999999999999999998": KindMalformedEvent,
+		"inbound/two/missing":        KindOrphanReference,
+	}
+	actual := kindsOf(report)
+	for path, kind := range expected {
+		if actual[path] != kind {
+			t.Errorf("expected %s to be %s got %q", path, kind, actual[path])
+		}
+	}
+	if len(actual) != len(expected) {
+		t.Errorf("unexpected findings %+v", report.Findings)
+	}
+	if report.Unresolved() != len(report.Findings) {
+		t.Errorf("expected no finding to be resolved without repair")
+	}
+
+	report, err = NewChecker("one", filepath.Join(tmpdir, "t_one"), tmpdir, time.Hour, true).Run()
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	for _, finding := range report.Findings {
+		event := strings.HasPrefix(finding.Path, "event/")
+		if finding.Corrupt && !event && finding.Action != ActionQuarantined {
+			t.Errorf("expected corrupt %s to be quarantined", finding.Path)
+		}
+		if (!finding.Corrupt || event) && finding.Action != "" {
+			t.Errorf("expected %s to be left as is", finding.Path)
+		}
+	}
+	for _, path := range []string{"transaction/unknown", "pending/committed"} {
+		if ok, _ := storage.Exists(path); ok {
+			t.Errorf("expected %s to be removed", path)
+		}
+		if ok, _ := storage.Exists("quarantine/" + path); !ok {
+			t.Errorf("expected %s to be quarantined", path)
+		}
+	}
+	if ok, _ := storage.Exists("event/99999999999999999998"); !ok {
+		t.Errorf("expected malformed event to be kept in sequence")
+	}
+	if ok, _ := storage.Exists("quarantine/event/99999999999999999998"); ok {
+		t.Errorf("expected malformed event not to be quarantined")
+	}
+
+	report, err = NewChecker("one", filepath.Join(tmpdir, "t_one"), tmpdir, 0, false).Run()
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	expected = map[string]string{
+		"event/99999999999999999999": KindOrphanEvent,
+		"event/99999999999999999998": KindMalformedEvent,
+		"inbound/two/missing":        KindOrphanReference,
+	}
+	actual = kindsOf(report)
+	if len(actual) != len(expected) {
+		t.Errorf("unexpected findings after repair %+v", report.Findings)
+	}
+	for path, kind := range expected {
+		if actual[path] != kind {
+			t.Errorf("expected %s to be %s got %q", path, kind, actual[path])
+		}
+	}
+}
+
+func TestCheckInterruptedUpdate(t *testing.T) {
+	tmpdir, storage, _ := setupJournal(t)
+	defer os.RemoveAll(tmpdir)
+
+	data := newTransaction("accepted", persistence.StatusCommitted).Serialize()
+	if err := storage.WriteFile("pending/accepted", append([]byte(fmt.Sprintf("%08x\n", crc32.ChecksumIEEE(data))), data...)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if err := storage.WriteFile("transaction/accepted", []byte("accep")); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	report, err := NewChecker("one", filepath.Join(tmpdir, "t_one"), tmpdir, time.Hour, true).Run()
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	actual := kindsOf(report)
+	if actual["pending/accepted"] != KindInterruptedUpdate {
+		t.Errorf("expected interrupted update got %+v", report.Findings)
+	}
+	if _, ok := actual["transaction/accepted"]; ok {
+		t.Errorf("expected event of completed update to be appended got %+v", report.Findings)
+	}
+	if state, _ := persistence.LoadTransactionState(storage, "accepted"); state != persistence.StatusCommitted {
+		t.Errorf("expected completed update to be committed got %s", state)
+	}
+	if ok, _ := storage.Exists("pending/accepted"); ok {
+		t.Errorf("expected pending copy to be removed")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsck
+
+import (
+	"time"
+)
+
+const (
+	// KindUnreadable represents journal file that cannot be read
+	KindUnreadable = "unreadable"
+	// KindMalformed represents journal entry that does not parse
+	KindMalformed = "malformed"
+	// KindUnknownState represents journal entry in state that is not known
+	KindUnknownState = "unknown-state"
+	// KindInvalidAmount represents journal entry with transfer amount that is
+	// not valid decimal
+	KindInvalidAmount = "invalid-amount"
+	// KindStale represents transaction stuck in non terminal state
+	KindStale = "stale"
+	// KindInterruptedUpdate represents intact pending copy of update that was
+	// not completed
+	KindInterruptedUpdate = "interrupted-update"
+	// KindTornPending represents pending copy of update that was not fully
+	// written
+	KindTornPending = "torn-pending"
+	// KindOrphanPending represents pending copy of transaction that is not in
+	// journal
+	KindOrphanPending = "orphan-pending"
+	// KindMalformedEvent represents event that does not parse
+	KindMalformedEvent = "malformed-event"
+	// KindOrphanEvent represents event of transaction that is not in journal
+	KindOrphanEvent = "orphan-event"
+	// KindMissingEvent represents transaction without any event
+	KindMissingEvent = "missing-event"
+	// KindStateMismatch represents transaction which state differs from state
+	// of its last event
+	KindStateMismatch = "state-mismatch"
+	// KindOrphanReference represents inbound reference of transaction that is
+	// not in journal of origin tenant
+	KindOrphanReference = "orphan-reference"
+)
+
+const (
+	// ActionQuarantined represents corrupt file moved to quarantine
+	ActionQuarantined = "quarantined"
+	// ActionCompleted represents interrupted update completed from pending copy
+	ActionCompleted = "completed"
+)
+
+// Finding represents single problem found in journal
+type Finding struct {
+	Path    string `json:"path"`
+	Kind    string `json:"kind"`
+	Detail  string `json:"detail,omitempty"`
+	Corrupt bool   `json:"corrupt"`
+	Action  string `json:"action,omitempty"`
+}
+
+// Report represents outcome of journal check
+type Report struct {
+	Tenant       string         `json:"tenant"`
+	CheckedAt    time.Time      `json:"checkedAt"`
+	Repair       bool           `json:"repair"`
+	Transactions int            `json:"transactions"`
+	Pending      int            `json:"pending"`
+	Events       int            `json:"events"`
+	References   int            `json:"references"`
+	States       map[string]int `json:"states"`
+	Findings     []Finding      `json:"findings"`
+}
+
+// Unresolved returns number of findings that were not repaired
+func (report *Report) Unresolved() int {
+	if report == nil {
+		return 0
+	}
+	result := 0
+	for _, finding := range report.Findings {
+		if finding.Action == "" {
+			result++
+		}
+	}
+	return result
+}
+
+func (report *Report) add(finding Finding) {
+	report.Findings = append(report.Findings, finding)
+}
@@ -1,13 +1,23 @@
 module github.com/jancajthaml-openbank/ledger-unit
 
-go 1.15
+go 1.20
 
 require (
 	github.com/DataDog/datadog-go v4.2.0+incompatible
 	github.com/jancajthaml-openbank/actor-system v1.3.1
 	github.com/jancajthaml-openbank/local-fs v1.2.0
-	github.com/rs/xid v1.2.1
 	github.com/rs/zerolog v1.20.0
-	github.com/stretchr/testify v1.6.1 // indirect
+	github.com/xitongsys/parquet-go v1.6.2
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
 	gopkg.in/inf.v0 v0.9.1
 )
+
+require (
+	github.com/apache/arrow/go/arrow v0.0.0-20200730104253-651201b0f516 // indirect
+	github.com/apache/thrift v0.14.2 // indirect
+	github.com/golang/snappy v0.0.3 // indirect
+	github.com/klauspost/compress v1.13.1 // indirect
+	github.com/pebbe/zmq4 v1.2.1 // indirect
+	github.com/pierrec/lz4/v4 v4.1.8 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+)
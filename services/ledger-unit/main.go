@@ -18,13 +18,24 @@ import (
 	"context"
 	"fmt"
 	"github.com/jancajthaml-openbank/ledger-unit/boot"
+	"os"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fsck" {
+		os.Exit(boot.Fsck(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(boot.Export(os.Args[2:], os.Stdout))
+	}
+
 	fmt.Println(">>> Start <<<")
 
 	program := boot.NewProgram()
-	program.Setup()
+	if err := program.Setup(); err != nil {
+		fmt.Println(">>> Refused to start <<<")
+		os.Exit(boot.ExitCodeInvalidConfiguration)
+	}
 
 	defer func() {
 		program.Stop()
@@ -16,18 +16,46 @@ package metrics
 
 import (
 	"sync/atomic"
+	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
+	"github.com/jancajthaml-openbank/ledger-unit/support/host"
+	localfs "github.com/jancajthaml-openbank/local-fs"
 )
 
+// Metrics represents contract of metrics collector
 type Metrics interface {
 	TransactionPromised(transfers int)
 	TransactionCommitted(transfers int)
 	TransactionRollbacked(transfers int)
+	TransactionRefused(transfers int)
+	SagaStarted()
+	SagaFinished()
+	PhaseDuration(phase string, duration time.Duration)
+	TransactionRejected(phase string, reason string, vaultTenant string)
+	PromiseBounced(vaultTenant string)
+	FatalError(phase string, vaultTenant string)
 }
 
 type metrics struct {
 	client                 *statsd.Client
+	storage                localfs.Storage
+	registry               *Registry
+	transactions           *Family
+	transfers              *Family
+	sagas                  *Family
+	phases                 *Family
+	rejections             *Family
+	bounces                *Family
+	fatals                 *Family
+	diskFree               *Family
+	diskUsed               *Family
+	diskHealthy            *Family
+	memoryFree             *Family
+	memoryUsed             *Family
+	memoryHealthy          *Family
+	diskMonitor            host.CapacityCheck
+	memoryMonitor          host.CapacityCheck
 	tenant                 string
 	promisedTransactions   int64
 	promisedTransfers      int64
@@ -37,15 +65,40 @@ type metrics struct {
 	rollbackedTransfers    int64
 }
 
-// NewMetrics returns blank metrics holder
-func NewMetrics(tenant string, endpoint string) *metrics {
+// NewMetrics returns blank metrics holder pushing to statsd endpoint and
+// writing prometheus snapshot into storage, snapshot is exposed by ledger-rest
+// so units of all tenants share one endpoint, nil monitors are not reported
+func NewMetrics(tenant string, endpoint string, rootStorage string, diskMonitor host.CapacityCheck, memoryMonitor host.CapacityCheck) *metrics {
 	client, err := statsd.New(endpoint, statsd.WithClientSideAggregation(), statsd.WithoutTelemetry())
 	if err != nil {
 		log.Error().Msgf("Failed to ensure statsd client %+v", err)
 		return nil
 	}
+	storage, err := localfs.NewPlaintextStorage(rootStorage)
+	if err != nil {
+		log.Error().Msgf("Failed to ensure storage %+v", err)
+		return nil
+	}
+	registry := NewRegistry()
 	return &metrics{
 		client:                 client,
+		storage:                storage,
+		registry:               registry,
+		transactions:           registry.Counter("ledger_transactions_total", "Number of transactions by outcome", "tenant", "outcome"),
+		transfers:              registry.Counter("ledger_transfers_total", "Number of transfers by outcome of their transaction", "tenant", "outcome"),
+		sagas:                  registry.Gauge("ledger_sagas_in_flight", "Number of transactions being negotiated", "tenant"),
+		phases:                 registry.Histogram("ledger_saga_phase_duration_seconds", "Duration of transaction negotiation phases", DefaultBuckets, "tenant", "phase"),
+		rejections:             registry.Counter("ledger_rejections_total", "Number of vault rejections by phase and reason", "tenant", "phase", "reason", "vault_tenant"),
+		bounces:                registry.Counter("ledger_bounces_total", "Number of promises bounced by vault", "tenant", "vault_tenant"),
+		fatals:                 registry.Counter("ledger_fatal_errors_total", "Number of fatal errors replied by vault", "tenant", "phase", "vault_tenant"),
+		diskFree:               registry.Gauge("ledger_disk_free_bytes", "Free space of storage", "tenant"),
+		diskUsed:               registry.Gauge("ledger_disk_used_bytes", "Used space of storage", "tenant"),
+		diskHealthy:            registry.Gauge("ledger_disk_healthy", "Whenever there is enough space of storage", "tenant"),
+		memoryFree:             registry.Gauge("ledger_memory_free_bytes", "Free memory of host", "tenant"),
+		memoryUsed:             registry.Gauge("ledger_memory_used_bytes", "Memory allocated by unit", "tenant"),
+		memoryHealthy:          registry.Gauge("ledger_memory_healthy", "Whenever there is enough memory", "tenant"),
+		diskMonitor:            diskMonitor,
+		memoryMonitor:          memoryMonitor,
 		tenant:                 tenant,
 		promisedTransactions:   int64(0),
 		promisedTransfers:      int64(0),
@@ -63,6 +116,8 @@ func (instance *metrics) TransactionPromised(transfers int) {
 	}
 	atomic.AddInt64(&(instance.promisedTransactions), 1)
 	atomic.AddInt64(&(instance.promisedTransfers), int64(transfers))
+	instance.transactions.Inc(instance.tenant, "promised")
+	instance.transfers.Add(float64(transfers), instance.tenant, "promised")
 }
 
 // TransactionCommitted increments transactions committed by one
@@ -72,6 +127,8 @@ func (instance *metrics) TransactionCommitted(transfers int) {
 	}
 	atomic.AddInt64(&(instance.committedTransactions), 1)
 	atomic.AddInt64(&(instance.committedTransfers), int64(transfers))
+	instance.transactions.Inc(instance.tenant, "committed")
+	instance.transfers.Add(float64(transfers), instance.tenant, "committed")
 }
 
 // TransactionRollbacked increments transactions rollbacked by one
@@ -81,6 +138,92 @@ func (instance *metrics) TransactionRollbacked(transfers int) {
 	}
 	atomic.AddInt64(&(instance.rollbackedTransactions), 1)
 	atomic.AddInt64(&(instance.rollbackedTransfers), int64(transfers))
+	instance.transactions.Inc(instance.tenant, "rollbacked")
+	instance.transfers.Add(float64(transfers), instance.tenant, "rollbacked")
+}
+
+// TransactionRefused increments transactions refused by one
+func (instance *metrics) TransactionRefused(transfers int) {
+	if instance == nil {
+		return
+	}
+	instance.transactions.Inc(instance.tenant, "refused")
+	instance.transfers.Add(float64(transfers), instance.tenant, "refused")
+}
+
+// SagaStarted increments number of transactions in flight by one
+func (instance *metrics) SagaStarted() {
+	if instance == nil {
+		return
+	}
+	instance.sagas.Add(1, instance.tenant)
+}
+
+// SagaFinished decrements number of transactions in flight by one
+func (instance *metrics) SagaFinished() {
+	if instance == nil {
+		return
+	}
+	instance.sagas.Add(-1, instance.tenant)
+}
+
+// PhaseDuration records how long negotiation phase of transaction took
+func (instance *metrics) PhaseDuration(phase string, duration time.Duration) {
+	if instance == nil {
+		return
+	}
+	instance.phases.Observe(duration.Seconds(), instance.tenant, phase)
+	instance.client.Timing("openbank.ledger.phase."+phase, duration, []string{"tenant:" + instance.tenant}, 1)
+}
+
+// TransactionRejected increments rejections of given reason by vault of given
+// tenant in given phase by one
+func (instance *metrics) TransactionRejected(phase string, reason string, vaultTenant string) {
+	if instance == nil {
+		return
+	}
+	instance.rejections.Inc(instance.tenant, phase, reason, vaultTenant)
+	instance.client.Incr("openbank.ledger.rejection", []string{"tenant:" + instance.tenant, "phase:" + phase, "reason:" + reason, "vault_tenant:" + vaultTenant}, 1)
+}
+
+// PromiseBounced increments promises bounced by vault of given tenant by one
+func (instance *metrics) PromiseBounced(vaultTenant string) {
+	if instance == nil {
+		return
+	}
+	instance.bounces.Inc(instance.tenant, vaultTenant)
+	instance.client.Incr("openbank.ledger.bounce", []string{"tenant:" + instance.tenant, "vault_tenant:" + vaultTenant}, 1)
+}
+
+// FatalError increments fatal errors replied by vault of given tenant in given
+// phase by one
+func (instance *metrics) FatalError(phase string, vaultTenant string) {
+	if instance == nil {
+		return
+	}
+	instance.fatals.Inc(instance.tenant, phase, vaultTenant)
+	instance.client.Incr("openbank.ledger.fatal", []string{"tenant:" + instance.tenant, "phase:" + phase, "vault_tenant:" + vaultTenant}, 1)
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// observeHost copies current values of host monitors into gauges
+func (instance *metrics) observeHost() {
+	if instance.diskMonitor != nil {
+		instance.diskFree.Set(float64(instance.diskMonitor.GetFree()), instance.tenant)
+		instance.diskUsed.Set(float64(instance.diskMonitor.GetUsed()), instance.tenant)
+		instance.diskHealthy.Set(boolToFloat(instance.diskMonitor.IsHealthy()), instance.tenant)
+	}
+	if instance.memoryMonitor != nil {
+		instance.memoryFree.Set(float64(instance.memoryMonitor.GetFree()), instance.tenant)
+		instance.memoryUsed.Set(float64(instance.memoryMonitor.GetUsed()), instance.tenant)
+		instance.memoryHealthy.Set(boolToFloat(instance.memoryMonitor.IsHealthy()), instance.tenant)
+	}
 }
 
 // Setup does nothing
@@ -127,4 +270,10 @@ func (instance *metrics) Work() {
 	instance.client.Count("openbank.ledger.transfer.committed", committedTransfers, tags, 1)
 	instance.client.Count("openbank.ledger.transaction.rollbacked", rollbackedTransactions, tags, 1)
 	instance.client.Count("openbank.ledger.transfer.rollbacked", rollbackedTransfers, tags, 1)
+
+	instance.observeHost()
+
+	if err := instance.storage.WriteFile("metrics", instance.registry.Bytes()); err != nil {
+		log.Warn().Msgf("Failed to persist metrics snapshot %+v", err)
+	}
 }
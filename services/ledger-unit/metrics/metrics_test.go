@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/support/host"
+)
+
+func TestSagaMetrics(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	instance := NewMetrics("one", "127.0.0.1:8125", tmpdir, nil, nil)
+	if instance == nil {
+		t.Fatalf("expected metrics instance")
+	}
+
+	instance.PhaseDuration("promise", 50*time.Millisecond)
+	instance.TransactionRejected("promise", "INSUFFICIENT_FUNDS", "two")
+	instance.TransactionRejected("promise", "INSUFFICIENT_FUNDS", "two")
+	instance.PromiseBounced("two")
+	instance.FatalError("commit", "three")
+
+	actual := string(instance.registry.Bytes())
+
+	for _, expected := range []string{
+		`ledger_saga_phase_duration_seconds_count{tenant="one",phase="promise"} 1`,
+		`ledger_rejections_total{tenant="one",phase="promise",reason="INSUFFICIENT_FUNDS",vault_tenant="two"} 2`,
+		`ledger_bounces_total{tenant="one",vault_tenant="two"} 1`,
+		`ledger_fatal_errors_total{tenant="one",phase="commit",vault_tenant="three"} 1`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %q in %s", expected, actual)
+		}
+	}
+}
+
+type capacityStub struct {
+	free    uint64
+	used    uint64
+	healthy bool
+}
+
+func (stub capacityStub) IsHealthy() bool {
+	return stub.healthy
+}
+
+func (stub capacityStub) GetFree() uint64 {
+	return stub.free
+}
+
+func (stub capacityStub) GetUsed() uint64 {
+	return stub.used
+}
+
+func TestHostMetrics(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	t.Log("reports values of host monitors")
+	{
+		instance := NewMetrics("one", "127.0.0.1:8125", tmpdir, capacityStub{10, 20, true}, capacityStub{30, 40, false})
+		if instance == nil {
+			t.Fatalf("expected metrics instance")
+		}
+
+		instance.Work()
+
+		data, err := ioutil.ReadFile(tmpdir + "/metrics")
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		actual := string(data)
+
+		for _, expected := range []string{
+			`ledger_disk_free_bytes{tenant="one"} 10`,
+			`ledger_disk_used_bytes{tenant="one"} 20`,
+			`ledger_disk_healthy{tenant="one"} 1`,
+			`ledger_memory_free_bytes{tenant="one"} 30`,
+			`ledger_memory_used_bytes{tenant="one"} 40`,
+			`ledger_memory_healthy{tenant="one"} 0`,
+		} {
+			if !strings.Contains(actual, expected) {
+				t.Errorf("expected %q in %s", expected, actual)
+			}
+		}
+	}
+
+	t.Log("reports measured values of real monitors")
+	{
+		diskMonitor := host.NewDiskMonitor(0, tmpdir)
+		diskMonitor.CheckDiskSpace()
+		memoryMonitor := host.NewMemoryMonitor(0)
+		memoryMonitor.CheckMemoryAllocation()
+
+		instance := NewMetrics("one", "127.0.0.1:8125", tmpdir, diskMonitor, memoryMonitor)
+		instance.Work()
+
+		actual := string(instance.registry.Bytes())
+		for _, expected := range []string{
+			`ledger_disk_healthy{tenant="one"} 1`,
+			`ledger_memory_healthy{tenant="one"} 1`,
+		} {
+			if !strings.Contains(actual, expected) {
+				t.Errorf("expected %q in %s", expected, actual)
+			}
+		}
+		if strings.Contains(actual, `ledger_memory_used_bytes{tenant="one"} 0`+"\n") {
+			t.Errorf("expected memory to be measured in %s", actual)
+		}
+	}
+
+	t.Log("omits host monitors when there are none")
+	{
+		instance := NewMetrics("one", "127.0.0.1:8125", tmpdir, nil, nil)
+		instance.Work()
+
+		if actual := string(instance.registry.Bytes()); strings.Contains(actual, `ledger_disk_free_bytes{`) {
+			t.Errorf("expected no host samples in %s", actual)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	money "gopkg.in/inf.v0"
+)
+
+// TenantConfig represents runtime configuration of tenant, zero values fall
+// back to configuration of service
+type TenantConfig struct {
+	Currencies        []string
+	FinalizerInterval time.Duration
+	MaxTransfers      int
+	MaxAmount         *money.Dec
+	LogLevel          string
+}
+
+// Deserialize tenant configuration from persisted json document
+func (entity *TenantConfig) Deserialize(data []byte) error {
+	if entity == nil {
+		return fmt.Errorf("cannot deserialize to nil pointer")
+	}
+
+	all := struct {
+		Currencies        []string `json:"currencies"`
+		FinalizerInterval string   `json:"finalizerInterval"`
+		MaxTransfers      int      `json:"maxTransfers"`
+		MaxAmount         string   `json:"maxAmount"`
+		LogLevel          string   `json:"logLevel"`
+	}{}
+
+	err := json.Unmarshal(data, &all)
+	if err != nil {
+		return err
+	}
+
+	entity.Currencies = all.Currencies
+	entity.MaxTransfers = all.MaxTransfers
+	entity.LogLevel = all.LogLevel
+	entity.FinalizerInterval = 0
+	entity.MaxAmount = nil
+
+	if all.FinalizerInterval != "" {
+		entity.FinalizerInterval, err = time.ParseDuration(all.FinalizerInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	if all.MaxAmount != "" {
+		amount, ok := new(money.Dec).SetString(all.MaxAmount)
+		if !ok || amount.Sign() <= 0 {
+			return fmt.Errorf("invalid maxAmount %s", all.MaxAmount)
+		}
+		entity.MaxAmount = amount
+	}
+
+	return nil
+}
+
+// Check returns reason why transaction is not allowed by configuration
+func (entity *TenantConfig) Check(transaction *Transaction) error {
+	if entity == nil || transaction == nil {
+		return nil
+	}
+
+	if entity.MaxTransfers > 0 && len(transaction.Transfers) > entity.MaxTransfers {
+		return fmt.Errorf("transaction has %d transfers, at most %d allowed", len(transaction.Transfers), entity.MaxTransfers)
+	}
+
+	for _, transfer := range transaction.Transfers {
+		if len(entity.Currencies) > 0 {
+			allowed := false
+			for _, currency := range entity.Currencies {
+				if currency == transfer.Currency {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return fmt.Errorf("currency %s is not allowed", transfer.Currency)
+			}
+		}
+		if entity.MaxAmount != nil && transfer.Amount != nil && transfer.Amount.Cmp(entity.MaxAmount) > 0 {
+			return fmt.Errorf("amount %s exceeds %s", transfer.Amount.String(), entity.MaxAmount.String())
+		}
+	}
+
+	return nil
+}
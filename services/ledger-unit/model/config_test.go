@@ -0,0 +1,97 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	money "gopkg.in/inf.v0"
+)
+
+func TestTenantConfigDeserialize(t *testing.T) {
+	entity := new(TenantConfig)
+	err := entity.Deserialize([]byte(`{"currencies":["EUR","CZK"],"finalizerInterval":"1m","maxTransfers":10,"maxAmount":"1000.5","logLevel":"DEBUG"}`))
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if len(entity.Currencies) != 2 || entity.Currencies[0] != "EUR" || entity.Currencies[1] != "CZK" {
+		t.Errorf("unexpected currencies %+v", entity.Currencies)
+	}
+	if entity.FinalizerInterval != time.Minute {
+		t.Errorf("unexpected finalizer interval %v", entity.FinalizerInterval)
+	}
+	if entity.MaxTransfers != 10 {
+		t.Errorf("unexpected max transfers %d", entity.MaxTransfers)
+	}
+	if entity.MaxAmount == nil || entity.MaxAmount.Cmp(money.NewDec(10005, 1)) != 0 {
+		t.Errorf("unexpected max amount %v", entity.MaxAmount)
+	}
+	if entity.LogLevel != "DEBUG" {
+		t.Errorf("unexpected log level %s", entity.LogLevel)
+	}
+
+	if err = entity.Deserialize([]byte(`{}`)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if entity.MaxAmount != nil || entity.FinalizerInterval != 0 {
+		t.Errorf("expected previous values to be reset, got %+v", entity)
+	}
+
+	for _, value := range []string{"0", "-1", "1e10", "Inf", "NaN", "1_000", "0x10", " 1"} {
+		if entity.Deserialize([]byte(`{"maxAmount":"`+value+`"}`)) == nil {
+			t.Errorf("expected maxAmount %q to be rejected", value)
+		}
+	}
+	if entity.Deserialize([]byte(`{"finalizerInterval":"soon"}`)) == nil {
+		t.Errorf("expected invalid finalizerInterval to be rejected")
+	}
+
+	var nothing *TenantConfig
+	if nothing.Deserialize([]byte(`{}`)) == nil {
+		t.Errorf("expected nil pointer to be rejected")
+	}
+}
+
+func TestTenantConfigCheck(t *testing.T) {
+	transfer := func(amount int64, currency string) Transfer {
+		return Transfer{
+			IDTransfer: "1",
+			Credit:     Account{Tenant: "one", Name: "A"},
+			Debit:      Account{Tenant: "one", Name: "B"},
+			Amount:     money.NewDec(amount, 0),
+			Currency:   currency,
+		}
+	}
+
+	config := &TenantConfig{
+		Currencies:   []string{"EUR"},
+		MaxTransfers: 2,
+		MaxAmount:    money.NewDec(100, 0),
+	}
+
+	for _, transaction := range []Transaction{
+		{Transfers: []Transfer{transfer(1, "EUR")}},
+		{Transfers: []Transfer{transfer(100, "EUR"), transfer(1, "EUR")}},
+	} {
+		if err := config.Check(&transaction); err != nil {
+			t.Errorf("expected %+v to be allowed, got %+v", transaction, err)
+		}
+	}
+
+	for reason, transaction := range map[string]Transaction{
+		"too many transfers": {Transfers: []Transfer{transfer(1, "EUR"), transfer(1, "EUR"), transfer(1, "EUR")}},
+		"currency":           {Transfers: []Transfer{transfer(1, "CZK")}},
+		"amount":             {Transfers: []Transfer{transfer(1, "EUR"), transfer(101, "EUR")}},
+	} {
+		if err := config.Check(&transaction); err == nil {
+			t.Errorf("expected transaction to be rejected because of %s", reason)
+		}
+	}
+
+	var unconfigured *TenantConfig
+	if err := unconfigured.Check(&Transaction{Transfers: []Transfer{transfer(1000, "CZK")}}); err != nil {
+		t.Errorf("expected missing configuration to allow everything, got %+v", err)
+	}
+	if err := new(TenantConfig).Check(&Transaction{Transfers: []Transfer{transfer(1000, "CZK")}}); err != nil {
+		t.Errorf("expected empty configuration to allow everything, got %+v", err)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build go1.18
+// +build go1.18
+
+package model
+
+import (
+	"testing"
+)
+
+func FuzzTransactionDeserialize(f *testing.F) {
+	f.Add([]byte("committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR\n"))
+	f.Add([]byte("new\n"))
+	f.Add([]byte("committed\nd=payroll&t.batch=7\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR e=E2E%201\n"))
+	f.Add([]byte("committed"))
+	f.Add([]byte(""))
+	f.Add([]byte("\n\n\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		entity := new(Transaction)
+		if err := entity.Deserialize(data); err != nil {
+			return
+		}
+		again := new(Transaction)
+		if err := again.Deserialize(entity.Serialize()); err != nil {
+			t.Fatalf("serialized transaction %q does not deserialize %+v", string(entity.Serialize()), err)
+		}
+		if !equalTransactions(*entity, *again) {
+			t.Fatalf("transaction %+v does not round trip, got %+v", entity, again)
+		}
+	})
+}
+
+func FuzzMetadataDeserialize(f *testing.F) {
+	f.Add("d=rent%20%3B%20march&e=E2E&r=invoice&t.kind=rent")
+	f.Add("t.=x")
+	f.Add("d=%ZZ")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, data string) {
+		entity := new(Metadata)
+		if err := entity.Deserialize(data); err != nil {
+			return
+		}
+		if entity.IsEmpty() {
+			t.Fatalf("empty metadata deserialized from %q", data)
+		}
+		again := new(Metadata)
+		if err := again.Deserialize(entity.Serialize()); err != nil {
+			t.Fatalf("serialized metadata %q does not deserialize %+v", entity.Serialize(), err)
+		}
+		if !entity.Equal(*again) {
+			t.Fatalf("metadata %+v does not round trip, got %+v", entity, again)
+		}
+	})
+}
+
+func FuzzTenantConfigDeserialize(f *testing.F) {
+	f.Add([]byte(`{"currencies":["EUR"],"finalizerInterval":"1m","maxTransfers":10,"maxAmount":"100","logLevel":"DEBUG"}`))
+	f.Add([]byte(`{}`))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		entity := new(TenantConfig)
+		entity.Deserialize(data)
+	})
+}
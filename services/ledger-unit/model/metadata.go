@@ -0,0 +1,208 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxDescriptionLength is maximal number of characters of description
+	MaxDescriptionLength = 140
+	// MaxEndToEndIDLength is maximal number of characters of end to end id
+	MaxEndToEndIDLength = 35
+	// MaxRemittanceInformationLength is maximal number of characters of
+	// remittance information
+	MaxRemittanceInformationLength = 140
+	// MaxTags is maximal number of tags
+	MaxTags = 16
+	// MaxTagKeyLength is maximal number of characters of tag key
+	MaxTagKeyLength = 32
+	// MaxTagValueLength is maximal number of characters of tag value
+	MaxTagValueLength = 256
+)
+
+const (
+	metadataDescription           = "d"
+	metadataEndToEndID            = "e"
+	metadataRemittanceInformation = "r"
+	metadataTagPrefix             = "t."
+)
+
+// Metadata represents descriptive data of transaction or transfer
+type Metadata struct {
+	Description           string            `json:"description,omitempty"`
+	EndToEndID            string            `json:"endToEndId,omitempty"`
+	RemittanceInformation string            `json:"remittanceInformation,omitempty"`
+	Tags                  map[string]string `json:"tags,omitempty"`
+}
+
+// IsEmpty tells whenever metadata carry no data
+func (entity Metadata) IsEmpty() bool {
+	return entity.Description == "" && entity.EndToEndID == "" && entity.RemittanceInformation == "" && len(entity.Tags) == 0
+}
+
+// Equal tells whenever metadata carry same data
+func (entity Metadata) Equal(other Metadata) bool {
+	if entity.Description != other.Description ||
+		entity.EndToEndID != other.EndToEndID ||
+		entity.RemittanceInformation != other.RemittanceInformation ||
+		len(entity.Tags) != len(other.Tags) {
+		return false
+	}
+	for key, value := range entity.Tags {
+		if candidate, ok := other.Tags[key]; !ok || candidate != value {
+			return false
+		}
+	}
+	return true
+}
+
+// identity returns serialized metadata identifying payment, description and
+// tags are annotations not taken into account
+func (entity Metadata) identity() string {
+	return Metadata{
+		EndToEndID:            entity.EndToEndID,
+		RemittanceInformation: entity.RemittanceInformation,
+	}.Serialize()
+}
+
+func validateText(name string, value string, limit int) error {
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("%s is not valid utf-8", name)
+	}
+	if utf8.RuneCountInString(value) > limit {
+		return fmt.Errorf("%s is longer than %d characters", name, limit)
+	}
+	for _, c := range value {
+		if unicode.IsControl(c) {
+			return fmt.Errorf("%s contains control character", name)
+		}
+	}
+	return nil
+}
+
+func isTagKey(value string) bool {
+	if value == "" || len(value) > MaxTagKeyLength {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' && c != '-' && c != '.' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate returns error describing why metadata exceed bounds
+func (entity Metadata) Validate() error {
+	if err := validateText("description", entity.Description, MaxDescriptionLength); err != nil {
+		return err
+	}
+	if err := validateText("endToEndId", entity.EndToEndID, MaxEndToEndIDLength); err != nil {
+		return err
+	}
+	if err := validateText("remittanceInformation", entity.RemittanceInformation, MaxRemittanceInformationLength); err != nil {
+		return err
+	}
+	if len(entity.Tags) > MaxTags {
+		return fmt.Errorf("more than %d tags", MaxTags)
+	}
+	for key, value := range entity.Tags {
+		if !isTagKey(key) {
+			return fmt.Errorf("invalid tag key %q", key)
+		}
+		if value == "" {
+			return fmt.Errorf("tag %s has no value", key)
+		}
+		if err := validateText("tag "+key, value, MaxTagValueLength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Serialize metadata to single token free of whitespace and semicolons
+func (entity Metadata) Serialize() string {
+	values := make(url.Values)
+	if entity.Description != "" {
+		values.Set(metadataDescription, entity.Description)
+	}
+	if entity.EndToEndID != "" {
+		values.Set(metadataEndToEndID, entity.EndToEndID)
+	}
+	if entity.RemittanceInformation != "" {
+		values.Set(metadataRemittanceInformation, entity.RemittanceInformation)
+	}
+	for key, value := range entity.Tags {
+		values.Set(metadataTagPrefix+key, value)
+	}
+	return strings.Replace(values.Encode(), "+", "%20", -1)
+}
+
+// Deserialize metadata from token
+func (entity *Metadata) Deserialize(data string) error {
+	if entity == nil {
+		return fmt.Errorf("cannot deserialize to nil pointer")
+	}
+	if data == "" || strings.ContainsAny(data, " ;\n") {
+		return fmt.Errorf("malformed metadata")
+	}
+	result := Metadata{}
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(data, "&") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("malformed metadata")
+		}
+		key, err := url.QueryUnescape(parts[0])
+		if err != nil {
+			return fmt.Errorf("malformed metadata")
+		}
+		value, err := url.QueryUnescape(parts[1])
+		if err != nil || value == "" {
+			return fmt.Errorf("malformed metadata")
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate metadata %s", key)
+		}
+		seen[key] = true
+		switch {
+		case key == metadataDescription:
+			result.Description = value
+		case key == metadataEndToEndID:
+			result.EndToEndID = value
+		case key == metadataRemittanceInformation:
+			result.RemittanceInformation = value
+		case strings.HasPrefix(key, metadataTagPrefix):
+			if result.Tags == nil {
+				result.Tags = make(map[string]string)
+			}
+			result.Tags[key[len(metadataTagPrefix):]] = value
+		default:
+			return fmt.Errorf("unknown metadata %s", key)
+		}
+	}
+	if err := result.Validate(); err != nil {
+		return err
+	}
+	*entity = result
+	return nil
+}
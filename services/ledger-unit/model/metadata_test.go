@@ -0,0 +1,145 @@
+package model
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	money "gopkg.in/inf.v0"
+)
+
+func randomMetadata(random *rand.Rand) Metadata {
+	text := func() string {
+		alphabet := []rune("ab Z09;&=%+/\\?#ěščř€\"'")
+		result := make([]rune, random.Intn(20))
+		for i := range result {
+			result[i] = alphabet[random.Intn(len(alphabet))]
+		}
+		return string(result)
+	}
+	result := Metadata{}
+	if random.Intn(3) == 0 {
+		return result
+	}
+	result.Description = text()
+	result.EndToEndID = text()
+	result.RemittanceInformation = text()
+	for i := random.Intn(4); i > 0; i-- {
+		if value := text(); value != "" {
+			if result.Tags == nil {
+				result.Tags = make(map[string]string)
+			}
+			result.Tags[randomToken(random)] = value
+		}
+	}
+	return result
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		expected := randomMetadata(random)
+		if expected.IsEmpty() {
+			continue
+		}
+		data := expected.Serialize()
+		if strings.ContainsAny(data, " ;\n") {
+			t.Fatalf("serialized metadata %q is not single token", data)
+		}
+		actual := Metadata{}
+		if err := actual.Deserialize(data); err != nil {
+			t.Fatalf("unexpected error %+v deserializing %q", err, data)
+		}
+		if !expected.Equal(actual) {
+			t.Fatalf("expected %+v got %+v", expected, actual)
+		}
+	}
+}
+
+func TestMetadataValidate(t *testing.T) {
+	valid := Metadata{
+		Description:           strings.Repeat("ř", MaxDescriptionLength),
+		EndToEndID:            strings.Repeat("x", MaxEndToEndIDLength),
+		RemittanceInformation: "invoice 42",
+		Tags:                  map[string]string{"cost-center.eu_1": "a b"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error %+v", err)
+	}
+
+	tooManyTags := Metadata{Tags: make(map[string]string)}
+	for i := 0; i <= MaxTags; i++ {
+		tooManyTags.Tags[strings.Repeat("k", i+1)] = "v"
+	}
+
+	for _, entity := range []Metadata{
+		{Description: strings.Repeat("x", MaxDescriptionLength+1)},
+		{EndToEndID: strings.Repeat("x", MaxEndToEndIDLength+1)},
+		{RemittanceInformation: strings.Repeat("x", MaxRemittanceInformationLength+1)},
+		{Description: "line\nbreak"},
+		{Description: "\xff"},
+		{Tags: map[string]string{"": "v"}},
+		{Tags: map[string]string{"with space": "v"}},
+		{Tags: map[string]string{strings.Repeat("k", MaxTagKeyLength+1): "v"}},
+		{Tags: map[string]string{"k": ""}},
+		{Tags: map[string]string{"k": strings.Repeat("v", MaxTagValueLength+1)}},
+		tooManyTags,
+	} {
+		if err := entity.Validate(); err == nil {
+			t.Errorf("expected error validating %+v", entity)
+		}
+	}
+}
+
+func TestDeserializeMalformedMetadata(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"d",
+		"d=",
+		"d=a&d=b",
+		"x=a",
+		"d=%ZZ",
+		"d=a b",
+		"d=a;b",
+		"t.=a",
+		"t.a%20b=c",
+	} {
+		entity := new(Metadata)
+		if err := entity.Deserialize(data); err == nil {
+			t.Errorf("expected error deserializing %q", data)
+		}
+	}
+}
+
+func TestIsSameAsConsidersMetadataIdentity(t *testing.T) {
+	newTransaction := func(transaction Metadata, transfer Metadata) *Transaction {
+		return &Transaction{
+			IDTransaction: "xyz",
+			Metadata:      transaction,
+			Transfers: []Transfer{
+				{
+					IDTransfer: "1",
+					Credit:     Account{Tenant: "one", Name: "A"},
+					Debit:      Account{Tenant: "one", Name: "B"},
+					ValueDate:  "2020-01-01T00:00:00Z",
+					Amount:     money.NewDec(1, 0),
+					Currency:   "EUR",
+					Metadata:   transfer,
+				},
+			},
+		}
+	}
+
+	original := newTransaction(Metadata{EndToEndID: "E1", Description: "rent"}, Metadata{RemittanceInformation: "march"})
+
+	if !original.IsSameAs(newTransaction(Metadata{EndToEndID: "E1", Tags: map[string]string{"k": "v"}}, Metadata{RemittanceInformation: "march", Description: "x"})) {
+		t.Errorf("expected transactions differing in annotations to be same")
+	}
+	if original.IsSameAs(newTransaction(Metadata{EndToEndID: "E2"}, Metadata{RemittanceInformation: "march"})) {
+		t.Errorf("expected transactions differing in end to end id not to be same")
+	}
+	if original.IsSameAs(newTransaction(Metadata{EndToEndID: "E1"}, Metadata{RemittanceInformation: "april"})) {
+		t.Errorf("expected transactions differing in remittance information not to be same")
+	}
+}
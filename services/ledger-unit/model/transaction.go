@@ -16,11 +16,15 @@ package model
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 
 	money "gopkg.in/inf.v0"
 )
 
+// ErrMalformedAmount is returned when transfer amount is not valid decimal
+var ErrMalformedAmount = fmt.Errorf("malformed transfer amount")
+
 // Transfer represents ingress/egress message of transfer
 type Transfer struct {
 	IDTransfer string
@@ -29,12 +33,14 @@ type Transfer struct {
 	ValueDate  string
 	Amount     *money.Dec
 	Currency   string
+	Metadata   Metadata
 }
 
 // Transaction represents egress message of transaction
 type Transaction struct {
 	IDTransaction string
 	State         string
+	Metadata      Metadata
 	Transfers     []Transfer
 }
 
@@ -45,6 +51,11 @@ func (entity *Transaction) Serialize() []byte {
 	buffer.WriteString(entity.State)
 	buffer.WriteString("\n")
 
+	if !entity.Metadata.IsEmpty() {
+		buffer.WriteString(entity.Metadata.Serialize())
+		buffer.WriteString("\n")
+	}
+
 	for _, transfer := range entity.Transfers {
 		buffer.WriteString(transfer.IDTransfer)
 		buffer.WriteString(" ")
@@ -61,6 +72,10 @@ func (entity *Transaction) Serialize() []byte {
 		buffer.WriteString(transfer.Amount.String())
 		buffer.WriteString(" ")
 		buffer.WriteString(transfer.Currency)
+		if !transfer.Metadata.IsEmpty() {
+			buffer.WriteString(" ")
+			buffer.WriteString(transfer.Metadata.Serialize())
+		}
 		buffer.WriteString("\n")
 	}
 
@@ -68,63 +83,73 @@ func (entity *Transaction) Serialize() []byte {
 }
 
 // Deserialize transaction from binary data
-func (entity *Transaction) Deserialize(data []byte) {
+func (entity *Transaction) Deserialize(data []byte) error {
 	if entity == nil {
-		return
+		return fmt.Errorf("cannot deserialize to nil pointer")
 	}
 
-	entity.Transfers = make([]Transfer, 0)
-
-	var j = bytes.IndexByte(data, '\n')
+	j := bytes.IndexByte(data, '\n')
+	if j < 0 {
+		return fmt.Errorf("malformed transaction")
+	}
 
 	entity.State = string(data[0:j])
+	entity.Metadata = Metadata{}
+	entity.Transfers = make([]Transfer, 0)
 
-	var i = j + 1
-	var transfer []string
-
-scan:
-	j = bytes.IndexByte(data[i:], '\n')
-	if j < 0 {
-		if len(data) > 0 {
-			transfer = strings.SplitN(string(data[i:]), " ", 8)
-			goto parse
+	for idx, line := range bytes.Split(data[j+1:], []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		transfer := strings.Split(string(line), " ")
+		if len(transfer) == 1 && idx == 0 {
+			if err := entity.Metadata.Deserialize(transfer[0]); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(transfer) != 8 && len(transfer) != 9 {
+			return fmt.Errorf("malformed transfer")
+		}
+		amount, ok := new(money.Dec).SetString(transfer[6])
+		if !ok {
+			return ErrMalformedAmount
 		}
-		return
+		var metadata Metadata
+		if len(transfer) == 9 {
+			if err := metadata.Deserialize(transfer[8]); err != nil {
+				return err
+			}
+		}
+		entity.Transfers = append(entity.Transfers, Transfer{
+			IDTransfer: transfer[0],
+			Credit: Account{
+				Tenant: transfer[1],
+				Name:   transfer[2],
+			},
+			Debit: Account{
+				Tenant: transfer[3],
+				Name:   transfer[4],
+			},
+			ValueDate: transfer[5],
+			Amount:    amount,
+			Currency:  transfer[7],
+			Metadata:  metadata,
+		})
 	}
-	j += i
-	transfer = strings.SplitN(string(data[i:j]), " ", 8)
 
-parse:
-	if len(transfer) != 8 {
-		return
-	}
-	amount, _ := new(money.Dec).SetString(transfer[6])
-
-	entity.Transfers = append(entity.Transfers, Transfer{
-		IDTransfer: transfer[0],
-		Credit: Account{
-			Tenant: transfer[1],
-			Name:   transfer[2],
-		},
-		Debit: Account{
-			Tenant: transfer[3],
-			Name:   transfer[4],
-		},
-		ValueDate: transfer[5],
-		Amount:    amount,
-		Currency:  transfer[7],
-	})
-
-	i = j + 1
-	goto scan
+	return nil
 }
 
 // DeserializeState deserializes transaction state from binary data
-func (entity *Transaction) DeserializeState(data []byte) {
+func (entity *Transaction) DeserializeState(data []byte) error {
 	if entity == nil {
-		return
+		return fmt.Errorf("cannot deserialize to nil pointer")
+	}
+	j := bytes.IndexByte(data, '\n')
+	if j < 0 {
+		return fmt.Errorf("malformed transaction")
 	}
-	var j = bytes.IndexByte(data, '\n')
 	entity.State = string(data[0:j])
-	return
+	return nil
 }
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"math/rand"
+	"testing"
+
+	money "gopkg.in/inf.v0"
+)
+
+func randomToken(random *rand.Rand) string {
+	alphabet := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-:."
+	result := make([]byte, 1+random.Intn(16))
+	for i := range result {
+		result[i] = alphabet[random.Intn(len(alphabet))]
+	}
+	return string(result)
+}
+
+func equalTransactions(a Transaction, b Transaction) bool {
+	if a.State != b.State || !a.Metadata.Equal(b.Metadata) || len(a.Transfers) != len(b.Transfers) {
+		return false
+	}
+	for i := range a.Transfers {
+		x, y := a.Transfers[i], b.Transfers[i]
+		if x.IDTransfer != y.IDTransfer ||
+			x.Credit != y.Credit ||
+			x.Debit != y.Debit ||
+			x.ValueDate != y.ValueDate ||
+			x.Amount.Cmp(y.Amount) != 0 ||
+			x.Currency != y.Currency ||
+			!x.Metadata.Equal(y.Metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTransactionRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		expected := Transaction{
+			IDTransaction: "xxx",
+			State:         randomToken(random),
+			Metadata:      randomMetadata(random),
+			Transfers:     make([]Transfer, random.Intn(5)),
+		}
+		for j := range expected.Transfers {
+			expected.Transfers[j] = Transfer{
+				IDTransfer: randomToken(random),
+				Credit:     Account{Tenant: randomToken(random), Name: randomToken(random)},
+				Debit:      Account{Tenant: randomToken(random), Name: randomToken(random)},
+				ValueDate:  randomToken(random),
+				Amount:     money.NewDec(random.Int63()-random.Int63(), money.Scale(random.Intn(10))),
+				Currency:   randomToken(random),
+				Metadata:   randomMetadata(random),
+			}
+		}
+
+		actual := Transaction{IDTransaction: "xxx"}
+		if err := actual.Deserialize(expected.Serialize()); err != nil {
+			t.Fatalf("unexpected error %+v deserializing %q", err, string(expected.Serialize()))
+		}
+		if !equalTransactions(expected, actual) {
+			t.Fatalf("expected %+v got %+v", expected, actual)
+		}
+
+		state := Transaction{}
+		if err := state.DeserializeState(expected.Serialize()); err != nil || state.State != expected.State {
+			t.Fatalf("expected state %s got %s", expected.State, state.State)
+		}
+	}
+}
+
+func TestDeserializeMalformedTransaction(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"committed",
+		"committed\nt1 one A one B 2020-01-01T00:00:00Z 1",
+		"committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR x\n",
+		"committed\nt1 one A one B 2020-01-01T00:00:00Z abc EUR\n",
+		"committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR\nt2",
+		"committed\nd=x\nd=y\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR\n",
+		"committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR d=x\nd=y\n",
+		"committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR x=1\n",
+		"committed\nt1 one A one B 2020-01-01T00:00:00Z 1 EUR d=\n",
+	} {
+		entity := new(Transaction)
+		if err := entity.Deserialize([]byte(data)); err == nil {
+			t.Errorf("expected error deserializing %q", data)
+		}
+	}
+
+	entity := new(Transaction)
+	if err := entity.DeserializeState([]byte("committed")); err == nil {
+		t.Errorf("expected error deserializing state without newline")
+	}
+
+	var nothing *Transaction
+	if err := nothing.Deserialize([]byte("committed\n")); err == nil {
+		t.Errorf("expected error deserializing to nil pointer")
+	}
+}
+
+func TestCounterpartyTenants(t *testing.T) {
+	account := func(tenant string) Account {
+		return Account{Tenant: tenant, Name: "x"}
+	}
+
+	var empty *Transaction
+	if actual := empty.CounterpartyTenants("one"); len(actual) != 0 {
+		t.Errorf("expected no counterparty tenants of nil transaction got %v", actual)
+	}
+
+	transaction := Transaction{
+		Transfers: []Transfer{
+			{Credit: account("one"), Debit: account("one")},
+		},
+	}
+	if actual := transaction.CounterpartyTenants("one"); len(actual) != 0 {
+		t.Errorf("expected no counterparty tenants of local transaction got %v", actual)
+	}
+
+	transaction.Transfers = append(
+		transaction.Transfers,
+		Transfer{Credit: account("three"), Debit: account("one")},
+		Transfer{Credit: account("one"), Debit: account("two")},
+		Transfer{Credit: account("two"), Debit: account("three")},
+	)
+	actual := transaction.CounterpartyTenants("one")
+	if len(actual) != 2 || actual[0] != "three" || actual[1] != "two" {
+		t.Errorf("expected sorted unique counterparty tenants [three two] got %v", actual)
+	}
+}
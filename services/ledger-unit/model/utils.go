@@ -15,6 +15,8 @@
 package model
 
 import (
+	"sort"
+
 	money "gopkg.in/inf.v0"
 )
 
@@ -23,7 +25,9 @@ type negotiatonChunk struct {
 	Key      Account
 }
 
-// IsSameAs represents equality check of two Transactions
+// IsSameAs represents equality check of two Transactions, end to end id and
+// remittance information of metadata are considered while description and
+// tags are not
 func (entity *Transaction) IsSameAs(obj *Transaction) bool {
 	if entity == nil || obj == nil {
 		return false
@@ -33,6 +37,10 @@ func (entity *Transaction) IsSameAs(obj *Transaction) bool {
 		return false
 	}
 
+	if entity.Metadata.identity() != obj.Metadata.identity() {
+		return false
+	}
+
 	leftLen := len(entity.Transfers)
 	rightLen := len(obj.Transfers)
 
@@ -44,11 +52,11 @@ func (entity *Transaction) IsSameAs(obj *Transaction) bool {
 	y := make([]string, rightLen)
 
 	for i, e := range entity.Transfers {
-		x[i] = e.Credit.Tenant + "/" + e.Credit.Name + "/" + e.Debit.Tenant + "/" + e.Debit.Name + "/" + e.Amount.String() + "/" + e.Currency
+		x[i] = e.Credit.Tenant + "/" + e.Credit.Name + "/" + e.Debit.Tenant + "/" + e.Debit.Name + "/" + e.Amount.String() + "/" + e.Currency + "/" + e.Metadata.identity()
 	}
 
 	for i, e := range obj.Transfers {
-		y[i] = e.Credit.Tenant + "/" + e.Credit.Name + "/" + e.Debit.Tenant + "/" + e.Debit.Name + "/" + e.Amount.String() + "/" + e.Currency
+		y[i] = e.Credit.Tenant + "/" + e.Credit.Name + "/" + e.Debit.Tenant + "/" + e.Debit.Name + "/" + e.Amount.String() + "/" + e.Currency + "/" + e.Metadata.identity()
 	}
 
 	visited := make([]bool, len(y))
@@ -109,3 +117,28 @@ func (entity *Transaction) PrepareRemoteNegotiation() map[Account]string {
 
 	return result
 }
+
+// CounterpartyTenants returns sorted unique tenants other than given tenant
+// which accounts are involved in transaction
+func (entity *Transaction) CounterpartyTenants(tenant string) []string {
+	if entity == nil {
+		return nil
+	}
+
+	visited := make(map[string]bool)
+	result := make([]string, 0)
+
+	for _, transfer := range entity.Transfers {
+		for _, candidate := range []string{transfer.Credit.Tenant, transfer.Debit.Tenant} {
+			if candidate == tenant || visited[candidate] {
+				continue
+			}
+			visited[candidate] = true
+			result = append(result, candidate)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
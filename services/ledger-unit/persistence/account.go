@@ -0,0 +1,125 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/support/naming"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// AccountIndexMark is file marking that account index of tenant covers all
+// committed transactions involving it
+const AccountIndexMark = "account_index"
+
+// isIndexableAccount returns true if name of account can be used as path
+// segment, other accounts cannot be addressed by ledger-rest anyway
+func isIndexableAccount(account model.Account) bool {
+	return account.Name != "" && account.Name != "." && account.Name != ".." && !strings.Contains(account.Name, "/")
+}
+
+// IndexAccountEntries persist entries of committed transaction of origin
+// tenant into account index of every account it involves, rewriting existing
+// entry is no-op
+func IndexAccountEntries(shared localfs.Storage, origin string, entity *model.Transaction) error {
+	visited := make(map[model.Account]bool)
+	for _, transfer := range entity.Transfers {
+		for _, account := range []model.Account{transfer.Credit, transfer.Debit} {
+			if visited[account] || !isIndexableAccount(account) {
+				continue
+			}
+			visited[account] = true
+			if err := naming.ValidateTenant(account.Tenant); err != nil {
+				return err
+			}
+			entryPath := "t_" + account.Tenant + "/account/" + account.Name + "/" + origin + "/" + entity.IDTransaction
+			if ok, err := shared.Exists(entryPath); err == nil && ok {
+				continue
+			}
+			if err := shared.WriteFile(entryPath, []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func listDirectory(storage localfs.Storage, path string) ([]string, error) {
+	exists, err := storage.Exists(path)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return storage.ListDirectory(path, true)
+}
+
+// BackfillAccountIndex indexes committed transactions of tenant and committed
+// transactions of other tenants referencing it that were journaled before
+// account index existed, tenant is marked as indexed once all of them are
+func BackfillAccountIndex(storage localfs.Storage, shared localfs.Storage, tenant string) error {
+	if ok, err := storage.Exists(AccountIndexMark); err != nil || ok {
+		return err
+	}
+	ids, err := listDirectory(storage, "transaction")
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		entity, err := LoadTransaction(storage, id)
+		if err != nil {
+			log.Warn().Msgf("Skipping index of transaction %s %+v", id, err)
+			continue
+		}
+		if entity.State != StatusCommitted {
+			continue
+		}
+		if err = IndexAccountEntries(shared, tenant, entity); err != nil {
+			return err
+		}
+	}
+	origins, err := listDirectory(storage, "inbound")
+	if err != nil {
+		return err
+	}
+	for _, origin := range origins {
+		ids, err := listDirectory(storage, "inbound/"+origin)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			data, err := shared.ReadFileFully("t_" + origin + "/transaction/" + id)
+			if err != nil {
+				log.Warn().Msgf("Skipping index of transaction %s of tenant %s %+v", id, origin, err)
+				continue
+			}
+			entity := new(model.Transaction)
+			entity.IDTransaction = id
+			if err = entity.Deserialize(data); err != nil {
+				log.Warn().Msgf("Skipping index of transaction %s of tenant %s %+v", id, origin, err)
+				continue
+			}
+			if entity.State != StatusCommitted {
+				continue
+			}
+			if err = IndexAccountEntries(shared, origin, entity); err != nil {
+				return fmt.Errorf("failed to index transaction %s of tenant %s %+v", id, origin, err)
+			}
+		}
+	}
+	return storage.WriteFile(AccountIndexMark, []byte{})
+}
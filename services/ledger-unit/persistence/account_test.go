@@ -0,0 +1,103 @@
+package persistence
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+func TestIndexAccountEntries(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "account")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	shared, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	transaction := newTransaction(StatusCommitted)
+	transaction.Transfers = append(transaction.Transfers, transaction.Transfers[0])
+	transaction.Transfers[1].Credit = model.Account{Tenant: "two", Name: ".."}
+
+	t.Log("indexes every addressable account once")
+	{
+		if err := IndexAccountEntries(shared, "one", transaction); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		for _, path := range []string{"t_one/account/credit/one/xxx", "t_one/account/debit/one/xxx"} {
+			if ok, _ := shared.Exists(path); !ok {
+				t.Errorf("expected account index entry %s", path)
+			}
+		}
+		if ok, _ := shared.Exists("t_two"); ok {
+			t.Errorf("expected account which name is not path segment to be skipped")
+		}
+	}
+
+	t.Log("refuses invalid tenant")
+	{
+		transaction.Transfers[1].Credit = model.Account{Tenant: "../one", Name: "credit"}
+		if err := IndexAccountEntries(shared, "one", transaction); err == nil {
+			t.Errorf("expected error")
+		}
+	}
+}
+
+func TestBackfillAccountIndex(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "account")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	shared, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	storage, err := localfs.NewPlaintextStorage(tmpdir + "/t_one")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	shared.WriteFile("t_one/transaction/a", []byte("committed\n1 one A one B 2020-01-01T00:00:00Z 1 EUR\n"))
+	shared.WriteFile("t_one/transaction/b", []byte("rollbacked\n2 one A one B 2020-01-01T00:00:00Z 1 EUR\n"))
+	shared.WriteFile("t_one/transaction/c", []byte("garbage"))
+	shared.WriteFile("t_two/transaction/d", []byte("committed\n3 two C one A 2020-01-01T00:00:00Z 1 EUR\n"))
+	shared.WriteFile("t_one/inbound/two/d", []byte("committed"))
+	shared.WriteFile("t_one/inbound/two/missing", []byte("committed"))
+
+	if err := BackfillAccountIndex(storage, shared, "one"); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	for path, expected := range map[string]bool{
+		"t_one/account/A/one/a":     true,
+		"t_one/account/B/one/a":     true,
+		"t_one/account/A/one/b":     false,
+		"t_one/account/A/two/d":     true,
+		"t_two/account/C/two/d":     true,
+		"t_one/" + AccountIndexMark: true,
+	} {
+		if ok, _ := shared.Exists(path); ok != expected {
+			t.Errorf("expected %s to exist %v", path, expected)
+		}
+	}
+
+	t.Log("is no-op once tenant is indexed")
+	{
+		shared.WriteFile("t_one/transaction/e", []byte("committed\n4 one A one B 2020-01-01T00:00:00Z 1 EUR\n"))
+		if err := BackfillAccountIndex(storage, shared, "one"); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if ok, _ := shared.Exists("t_one/account/A/one/e"); ok {
+			t.Errorf("expected transaction not to be indexed again")
+		}
+	}
+}
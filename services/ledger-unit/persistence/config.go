@@ -0,0 +1,51 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// LoadTenantConfigModification returns time of last modification of tenant
+// configuration, zero time if there is none
+func LoadTenantConfigModification(storage localfs.Storage) (time.Time, error) {
+	ok, err := storage.Exists("config")
+	if err != nil || !ok {
+		return time.Time{}, err
+	}
+	return storage.LastModification("config")
+}
+
+// LoadTenantConfig loads tenant configuration, empty configuration if there
+// is none
+func LoadTenantConfig(storage localfs.Storage) (*model.TenantConfig, error) {
+	result := new(model.TenantConfig)
+	ok, err := storage.Exists("config")
+	if err != nil || !ok {
+		return result, err
+	}
+	data, err := storage.ReadFileFully("config")
+	if err != nil {
+		return nil, err
+	}
+	if err = result.Deserialize(data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
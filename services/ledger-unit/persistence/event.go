@@ -0,0 +1,114 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// EventLog represents durable sequence of transaction state changes
+type EventLog struct {
+	storage  localfs.Storage
+	mutex    sync.Mutex
+	sequence uint64
+}
+
+// NewEventLog returns event log continuing after last persisted event
+func NewEventLog(storage localfs.Storage) *EventLog {
+	result := &EventLog{
+		storage:  storage,
+		sequence: 0,
+	}
+	ok, err := storage.Exists("event")
+	if err != nil || !ok {
+		return result
+	}
+	events, err := storage.ListDirectory("event", false)
+	if err != nil || len(events) == 0 {
+		return result
+	}
+	sequence, err := strconv.ParseUint(events[0], 10, 64)
+	if err != nil {
+		log.Warn().Msgf("Invalid last event %s", events[0])
+		return result
+	}
+	result.sequence = sequence
+	return result
+}
+
+// Append persists state change of transaction as next event in sequence,
+// slot occupied by event torn by failed write is skipped so that sequence
+// continues after it
+func (events *EventLog) Append(entity *model.Transaction) error {
+	if events == nil {
+		return nil
+	}
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+
+	now := time.Now().UTC()
+	data := entity.IDTransaction + " " + entity.State + " " + now.Format(time.RFC3339Nano)
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		next := events.sequence + 1
+		eventPath := "event/" + fmt.Sprintf("%020d", next)
+		err = events.storage.WriteFileExclusive(eventPath, []byte(data))
+		if err == nil {
+			events.sequence = next
+			events.recordCreation(entity.IDTransaction, now)
+			return nil
+		}
+		if ok, _ := events.storage.Exists(eventPath); !ok {
+			return err
+		}
+		log.Warn().Msgf("Skipping occupied event %s %+v", eventPath, err)
+		events.sequence = next
+	}
+	return err
+}
+
+// recordCreation persists time of first event of transaction into creation
+// index unless it is already there
+func (events *EventLog) recordCreation(id string, at time.Time) {
+	creationPath := "creation/" + id
+	if ok, err := events.storage.Exists(creationPath); err != nil || ok {
+		return
+	}
+	if err := events.storage.WriteFileExclusive(creationPath, []byte(at.Format(time.RFC3339Nano))); err != nil {
+		log.Warn().Msgf("Failed to index creation of transaction %s %+v", id, err)
+	}
+}
+
+// LoadCreation returns time of first event of transaction from creation
+// index
+func LoadCreation(storage localfs.Storage, id string) (time.Time, bool) {
+	data, err := storage.ReadFileFully("creation/" + id)
+	if err != nil {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339Nano, string(data))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return at, true
+}
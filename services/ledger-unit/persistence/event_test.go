@@ -0,0 +1,244 @@
+package persistence
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jancajthaml-openbank/ledger-unit/support/faults"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+func setupEventStorage(t *testing.T) (string, localfs.Storage) {
+	tmpdir, err := ioutil.TempDir("", "events")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		os.RemoveAll(tmpdir)
+		t.Fatalf("unexpected error %+v", err)
+	}
+	return tmpdir, storage
+}
+
+func readEvent(t *testing.T, storage localfs.Storage, sequence string) []string {
+	t.Helper()
+	data, err := storage.ReadFileFully("event/" + sequence)
+	if err != nil {
+		t.Fatalf("expected event %s got %+v", sequence, err)
+	}
+	return strings.Split(string(data), " ")
+}
+
+// failingEventLog returns event log that cannot append any event
+func failingEventLog(t *testing.T, storage localfs.Storage) *EventLog {
+	t.Helper()
+	injector, err := faults.NewInjector("fail-write@event/", 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	return NewEventLog(injector.Storage(storage))
+}
+
+func TestEventLogAppend(t *testing.T) {
+	tmpdir, storage := setupEventStorage(t)
+	defer os.RemoveAll(tmpdir)
+
+	var empty *EventLog
+	if err := empty.Append(newTransaction(StatusNew)); err != nil {
+		t.Errorf("expected nil event log to ignore append got %+v", err)
+	}
+
+	events := NewEventLog(storage)
+	if err := events.Append(newTransaction(StatusNew)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if err := events.Append(newTransaction(StatusAccepted)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	first := readEvent(t, storage, "00000000000000000001")
+	if len(first) != 3 || first[0] != "xxx" || first[1] != StatusNew {
+		t.Errorf("unexpected first event %v", first)
+	}
+	second := readEvent(t, storage, "00000000000000000002")
+	if len(second) != 3 || second[0] != "xxx" || second[1] != StatusAccepted {
+		t.Errorf("unexpected second event %v", second)
+	}
+
+	t.Log("reopened event log continues after last event")
+
+	events = NewEventLog(storage)
+	if err := events.Append(newTransaction(StatusCommitted)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	third := readEvent(t, storage, "00000000000000000003")
+	if len(third) != 3 || third[1] != StatusCommitted {
+		t.Errorf("unexpected third event %v", third)
+	}
+}
+
+func TestEventLogAppendFailure(t *testing.T) {
+	tmpdir, storage := setupEventStorage(t)
+	defer os.RemoveAll(tmpdir)
+
+	t.Log("failed write does not advance sequence")
+	{
+		injector, err := faults.NewInjector("fail-write@event/", 1, nil)
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		events := NewEventLog(injector.Storage(storage))
+		if err := events.Append(newTransaction(StatusNew)); err == nil {
+			t.Errorf("expected append to fail")
+		}
+		if events.sequence != 0 {
+			t.Errorf("expected sequence not to advance on failure got %d", events.sequence)
+		}
+	}
+
+	t.Log("append continues after occupied slot")
+	{
+		events := NewEventLog(storage)
+		if err := storage.WriteFile("event/00000000000000000001", []byte("yyy ne")); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if err := events.Append(newTransaction(StatusNew)); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if events.sequence != 2 {
+			t.Errorf("expected sequence to skip occupied slot got %d", events.sequence)
+		}
+		event := readEvent(t, storage, "00000000000000000002")
+		if len(event) != 3 || event[0] != "xxx" || event[1] != StatusNew {
+			t.Errorf("unexpected event %v", event)
+		}
+	}
+
+	t.Log("append continues after slots torn by failed writes")
+	{
+		injector, err := faults.NewInjector("partial-write@event/", 1, nil)
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		events := NewEventLog(injector.Storage(storage))
+		if err := events.Append(newTransaction(StatusAccepted)); err == nil {
+			t.Errorf("expected append to fail")
+		}
+		events.storage = storage
+		if err := events.Append(newTransaction(StatusAccepted)); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		event := readEvent(t, storage, fmt.Sprintf("%020d", events.sequence))
+		if len(event) != 3 || event[1] != StatusAccepted {
+			t.Errorf("unexpected event %v", event)
+		}
+		if events.sequence != 5 {
+			t.Errorf("expected sequence to skip torn slots got %d", events.sequence)
+		}
+	}
+}
+
+func TestEventOfInterruptedUpdateIsNotLost(t *testing.T) {
+	tmpdir, storage := setupEventStorage(t)
+	defer os.RemoveAll(tmpdir)
+
+	events := NewEventLog(storage)
+	if err := CreateTransaction(storage, events, newTransaction(StatusNew)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	t.Log("pending copy is kept when event cannot be appended")
+
+	if err := UpdateTransaction(storage, failingEventLog(t, storage), newTransaction(StatusAccepted)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if !HasPendingUpdate(storage, "xxx") {
+		t.Fatalf("expected pending copy to be kept")
+	}
+	if state, _ := LoadTransactionState(storage, "xxx"); state != StatusAccepted {
+		t.Errorf("expected state %s got %s", StatusAccepted, state)
+	}
+
+	t.Log("event is appended when pending copy is completed")
+
+	events = NewEventLog(storage)
+	if err := CompletePendingUpdate(storage, events, "xxx"); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if HasPendingUpdate(storage, "xxx") {
+		t.Errorf("expected pending copy to be removed")
+	}
+	event := readEvent(t, storage, "00000000000000000002")
+	if len(event) != 3 || event[0] != "xxx" || event[1] != StatusAccepted {
+		t.Errorf("unexpected event %v", event)
+	}
+}
+
+func TestEventOfCreatedTransactionIsNotLost(t *testing.T) {
+	tmpdir, storage := setupEventStorage(t)
+	defer os.RemoveAll(tmpdir)
+
+	if err := CreateTransaction(storage, failingEventLog(t, storage), newTransaction(StatusNew)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if !HasPendingUpdate(storage, "xxx") {
+		t.Fatalf("expected pending copy to be kept")
+	}
+
+	events := NewEventLog(storage)
+	if err := UpdateTransaction(storage, events, newTransaction(StatusAccepted)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	created := readEvent(t, storage, "00000000000000000001")
+	if len(created) != 3 || created[1] != StatusNew {
+		t.Errorf("expected event of creation to be appended before update got %v", created)
+	}
+	updated := readEvent(t, storage, "00000000000000000002")
+	if len(updated) != 3 || updated[1] != StatusAccepted {
+		t.Errorf("unexpected event %v", updated)
+	}
+	if HasPendingUpdate(storage, "xxx") {
+		t.Errorf("expected pending copy to be removed")
+	}
+}
+
+func TestCreationIndex(t *testing.T) {
+	tmpdir, storage := setupEventStorage(t)
+	defer os.RemoveAll(tmpdir)
+
+	if _, ok := LoadCreation(storage, "xxx"); ok {
+		t.Errorf("expected no creation of unknown transaction")
+	}
+
+	events := NewEventLog(storage)
+	if err := events.Append(newTransaction(StatusNew)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	created, ok := LoadCreation(storage, "xxx")
+	if !ok {
+		t.Fatalf("expected creation to be indexed")
+	}
+	event := readEvent(t, storage, "00000000000000000001")
+	if created.Format(time.RFC3339Nano) != event[2] {
+		t.Errorf("expected creation at time of first event %s got %s", event[2], created.Format(time.RFC3339Nano))
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := events.Append(newTransaction(StatusCommitted)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if at, _ := LoadCreation(storage, "xxx"); !at.Equal(created) {
+		t.Errorf("expected creation to be kept after update got %s", at)
+	}
+
+	storage.WriteFile("creation/yyy", []byte("2020-01-0"))
+	if _, ok := LoadCreation(storage, "yyy"); ok {
+		t.Errorf("expected torn creation to be ignored")
+	}
+}
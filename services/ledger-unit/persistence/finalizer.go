@@ -0,0 +1,27 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"time"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// UpdateLastFinalizerScan persist time of last successful stale transactions
+// scan
+func UpdateLastFinalizerScan(storage localfs.Storage, at time.Time) error {
+	return storage.WriteFile("finalizer", []byte(at.UTC().Format(time.RFC3339Nano)))
+}
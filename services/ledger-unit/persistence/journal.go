@@ -15,47 +15,212 @@
 package persistence
 
 import (
+	"fmt"
+	"hash/crc32"
+
 	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/support/naming"
 
 	localfs "github.com/jancajthaml-openbank/local-fs"
 )
 
+// loadTransactionData returns journal entry of transaction, intact pending
+// copy of interrupted update takes precedence over possibly torn journal entry
+func loadTransactionData(storage localfs.Storage, id string) ([]byte, error) {
+	if data, ok := LoadPendingData(storage, id); ok {
+		return data, nil
+	}
+	return storage.ReadFileFully("transaction/" + id)
+}
+
+// loadPendingData returns pending copy of transaction if it was fully written
+func LoadPendingData(storage localfs.Storage, id string) ([]byte, bool) {
+	data, err := storage.ReadFileFully("pending/" + id)
+	if err != nil || len(data) < 9 || string(data[:9]) != checksumOf(data[9:]) {
+		return nil, false
+	}
+	return data[9:], true
+}
+
+// checksumOf returns header line of pending copy of given data
+func checksumOf(data []byte) string {
+	return fmt.Sprintf("%08x\n", crc32.ChecksumIEEE(data))
+}
+
+// HasPendingUpdate returns true if transaction has intact pending copy of
+// update that was not completed
+func HasPendingUpdate(storage localfs.Storage, id string) bool {
+	_, ok := LoadPendingData(storage, id)
+	return ok
+}
+
+// CompletePendingUpdate completes interrupted update of transaction from its
+// intact pending copy and appends its state change to event log, event of
+// update interrupted after it was appended is appended again
+func CompletePendingUpdate(storage localfs.Storage, events *EventLog, id string) error {
+	data, ok := LoadPendingData(storage, id)
+	if !ok {
+		return fmt.Errorf("transaction %s has no intact pending copy", id)
+	}
+	if err := completePendingData(storage, events, id, data); err != nil {
+		return err
+	}
+	return storage.DeleteFile("pending/" + id)
+}
+
+// completePendingData writes pending copy of transaction to journal and
+// appends its state change to event log
+func completePendingData(storage localfs.Storage, events *EventLog, id string, data []byte) error {
+	if err := storage.WriteFile("transaction/"+id, data); err != nil {
+		return err
+	}
+	entity := new(model.Transaction)
+	entity.IDTransaction = id
+	if err := entity.DeserializeState(data); err != nil {
+		return fmt.Errorf("transaction %s %+v", id, err)
+	}
+	return events.Append(entity)
+}
+
 // LoadTransaction loads transaction from journal
 func LoadTransaction(storage localfs.Storage, id string) (*model.Transaction, error) {
-	transactionPath := "transaction/" + id
-	data, err := storage.ReadFileFully(transactionPath)
+	data, err := loadTransactionData(storage, id)
 	if err != nil {
 		return nil, err
 	}
 	result := new(model.Transaction)
 	result.IDTransaction = id
-	result.Deserialize(data)
+	if err = result.Deserialize(data); err != nil {
+		return nil, fmt.Errorf("transaction %s %+v", id, err)
+	}
 	return result, nil
 }
 
 // LoadTransactionState loads transaction status journal
 func LoadTransactionState(storage localfs.Storage, id string) (string, error) {
-	transactionPath := "transaction/" + id
-	data, err := storage.ReadFileFully(transactionPath)
+	data, err := loadTransactionData(storage, id)
 	if err != nil {
 		return "", err
 	}
 	result := new(model.Transaction)
 	result.IDTransaction = id
-	result.DeserializeState(data)
+	if err = result.DeserializeState(data); err != nil {
+		return "", fmt.Errorf("transaction %s %+v", id, err)
+	}
 	return result.State, nil
 }
 
-// CreateTransaction persist transaction entity state to storage
-func CreateTransaction(storage localfs.Storage, entity *model.Transaction) error {
+// CreateTransaction persist transaction entity state to storage and appends
+// its state change to event log, when event cannot be appended pending copy
+// of transaction is left behind so that event is appended when it is
+// completed
+func CreateTransaction(storage localfs.Storage, events *EventLog, entity *model.Transaction) error {
 	transactionPath := "transaction/" + entity.IDTransaction
+	pendingPath := "pending/" + entity.IDTransaction
 	data := entity.Serialize()
-	return storage.WriteFileExclusive(transactionPath, data)
+	existed, _ := storage.Exists(transactionPath)
+	err := storage.WriteFileExclusive(transactionPath, data)
+	if err != nil {
+		// entry torn by failed write would be recovered with truncated
+		// transfers, no order was sent for it yet
+		if !existed {
+			storage.DeleteFile(transactionPath)
+		}
+		return err
+	}
+	if err = events.Append(entity); err == nil {
+		return nil
+	}
+	log.Warn().Msgf("Failed to append event of transaction %s %+v", entity.IDTransaction, err)
+	if err = storage.WriteFile(pendingPath, append([]byte(checksumOf(data)), data...)); err != nil {
+		storage.DeleteFile(transactionPath)
+		return err
+	}
+	return nil
 }
 
-// UpdateTransaction persist update of transaction to disk
-func UpdateTransaction(storage localfs.Storage, entity *model.Transaction) error {
+// UpdateTransaction persist update of transaction to disk and appends its
+// state change to event log, checksummed pending copy is written first so
+// that update torn by crash is completed from it when loaded, pending copy of
+// previous interrupted update is completed before it is replaced, pending
+// copy is removed only after event was appended so that event which cannot
+// be appended is appended when pending copy is completed
+func UpdateTransaction(storage localfs.Storage, events *EventLog, entity *model.Transaction) error {
 	transactionPath := "transaction/" + entity.IDTransaction
+	pendingPath := "pending/" + entity.IDTransaction
+	if previous, ok := LoadPendingData(storage, entity.IDTransaction); ok {
+		if err := completePendingData(storage, events, entity.IDTransaction, previous); err != nil {
+			return err
+		}
+	}
 	data := entity.Serialize()
-	return storage.WriteFile(transactionPath, data)
+	err := storage.WriteFile(pendingPath, append([]byte(checksumOf(data)), data...))
+	if err != nil {
+		return err
+	}
+	err = storage.WriteFile(transactionPath, data)
+	if err != nil {
+		return err
+	}
+	if err = events.Append(entity); err != nil {
+		log.Warn().Msgf("Failed to append event of transaction %s %+v", entity.IDTransaction, err)
+		return nil
+	}
+	if err = storage.DeleteFile(pendingPath); err != nil {
+		log.Warn().Msgf("Failed to remove pending copy of transaction %s %+v", entity.IDTransaction, err)
+	}
+	return nil
+}
+
+// CreateInboundReference persist reference of transaction of origin tenant
+// into journal of counterparty tenant, rewriting existing reference is no-op
+func CreateInboundReference(storage localfs.Storage, tenant string, origin string, entity *model.Transaction) error {
+	if err := naming.ValidateTenant(tenant); err != nil {
+		return err
+	}
+	referencePath := "t_" + tenant + "/inbound/" + origin + "/" + entity.IDTransaction
+	if data, err := storage.ReadFileFully(referencePath); err == nil && string(data) == entity.State {
+		return nil
+	}
+	return storage.WriteFile(referencePath, []byte(entity.State))
+}
+
+// CreateOutboundMark persist mark that references of transaction are yet to
+// be published to account index and its counterparty tenants, mark is
+// written before
+// transaction is committed so that publication interrupted by crash is
+// repeated on recovery
+func CreateOutboundMark(storage localfs.Storage, id string) error {
+	return storage.WriteFile("outbound/"+id, []byte{})
+}
+
+// HasOutboundMark returns true if references of transaction are yet to be
+// published
+func HasOutboundMark(storage localfs.Storage, id string) bool {
+	ok, err := storage.Exists("outbound/" + id)
+	return err == nil && ok
+}
+
+// DeleteOutboundMark removes mark of transaction with published references
+func DeleteOutboundMark(storage localfs.Storage, id string) error {
+	return storage.DeleteFile("outbound/" + id)
+}
+
+// PublishInboundReferences persist references of committed transaction of
+// origin tenant into journals of all its counterparty tenants and into account
+// index of all its accounts and removes outbound mark of transaction once all
+// of them are persisted
+func PublishInboundReferences(storage localfs.Storage, shared localfs.Storage, origin string, entity *model.Transaction) error {
+	for _, tenant := range entity.CounterpartyTenants(origin) {
+		if err := CreateInboundReference(shared, tenant, origin, entity); err != nil {
+			return fmt.Errorf("failed to publish reference to tenant %s %+v", tenant, err)
+		}
+	}
+	if err := IndexAccountEntries(shared, origin, entity); err != nil {
+		return fmt.Errorf("failed to index accounts %+v", err)
+	}
+	if !HasOutboundMark(storage, entity.IDTransaction) {
+		return nil
+	}
+	return DeleteOutboundMark(storage, entity.IDTransaction)
 }
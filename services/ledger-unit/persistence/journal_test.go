@@ -0,0 +1,204 @@
+package persistence
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/jancajthaml-openbank/ledger-unit/model"
+	"github.com/jancajthaml-openbank/ledger-unit/support/faults"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+	money "gopkg.in/inf.v0"
+)
+
+func newTransaction(state string) *model.Transaction {
+	return &model.Transaction{
+		IDTransaction: "xxx",
+		State:         state,
+		Transfers: []model.Transfer{
+			{
+				IDTransfer: "a",
+				Credit:     model.Account{Tenant: "one", Name: "credit"},
+				Debit:      model.Account{Tenant: "one", Name: "debit"},
+				ValueDate:  "2020-01-01T00:00:00Z",
+				Amount:     new(money.Dec).SetUnscaled(1),
+				Currency:   "EUR",
+			},
+		},
+	}
+}
+
+func TestUpdateTransaction(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	if err := CreateTransaction(storage, nil, newTransaction(StatusNew)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if err := UpdateTransaction(storage, nil, newTransaction(StatusAccepted)); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if ok, _ := storage.Exists("pending/xxx"); ok {
+		t.Errorf("expected pending copy to be removed after update")
+	}
+	if state, _ := LoadTransactionState(storage, "xxx"); state != StatusAccepted {
+		t.Errorf("expected state %s got %s", StatusAccepted, state)
+	}
+}
+
+func TestCreateTornTransaction(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	t.Log("torn journal entry of new transaction is removed")
+	{
+		injector, err := faults.NewInjector("partial-write@transaction/", 1, nil)
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		torn := injector.Storage(storage)
+
+		if err := CreateTransaction(torn, nil, newTransaction(StatusNew)); err == nil {
+			t.Fatalf("expected injected write failure")
+		}
+		if ok, _ := storage.Exists("transaction/xxx"); ok {
+			t.Errorf("expected torn journal entry to be removed")
+		}
+	}
+
+	t.Log("existing journal entry is kept")
+	{
+		if err := CreateTransaction(storage, nil, newTransaction(StatusCommitted)); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if err := CreateTransaction(storage, nil, newTransaction(StatusNew)); err == nil {
+			t.Fatalf("expected duplicate to be refused")
+		}
+		if state, _ := LoadTransactionState(storage, "xxx"); state != StatusCommitted {
+			t.Errorf("expected state %s got %s", StatusCommitted, state)
+		}
+	}
+}
+
+func TestLoadTornTransaction(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	storage, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	t.Log("torn journal entry without pending copy is unreadable")
+	{
+		storage.WriteFile("transaction/xxx", []byte("acc"))
+
+		if _, err := LoadTransaction(storage, "xxx"); err == nil {
+			t.Errorf("expected torn journal entry to be unreadable")
+		}
+		if _, err := LoadTransactionState(storage, "xxx"); err == nil {
+			t.Errorf("expected torn journal entry to be unreadable")
+		}
+	}
+
+	t.Log("torn journal entry is completed from pending copy")
+	{
+		data := newTransaction(StatusAccepted).Serialize()
+		storage.WriteFile("pending/xxx", append([]byte("00000000\n"), data...))
+
+		if _, err := LoadTransaction(storage, "xxx"); err == nil {
+			t.Errorf("expected pending copy with invalid checksum to be ignored")
+		}
+
+		pending := newTransaction(StatusCommitted).Serialize()
+		storage.WriteFile("pending/xxx", append([]byte(checksumOf(pending)), pending...))
+
+		transaction, err := LoadTransaction(storage, "xxx")
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if transaction.State != StatusCommitted || len(transaction.Transfers) != 1 {
+			t.Errorf("expected transaction from pending copy got %+v", transaction)
+		}
+	}
+
+	t.Log("pending copy of interrupted update is completed before it is replaced")
+	{
+		pending := newTransaction(StatusAccepted).Serialize()
+		storage.WriteFile("pending/xxx", append([]byte(checksumOf(pending)), pending...))
+		storage.WriteFile("transaction/xxx", []byte("ne"))
+
+		if err := UpdateTransaction(storage, nil, newTransaction(StatusCommitted)); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if state, _ := LoadTransactionState(storage, "xxx"); state != StatusCommitted {
+			t.Errorf("expected state %s got %s", StatusCommitted, state)
+		}
+	}
+}
+
+func TestPublishInboundReferences(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	shared, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	storage, err := localfs.NewPlaintextStorage(tmpdir + "/t_one")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	transaction := newTransaction(StatusCommitted)
+	transaction.Transfers[0].Debit.Tenant = "two"
+
+	if err := CreateOutboundMark(storage, transaction.IDTransaction); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := PublishInboundReferences(storage, shared, "one", transaction); err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		data, err := shared.ReadFileFully("t_two/inbound/one/xxx")
+		if err != nil || string(data) != StatusCommitted {
+			t.Errorf("expected reference %s got %q %+v", StatusCommitted, string(data), err)
+		}
+		if HasOutboundMark(storage, transaction.IDTransaction) {
+			t.Errorf("expected mark to be removed after publication")
+		}
+		for _, path := range []string{"t_one/account/credit/one/xxx", "t_two/account/debit/one/xxx"} {
+			if ok, _ := shared.Exists(path); !ok {
+				t.Errorf("expected account index entry %s", path)
+			}
+		}
+	}
+
+	if ok, _ := shared.Exists("t_one/inbound"); ok {
+		t.Errorf("expected no reference of transaction to its own tenant")
+	}
+}
@@ -61,10 +61,11 @@ func (daemon ScheduledDaemon) Start(parentContext context.Context, cancelFunctio
 		return
 	}
 	log.Info().Msgf("Start daemon %s run each %v", daemon.name, daemon.interval)
+loop:
 	for {
 		select {
 		case <-parentContext.Done():
-			break
+			break loop
 		case <-ticker.C:
 			daemon.Work()
 		}
@@ -0,0 +1,225 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package faults
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Checkpoint names point of saga at which process may be aborted
+type Checkpoint string
+
+// Checkpoints of transaction saga
+const (
+	// AfterPromisePersisted is reached once new transaction is in journal and
+	// before promise orders are sent
+	AfterPromisePersisted Checkpoint = "after-promise-persisted"
+	// AfterAcceptedPersisted is reached once accepted transaction is in journal
+	// and before commit orders are sent
+	AfterAcceptedPersisted Checkpoint = "after-accepted-persisted"
+	// AfterCommitSent is reached once commit orders are sent
+	AfterCommitSent Checkpoint = "after-commit-sent"
+)
+
+// Kinds of injected faults
+const (
+	// KindAbort aborts process at checkpoint
+	KindAbort = "abort"
+	// KindFailWrite fails write of file without writing anything
+	KindFailWrite = "fail-write"
+	// KindPartialWrite writes only prefix of data and fails write of file
+	KindPartialWrite = "partial-write"
+	// KindDropMessage silently drops outgoing message
+	KindDropMessage = "drop-message"
+)
+
+type rule struct {
+	kind        string
+	target      string
+	probability float64
+}
+
+// Injector decides which faults happen, nil injector injects nothing
+type Injector struct {
+	mutex   sync.Mutex
+	rules   []rule
+	random  *rand.Rand
+	aborted bool
+	onAbort func(Checkpoint)
+}
+
+// NewInjector returns injector of faults described by spec, spec is comma
+// separated list of rules "<kind>@<target>[:<probability>]" where target is
+// checkpoint name for abort, path prefix for writes and message prefix for
+// messages, empty spec disables fault injection, invalid spec is error so
+// that faults are never silently left out
+func NewInjector(spec string, seed int64, onAbort func(Checkpoint)) (*Injector, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	rules, err := parseSpec(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fault injection spec %q %+v", spec, err)
+	}
+	log.Warn().Msgf("Fault injection enabled %q", spec)
+	return &Injector{
+		rules:   rules,
+		random:  rand.New(rand.NewSource(seed)),
+		onAbort: onAbort,
+	}, nil
+}
+
+func parseSpec(spec string) ([]rule, error) {
+	result := make([]rule, 0)
+	for _, chunk := range strings.Split(spec, ",") {
+		chunk = strings.TrimSpace(chunk)
+		if chunk == "" {
+			continue
+		}
+		at := strings.IndexByte(chunk, '@')
+		if at < 0 {
+			return nil, fmt.Errorf("rule %q has no target", chunk)
+		}
+		item := rule{
+			kind:        chunk[:at],
+			target:      chunk[at+1:],
+			probability: 1,
+		}
+		if colon := strings.LastIndexByte(item.target, ':'); colon >= 0 {
+			probability, err := strconv.ParseFloat(item.target[colon+1:], 64)
+			if err != nil || probability <= 0 || probability > 1 {
+				return nil, fmt.Errorf("rule %q has invalid probability", chunk)
+			}
+			item.target = item.target[:colon]
+			item.probability = probability
+		}
+		switch item.kind {
+		case KindAbort:
+			switch Checkpoint(item.target) {
+			case AfterPromisePersisted, AfterAcceptedPersisted, AfterCommitSent:
+			default:
+				return nil, fmt.Errorf("rule %q has unknown checkpoint", chunk)
+			}
+		case KindFailWrite, KindPartialWrite, KindDropMessage:
+		default:
+			return nil, fmt.Errorf("rule %q has unknown kind", chunk)
+		}
+		result = append(result, item)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no rules")
+	}
+	return result, nil
+}
+
+// fires returns first rule of given kind matching target that fires, caller
+// must hold mutex
+func (injector *Injector) fires(kind string, matches func(target string) bool) bool {
+	for _, item := range injector.rules {
+		if item.kind != kind || !matches(item.target) {
+			continue
+		}
+		if item.probability >= 1 || injector.random.Float64() < item.probability {
+			return true
+		}
+	}
+	return false
+}
+
+// Aborted tells whenever process was aborted at some checkpoint, aborted
+// process neither writes nor sends anything
+func (injector *Injector) Aborted() bool {
+	if injector == nil {
+		return false
+	}
+	injector.mutex.Lock()
+	defer injector.mutex.Unlock()
+	return injector.aborted
+}
+
+// Checkpoint aborts process if abort at given checkpoint fires, returns true
+// if process is aborted
+func (injector *Injector) Checkpoint(checkpoint Checkpoint) bool {
+	if injector == nil {
+		return false
+	}
+	injector.mutex.Lock()
+	if injector.aborted {
+		injector.mutex.Unlock()
+		return true
+	}
+	if !injector.fires(KindAbort, func(target string) bool { return target == string(checkpoint) }) {
+		injector.mutex.Unlock()
+		return false
+	}
+	injector.aborted = true
+	injector.mutex.Unlock()
+	log.Error().Msgf("Aborting at checkpoint %s", checkpoint)
+	if injector.onAbort != nil {
+		injector.onAbort(checkpoint)
+	}
+	return true
+}
+
+// DropMessage tells whenever outgoing message should be dropped
+func (injector *Injector) DropMessage(msg string) bool {
+	if injector == nil {
+		return false
+	}
+	injector.mutex.Lock()
+	defer injector.mutex.Unlock()
+	if injector.aborted {
+		return true
+	}
+	if injector.fires(KindDropMessage, func(target string) bool { return strings.HasPrefix(msg, target) }) {
+		log.Warn().Msgf("Dropping message %q", msg)
+		return true
+	}
+	return false
+}
+
+// writeFault returns kind of fault of write to given path or empty string
+func (injector *Injector) writeFault(path string) string {
+	if injector == nil {
+		return ""
+	}
+	injector.mutex.Lock()
+	defer injector.mutex.Unlock()
+	if injector.aborted {
+		return KindFailWrite
+	}
+	matches := func(target string) bool { return strings.HasPrefix(path, target) }
+	if injector.fires(KindFailWrite, matches) {
+		return KindFailWrite
+	}
+	if injector.fires(KindPartialWrite, matches) {
+		return KindPartialWrite
+	}
+	return ""
+}
+
+// partialLength returns length of torn prefix of data
+func (injector *Injector) partialLength(size int) int {
+	injector.mutex.Lock()
+	defer injector.mutex.Unlock()
+	if size < 2 {
+		return 0
+	}
+	return 1 + injector.random.Intn(size-1)
+}
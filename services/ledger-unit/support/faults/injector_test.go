@@ -0,0 +1,135 @@
+package faults
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+func TestNewInjector(t *testing.T) {
+	if injector, err := NewInjector("", 0, nil); injector != nil || err != nil {
+		t.Errorf("expected empty spec to disable fault injection")
+	}
+
+	for _, spec := range []string{
+		"abort",
+		"abort@nowhere",
+		"explode@transaction/",
+		"fail-write@transaction/:0",
+		"fail-write@transaction/:2",
+		"fail-write@transaction/:x",
+		",",
+	} {
+		if injector, err := NewInjector(spec, 0, nil); injector != nil || err == nil {
+			t.Errorf("expected spec %q to be invalid", spec)
+		}
+	}
+
+	if injector, err := NewInjector("abort@after-commit-sent, fail-write@transaction/:0.5,drop-message@NC", 0, nil); injector == nil || err != nil {
+		t.Errorf("expected spec to be valid %+v", err)
+	}
+}
+
+func mustInjector(t *testing.T, spec string, onAbort func(Checkpoint)) *Injector {
+	injector, err := NewInjector(spec, 0, onAbort)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	return injector
+}
+
+func TestNilInjector(t *testing.T) {
+	var injector *Injector
+
+	if injector.Checkpoint(AfterCommitSent) {
+		t.Errorf("expected nil injector not to abort")
+	}
+	if injector.DropMessage("NC") {
+		t.Errorf("expected nil injector not to drop messages")
+	}
+	if injector.Aborted() {
+		t.Errorf("expected nil injector not to be aborted")
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	aborts := make([]Checkpoint, 0)
+	injector := mustInjector(t, "abort@after-accepted-persisted", func(checkpoint Checkpoint) {
+		aborts = append(aborts, checkpoint)
+	})
+
+	if injector.Checkpoint(AfterPromisePersisted) {
+		t.Errorf("expected not to abort at %s", AfterPromisePersisted)
+	}
+	if !injector.Checkpoint(AfterAcceptedPersisted) {
+		t.Errorf("expected to abort at %s", AfterAcceptedPersisted)
+	}
+	if !injector.Checkpoint(AfterPromisePersisted) {
+		t.Errorf("expected aborted process to stay aborted")
+	}
+	if len(aborts) != 1 || aborts[0] != AfterAcceptedPersisted {
+		t.Errorf("expected single abort at %s got %v", AfterAcceptedPersisted, aborts)
+	}
+	if !injector.DropMessage("NC xxx 1 EUR") {
+		t.Errorf("expected aborted process to drop messages")
+	}
+}
+
+func TestDropMessage(t *testing.T) {
+	injector := mustInjector(t, "drop-message@NC", nil)
+
+	if injector.DropMessage("NP xxx 1 EUR") {
+		t.Errorf("expected promise not to be dropped")
+	}
+	if !injector.DropMessage("NC xxx 1 EUR") {
+		t.Errorf("expected commit to be dropped")
+	}
+}
+
+func TestStorage(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "faults")
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	underlying, err := localfs.NewPlaintextStorage(tmpdir)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+
+	injector := mustInjector(t, "fail-write@a/,partial-write@b/", nil)
+	storage := injector.Storage(underlying)
+
+	if err := storage.WriteFile("a/x", []byte("0123456789")); err == nil {
+		t.Errorf("expected write of a/x to fail")
+	}
+	if ok, _ := underlying.Exists("a/x"); ok {
+		t.Errorf("expected failed write not to create a/x")
+	}
+
+	if err := storage.WriteFile("b/x", []byte("0123456789")); err == nil {
+		t.Errorf("expected write of b/x to fail")
+	}
+	data, err := underlying.ReadFileFully("b/x")
+	if err != nil {
+		t.Fatalf("expected partial write to create b/x %+v", err)
+	}
+	if len(data) == 0 || len(data) >= 10 || string(data) != "0123456789"[:len(data)] {
+		t.Errorf("expected torn prefix of data got %q", string(data))
+	}
+
+	if err := storage.WriteFile("c/x", []byte("0123456789")); err != nil {
+		t.Errorf("unexpected error %+v", err)
+	}
+
+	if injector.Storage(underlying) == underlying {
+		t.Errorf("expected storage to be wrapped")
+	}
+	var disabled *Injector
+	if disabled.Storage(underlying) != underlying {
+		t.Errorf("expected nil injector not to wrap storage")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package faults
+
+import (
+	"fmt"
+
+	localfs "github.com/jancajthaml-openbank/local-fs"
+)
+
+// storage is localfs.Storage failing writes as decided by injector
+type storage struct {
+	localfs.Storage
+	injector *Injector
+}
+
+// Storage wraps given storage with write faults of injector, nil injector
+// returns storage as is
+func (injector *Injector) Storage(underlying localfs.Storage) localfs.Storage {
+	if injector == nil {
+		return underlying
+	}
+	return storage{
+		Storage:  underlying,
+		injector: injector,
+	}
+}
+
+func (storage storage) write(path string, data []byte, write func(string, []byte) error) error {
+	switch storage.injector.writeFault(path) {
+	case KindFailWrite:
+		return fmt.Errorf("injected write failure of %s", path)
+	case KindPartialWrite:
+		if err := write(path, data[:storage.injector.partialLength(len(data))]); err != nil {
+			return err
+		}
+		return fmt.Errorf("injected partial write of %s", path)
+	default:
+		return write(path, data)
+	}
+}
+
+// WriteFileExclusive creates file unless write fault fires
+func (storage storage) WriteFileExclusive(path string, data []byte) error {
+	return storage.write(path, data, storage.Storage.WriteFileExclusive)
+}
+
+// WriteFile writes file unless write fault fires
+func (storage storage) WriteFile(path string, data []byte) error {
+	return storage.write(path, data, storage.Storage.WriteFile)
+}
+
+// UpdateFile updates file unless write fault fires
+func (storage storage) UpdateFile(path string, data []byte) error {
+	return storage.write(path, data, storage.Storage.UpdateFile)
+}
+
+// AppendFile appends to file unless write fault fires
+func (storage storage) AppendFile(path string, data []byte) error {
+	return storage.write(path, data, storage.Storage.AppendFile)
+}
+
+// DeleteFile deletes file unless process was aborted
+func (storage storage) DeleteFile(path string) error {
+	if storage.injector.Aborted() {
+		return fmt.Errorf("injected delete failure of %s", path)
+	}
+	return storage.Storage.DeleteFile(path)
+}
@@ -0,0 +1,51 @@
+package host
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemoryMonitor(t *testing.T) {
+	t.Log("nil monitor is healthy and empty")
+	{
+		var monitor *MemoryMonitor
+		monitor.CheckMemoryAllocation()
+		if !monitor.IsHealthy() || monitor.GetFree() != 0 || monitor.GetUsed() != 0 {
+			t.Errorf("unexpected nil monitor state")
+		}
+	}
+
+	t.Log("healthy and empty by default")
+	{
+		monitor := NewMemoryMonitor(uint64(0))
+		if !monitor.IsHealthy() || monitor.GetFree() != 0 || monitor.GetUsed() != 0 {
+			t.Errorf("unexpected default monitor state")
+		}
+		atomic.StoreUint64(&(monitor.free), 10)
+		atomic.StoreUint64(&(monitor.used), 20)
+		if monitor.GetFree() != 10 || monitor.GetUsed() != 20 {
+			t.Errorf("expected free=10 used=20, got free=%d used=%d", monitor.GetFree(), monitor.GetUsed())
+		}
+	}
+
+	t.Log("healthy if available memory is above limit")
+	{
+		monitor := NewMemoryMonitor(uint64(1))
+		monitor.CheckMemoryAllocation()
+		if !monitor.IsHealthy() {
+			t.Errorf("expected to be healthy")
+		}
+		if monitor.GetFree() == 0 || monitor.GetUsed() == 0 {
+			t.Errorf("expected memory to be measured")
+		}
+	}
+
+	t.Log("unhealthy if available memory is under limit")
+	{
+		monitor := NewMemoryMonitor(^uint64(0))
+		monitor.CheckMemoryAllocation()
+		if monitor.IsHealthy() {
+			t.Errorf("expected to be unhealthy")
+		}
+	}
+}
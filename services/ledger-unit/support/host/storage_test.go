@@ -0,0 +1,57 @@
+package host
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestDiskMonitor(t *testing.T) {
+	t.Log("nil monitor is healthy and empty")
+	{
+		var monitor *DiskMonitor
+		monitor.CheckDiskSpace()
+		if !monitor.IsHealthy() || monitor.GetFree() != 0 || monitor.GetUsed() != 0 {
+			t.Errorf("unexpected nil monitor state")
+		}
+	}
+
+	t.Log("healthy and empty by default")
+	{
+		monitor := NewDiskMonitor(uint64(0), "/tmp")
+		if !monitor.IsHealthy() || monitor.GetFree() != 0 || monitor.GetUsed() != 0 {
+			t.Errorf("unexpected default monitor state")
+		}
+		atomic.StoreUint64(&(monitor.free), 10)
+		atomic.StoreUint64(&(monitor.used), 20)
+		if monitor.GetFree() != 10 || monitor.GetUsed() != 20 {
+			t.Errorf("expected free=10 used=20, got free=%d used=%d", monitor.GetFree(), monitor.GetUsed())
+		}
+	}
+
+	t.Log("healthy if available space is above limit")
+	{
+		monitor := NewDiskMonitor(uint64(1), "/tmp")
+		monitor.CheckDiskSpace()
+		if !monitor.IsHealthy() {
+			t.Errorf("expected to be healthy")
+		}
+	}
+
+	t.Log("unhealthy if available space is under limit")
+	{
+		monitor := NewDiskMonitor(^uint64(0), "/tmp")
+		monitor.CheckDiskSpace()
+		if monitor.IsHealthy() {
+			t.Errorf("expected to be unhealthy")
+		}
+	}
+
+	t.Log("unhealthy if storage does not exist")
+	{
+		monitor := NewDiskMonitor(uint64(0), "/nonexistent/storage")
+		monitor.CheckDiskSpace()
+		if monitor.IsHealthy() {
+			t.Errorf("expected to be unhealthy")
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTenant(t *testing.T) {
+	t.Log("valid names")
+	{
+		for _, name := range []string{"a", "T1", "CONFIGURATION_DEBUG", "lorem-ipsum", "0", strings.Repeat("x", MaxTenantLength)} {
+			if err := ValidateTenant(name); err != nil {
+				t.Errorf("%s should be valid tenant name but %+v", name, err)
+			}
+		}
+	}
+
+	t.Log("invalid names")
+	{
+		for _, name := range []string{"", "a/b", "..", "a@b", "a.service", "a b", "-a", "_a", "č", strings.Repeat("x", MaxTenantLength+1)} {
+			if ValidateTenant(name) == nil {
+				t.Errorf("%s should not be valid tenant name", name)
+			}
+		}
+	}
+
+	t.Log("subcommands of ledger-unit")
+	{
+		for _, name := range []string{"fsck", "FSCK", "export"} {
+			if ValidateTenant(name) == nil {
+				t.Errorf("%s should be reserved tenant name", name)
+			}
+		}
+	}
+
+	t.Log("reserved names")
+	{
+		defer func(names []string) {
+			ReservedTenantNames = names
+		}(ReservedTenantNames)
+		ReservedTenantNames = []string{"subcommand"}
+
+		for _, name := range []string{"subcommand", "SubCommand"} {
+			if ValidateTenant(name) == nil {
+				t.Errorf("%s should be reserved tenant name", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"testing"
+)
+
+func TestTraceparent(t *testing.T) {
+	t.Log("round trips valid traceparent")
+	{
+		value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		ctx, err := ParseTraceparent(value)
+		if err != nil {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if ctx.String() != value {
+			t.Errorf("expected %s got %s", value, ctx.String())
+		}
+		child := ctx.Child()
+		if child.TraceID != ctx.TraceID || child.SpanID == ctx.SpanID || child.Flags != ctx.Flags {
+			t.Errorf("expected child span of same trace got %s", child.String())
+		}
+	}
+
+	t.Log("rejects invalid traceparent")
+	{
+		for _, value := range []string{
+			"",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			"00-xyz-00f067aa0ba902b7-01",
+		} {
+			if _, err := ParseTraceparent(value); err == nil {
+				t.Errorf("expected error for %q", value)
+			}
+		}
+	}
+
+	t.Log("starts new trace from invalid context")
+	{
+		ctx := SpanContext{}.Child()
+		if !ctx.IsValid() || ctx.Flags != FlagSampled {
+			t.Errorf("expected new sampled trace got %s", ctx.String())
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2016-2020, Jan Cajthaml <jan.cajthaml@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Span kinds as defined by OTLP
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// Span represents finished unit of work within trace
+type Span struct {
+	Name       string
+	Kind       int
+	Context    SpanContext
+	Parent     SpanContext
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	Failed     bool
+}
+
+// Exporter batches finished spans and pushes them to OTLP/HTTP collector
+// endpoint in JSON encoding
+type Exporter struct {
+	endpoint string
+	service  string
+	client   *http.Client
+	queue    chan Span
+}
+
+const exporterQueueSize = 4096
+const exporterBatchSize = 512
+
+// NewExporter returns exporter pushing spans of given service to endpoint,
+// empty endpoint disables export
+func NewExporter(endpoint string, service string) *Exporter {
+	return &Exporter{
+		endpoint: endpoint,
+		service:  service,
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		queue: make(chan Span, exporterQueueSize),
+	}
+}
+
+// Export queues finished span, span is dropped when queue is full
+func (exporter *Exporter) Export(span Span) {
+	if exporter == nil || exporter.endpoint == "" || !span.Context.IsValid() {
+		return
+	}
+	if span.Context.Flags&FlagSampled == 0 {
+		return
+	}
+	select {
+	case exporter.queue <- span:
+	default:
+		log.Debug().Msgf("Dropping span %s, queue is full", span.Name)
+	}
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func attributes(values map[string]string) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(values))
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result = append(result, otlpAttribute{
+			Key:   key,
+			Value: otlpValue{StringValue: values[key]},
+		})
+	}
+	return result
+}
+
+// Marshal encodes spans as OTLP export request in JSON encoding
+func (exporter *Exporter) Marshal(spans []Span) ([]byte, error) {
+	if exporter == nil {
+		return nil, fmt.Errorf("nil pointer")
+	}
+	items := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		item := otlpSpan{
+			TraceID:           span.Context.TraceIDString(),
+			SpanID:            span.Context.SpanIDString(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        attributes(span.Attributes),
+			Status:            otlpStatus{Code: 1},
+		}
+		if span.Parent.IsValid() && span.Parent.TraceID == span.Context.TraceID {
+			item.ParentSpanID = span.Parent.SpanIDString()
+		}
+		if span.Failed {
+			item.Status.Code = 2
+		}
+		items = append(items, item)
+	}
+	return json.Marshal(otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: attributes(map[string]string{
+						"service.name": exporter.service,
+					}),
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: "ledger"},
+						Spans: items,
+					},
+				},
+			},
+		},
+	})
+}
+
+func (exporter *Exporter) drain() []Span {
+	spans := make([]Span, 0)
+	for len(spans) < exporterBatchSize {
+		select {
+		case span := <-exporter.queue:
+			spans = append(spans, span)
+		default:
+			return spans
+		}
+	}
+	return spans
+}
+
+func (exporter *Exporter) push(spans []Span) error {
+	chunk, err := exporter.Marshal(spans)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, exporter.endpoint, bytes.NewReader(chunk))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := exporter.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector responded with %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Setup does nothing
+func (exporter *Exporter) Setup() error {
+	return nil
+}
+
+// Work pushes queued spans to collector
+func (exporter *Exporter) Work() {
+	if exporter == nil || exporter.endpoint == "" {
+		return
+	}
+	for {
+		spans := exporter.drain()
+		if len(spans) == 0 {
+			return
+		}
+		if err := exporter.push(spans); err != nil {
+			log.Warn().Msgf("Failed to export %d spans %+v", len(spans), err)
+			return
+		}
+	}
+}
+
+// Cancel does nothing
+func (exporter *Exporter) Cancel() {
+}
+
+// Done always returns done
+func (exporter *Exporter) Done() <-chan interface{} {
+	done := make(chan interface{})
+	close(done)
+	return done
+}